@@ -2,11 +2,12 @@
 SETLOCAL
 SET GO111MODULE=on
 SET GOFLAGS=-mod=vendor
+IF "%VERSION%"=="" SET VERSION=dev
 SET GOOS=windows
 SET GOARCH=amd64
 PUSHD "%~dp0..\src"
-GO.EXE build -i -o ..\bin\ServerWatchdog.exe .
+GO.EXE build -i -ldflags "-X main.version=%VERSION%" -o ..\bin\ServerWatchdog.exe .
 SET GOOS=linux
-GO.EXE build -i -o ..\bin\serverwatchdog .
+GO.EXE build -i -ldflags "-X main.version=%VERSION%" -o ..\bin\serverwatchdog .
 POPD
 ENDLOCAL
@@ -16,7 +16,12 @@ ORIG_DIR=$(pwd)
 GO111MODULE=on
 GOFLAGS=-mod=vendor
 
+#version reported by --version
+if [ -z "$VERSION" ]; then
+  VERSION=dev
+fi
+
 #compile
 cd $BASE_DIR/../src
-go build -i -o ../bin/server_watchdog .
+go build -i -ldflags "-X main.version=$VERSION" -o ../bin/server_watchdog .
 cd $ORIG_DIR
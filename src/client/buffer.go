@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+func (c *Client) addToBuffer(r NotifyRequest) {
+	c.bufferMtx.Lock()
+	if len(c.buffer) >= c.opts.BufferSize {
+		c.buffer = c.buffer[1:]
+		c.bufferRemoved += 1
+	}
+	c.buffer = append(c.buffer, r)
+	c.bufferMtx.Unlock()
+	return
+}
+
+func (c *Client) flushLoop() {
+	ticker := time.NewTicker(c.opts.FlushInterval)
+
+	for {
+		select {
+		case <-c.stopCh:
+			ticker.Stop()
+			c.stopped.Done()
+			return
+
+		case <-ticker.C:
+			if c.Buffered() > 0 {
+				ctx, cancel := context.WithCancel(context.Background())
+				go func() {
+					select {
+					case <-c.stopCh:
+						cancel()
+					case <-ctx.Done():
+					}
+				}()
+
+				_ = c.flush(ctx)
+				cancel()
+			}
+		}
+	}
+}
+
+// flush sends the buffered notifications in batches. Notifications rejected by the server are discarded because
+// sending them again would fail too, except the ones exceeding the rate limit.
+func (c *Client) flush(ctx context.Context) error {
+	for {
+		c.bufferMtx.Lock()
+		count := len(c.buffer)
+		if count > maxNotifyBatchSize {
+			count = maxNotifyBatchSize
+		}
+		batch := make([]NotifyRequest, count)
+		copy(batch, c.buffer[:count])
+		removedBefore := c.bufferRemoved
+		c.bufferMtx.Unlock()
+
+		if count == 0 {
+			return nil
+		}
+
+		var res notifyBatchResponse
+		err := c.do(ctx, http.MethodPost, "/notify/batch", batch, true, &res)
+		if err != nil && isTemporary(err) {
+			return err
+		}
+
+		//the server processes the notifications in order so the rate limited ones are at the end of the batch
+		rateLimited := 0
+		if err == nil {
+			for idx := len(res.Results) - 1; idx >= 0 && res.Results[idx].RateLimited; idx-- {
+				rateLimited += 1
+			}
+			count -= rateLimited
+		}
+
+		//remove the sent batch. new notifications are only appended so the batch is still at the start of the
+		//buffer, except the ones discarded meanwhile because it was full
+		c.bufferMtx.Lock()
+		if discarded := int(c.bufferRemoved - removedBefore); discarded < count {
+			c.buffer = c.buffer[count - discarded:]
+			c.bufferRemoved += uint64(count - discarded)
+		}
+		c.bufferMtx.Unlock()
+
+		if rateLimited > 0 {
+			return &APIError{
+				StatusCode: http.StatusTooManyRequests,
+			}
+		}
+	}
+}
@@ -0,0 +1,394 @@
+// Package client implements a client of the server watchdog HTTP API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	defaultTimeout       = 10 * time.Second
+	defaultMaxRetries    = 3
+	defaultRetryDelay    = 500 * time.Millisecond
+	defaultMaxRetryDelay = 10 * time.Second
+	defaultBufferSize    = 1000
+	defaultFlushInterval = 30 * time.Second
+
+	maxNotifyBatchSize = 100
+	maxErrorBodySize   = 4096
+)
+
+//------------------------------------------------------------------------------
+
+// Options defines the settings of a client
+type Options struct {
+	// Url is the base url of the server, i.e. http://my-server:3004
+	Url string
+
+	// ApiKey is sent in every request
+	ApiKey string
+
+	// Timeout of each request attempt. Defaults to 10 seconds.
+	Timeout time.Duration
+
+	// MaxRetries is the number of times a request is retried when the server is unreachable, returns a server error
+	// or throttles the client. Defaults to 3. Use a negative value to disable retries.
+	MaxRetries int
+
+	// RetryDelay is the time to wait before the first retry. It doubles on each retry up to MaxRetryDelay. Defaults
+	// to 500 milliseconds and 10 seconds.
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+
+	// BufferSize is the maximum number of notifications kept while the server is unreachable. When full, the oldest
+	// ones are discarded. Defaults to 1000.
+	BufferSize int
+
+	// FlushInterval is how often buffered notifications are sent again. Defaults to 30 seconds.
+	FlushInterval time.Duration
+
+	// DisableBuffering makes Notify return the error instead of buffering the notification
+	DisableBuffering bool
+
+	// HttpClient is used to send the requests, if specified. Useful to set up TLS client certificates.
+	HttpClient *http.Client
+}
+
+// Client sends requests to a server watchdog
+type Client struct {
+	opts       Options
+	baseUrl    string
+	httpClient *http.Client
+
+	bufferMtx     sync.Mutex
+	buffer        []NotifyRequest
+	bufferRemoved uint64
+
+	stopCh    chan struct{}
+	stopped   sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// APIError is returned when the server rejects a request
+type APIError struct {
+	StatusCode int
+	Message    string
+	retryAfter time.Duration
+}
+
+//------------------------------------------------------------------------------
+
+// ErrBuffered is returned by Notify when the server cannot be reached and the notification was buffered to be sent
+// later
+var ErrBuffered = errors.New("Server unreachable. Notification buffered.")
+
+//------------------------------------------------------------------------------
+
+// New creates a client. Close must be called when done to send the buffered notifications.
+func New(opts Options) (*Client, error) {
+	u, err := url.Parse(opts.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return nil, errors.New("Invalid server url")
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = defaultMaxRetries
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = defaultRetryDelay
+	}
+	if opts.MaxRetryDelay <= 0 {
+		opts.MaxRetryDelay = defaultMaxRetryDelay
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = defaultBufferSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultFlushInterval
+	}
+
+	c := &Client{
+		opts:       opts,
+		baseUrl:    strings.TrimSuffix(opts.Url, "/"),
+		httpClient: opts.HttpClient,
+		stopCh:     make(chan struct{}),
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{}
+	}
+
+	if !opts.DisableBuffering {
+		c.stopped.Add(1)
+		go c.flushLoop()
+	}
+
+	return c, nil
+}
+
+// Close stops the background sending of buffered notifications and makes a last attempt to send them
+func (c *Client) Close() error {
+	var err error
+
+	c.closeOnce.Do(func() {
+		close(c.stopCh)
+		c.stopped.Wait()
+
+		if c.Buffered() > 0 {
+			ctx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout)
+			err = c.flush(ctx)
+			cancel()
+		}
+	})
+	return err
+}
+
+// Ping checks if the server is alive
+func (c *Client) Ping(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/ping", nil, false, nil)
+}
+
+// Notify sends a notification. If the server is unreachable after all the retries, the notification is buffered and
+// ErrBuffered is returned.
+func (c *Client) Notify(ctx context.Context, r NotifyRequest) error {
+	//keep the time the event happened because retries and buffering can delay its delivery
+	if r.Timestamp == nil {
+		now := time.Now().UTC()
+		r.Timestamp = &now
+	}
+
+	err := c.do(ctx, http.MethodPost, "/notify", &r, true, nil)
+	if err != nil && !c.opts.DisableBuffering && isTemporary(err) {
+		c.addToBuffer(r)
+		return ErrBuffered
+	}
+	return err
+}
+
+// NotifyBatch sends many notifications at once. The result of each one is returned in the same order.
+func (c *Client) NotifyBatch(ctx context.Context, r []NotifyRequest) ([]NotifyResult, error) {
+	results := make([]NotifyResult, 0, len(r))
+
+	for len(r) > 0 {
+		count := len(r)
+		if count > maxNotifyBatchSize {
+			count = maxNotifyBatchSize
+		}
+
+		var res notifyBatchResponse
+		err := c.do(ctx, http.MethodPost, "/notify/batch", r[:count], true, &res)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, res.Results...)
+
+		r = r[count:]
+	}
+
+	return results, nil
+}
+
+// WatchProcess adds a process to the watch list
+func (c *Client) WatchProcess(ctx context.Context, r WatchProcessRequest) error {
+	return c.do(ctx, http.MethodPost, "/process/watch", &r, false, nil)
+}
+
+// WatchSelf adds the calling process to the watch list. If not specified, the name of the executable is used as the
+// process name.
+func (c *Client) WatchSelf(ctx context.Context, r WatchProcessRequest) error {
+	r.Pid = os.Getpid()
+	if len(r.Name) == 0 {
+		if exe, err := os.Executable(); err == nil {
+			r.Name = filepath.Base(exe)
+		}
+	}
+	return c.WatchProcess(ctx, r)
+}
+
+// UnwatchProcess removes a process from the watch list
+func (c *Client) UnwatchProcess(ctx context.Context, r UnwatchProcessRequest) error {
+	return c.do(ctx, http.MethodPost, "/process/unwatch", &r, false, nil)
+}
+
+// Heartbeat sends a ping for the given heartbeat
+func (c *Client) Heartbeat(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodPost, "/heartbeat/" + url.PathEscape(name), nil, false, nil)
+}
+
+// StartJob marks the start of a job run
+func (c *Client) StartJob(ctx context.Context, name string, r StartJobRequest) error {
+	return c.do(ctx, http.MethodPost, "/jobs/" + url.PathEscape(name) + "/start", &r, false, nil)
+}
+
+// FinishJob marks the end of a successful job run
+func (c *Client) FinishJob(ctx context.Context, name string, r EndJobRequest) error {
+	return c.do(ctx, http.MethodPost, "/jobs/" + url.PathEscape(name) + "/finish", &r, false, nil)
+}
+
+// FailJob marks the end of a failed job run
+func (c *Client) FailJob(ctx context.Context, name string, r EndJobRequest) error {
+	return c.do(ctx, http.MethodPost, "/jobs/" + url.PathEscape(name) + "/fail", &r, false, nil)
+}
+
+// Status returns the state of all the monitors. It is the JSON document returned by the GET /status endpoint.
+func (c *Client) Status(ctx context.Context) (json.RawMessage, error) {
+	var res json.RawMessage
+
+	err := c.do(ctx, http.MethodGet, "/status", nil, false, &res)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Buffered returns the number of notifications waiting to be sent
+func (c *Client) Buffered() int {
+	c.bufferMtx.Lock()
+	defer c.bufferMtx.Unlock()
+
+	return len(c.buffer)
+}
+
+func (e *APIError) Error() string {
+	if len(e.Message) == 0 {
+		return "Server returned status " + strconv.Itoa(e.StatusCode)
+	}
+	return "Server returned status " + strconv.Itoa(e.StatusCode) + ": " + e.Message
+}
+
+//------------------------------------------------------------------------------
+
+// do sends a request, retrying it on temporary errors. Requests that must not be processed twice are sent with an
+// idempotency key that is kept across retries.
+func (c *Client) do(ctx context.Context, method string, path string, body interface{}, idempotent bool,
+                    out interface{}) error {
+	var payload []byte
+	var idempotencyKey string
+	var err error
+
+	if body != nil {
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+	if idempotent {
+		idempotencyKey = newIdempotencyKey()
+	}
+
+	delay := c.opts.RetryDelay
+	for attempt := 0; ; attempt++ {
+		err = c.send(ctx, method, path, payload, idempotencyKey, out)
+		if err == nil || !isTemporary(err) || attempt >= c.opts.MaxRetries {
+			return err
+		}
+
+		//honor the time requested by the server if throttled
+		wait := delay
+		if apiErr, ok := err.(*APIError); ok && apiErr.retryAfter > wait {
+			wait = apiErr.retryAfter
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+
+		delay *= 2
+		if delay > c.opts.MaxRetryDelay {
+			delay = c.opts.MaxRetryDelay
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method string, path string, payload []byte, idempotencyKey string,
+                      out interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	var bodyReader io.Reader
+	if payload != nil {
+		bodyReader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, c.baseUrl + path, bodyReader)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+
+	if len(c.opts.ApiKey) > 0 {
+		req.Header.Set("X-Api-Key", c.opts.ApiKey)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if len(idempotencyKey) > 0 {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+
+		apiErr := &APIError{
+			StatusCode: resp.StatusCode,
+			Message:    strings.TrimSpace(string(msg)),
+		}
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+			apiErr.retryAfter = time.Duration(secs) * time.Second
+		}
+		return apiErr
+	}
+
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+// isTemporary returns true if the request can succeed if sent again, i.e. the server cannot be reached, returned a
+// server error or throttled the client
+func isTemporary(err error) bool {
+	if apiErr, ok := err.(*APIError); ok {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	switch err.(type) {
+	case *json.SyntaxError, *json.UnmarshalTypeError:
+		return false
+	}
+	return true
+}
+
+func newIdempotencyKey() string {
+	var b [16]byte
+
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
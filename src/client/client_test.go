@@ -0,0 +1,377 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// testServer records the requests it receives and answers them with the given handler
+type testServer struct {
+	*httptest.Server
+
+	mtx      sync.Mutex
+	requests []testRequest
+}
+
+type testRequest struct {
+	path           string
+	idempotencyKey string
+	body           []byte
+}
+
+//------------------------------------------------------------------------------
+
+func TestNotifyRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int //status returned by each attempt, the last one is repeated
+		err      error
+		attempts int
+		buffered int
+	}{
+		{ "success", []int{ 200 }, nil, 1, 0 },
+		{ "server error then success", []int{ 500, 503, 200 }, nil, 3, 0 },
+		{ "throttled then success", []int{ 429, 200 }, nil, 2, 0 },
+		{ "unavailable after all retries", []int{ 503 }, ErrBuffered, 3, 1 },
+		{ "rejected", []int{ 400 }, nil, 1, 0 },
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			attempt := 0
+			ts := newTestServer(func(w http.ResponseWriter, r *http.Request, _ []byte) {
+				status := tc.statuses[len(tc.statuses) - 1]
+				if attempt < len(tc.statuses) {
+					status = tc.statuses[attempt]
+				}
+				attempt++
+				w.WriteHeader(status)
+			})
+			defer ts.Close()
+
+			c := newTestClient(t, ts, 2)
+			defer c.stop()
+
+			err := c.Notify(context.Background(), NotifyRequest{ Channel: "default", Message: "test" })
+			if tc.statuses[len(tc.statuses) - 1] == 400 {
+				if apiErr, ok := err.(*APIError); !ok || apiErr.StatusCode != 400 {
+					t.Fatalf("got error %v, want a 400 API error", err)
+				}
+			} else if err != tc.err {
+				t.Fatalf("got error %v, want %v", err, tc.err)
+			}
+
+			reqs := ts.getRequests()
+			if len(reqs) != tc.attempts {
+				t.Fatalf("got %v attempts, want %v", len(reqs), tc.attempts)
+			}
+			for idx := range reqs {
+				if reqs[idx].idempotencyKey != reqs[0].idempotencyKey || len(reqs[idx].idempotencyKey) == 0 {
+					t.Fatal("the idempotency key changed across retries")
+				}
+			}
+			if c.Buffered() != tc.buffered {
+				t.Fatalf("got %v buffered notifications, want %v", c.Buffered(), tc.buffered)
+			}
+		})
+	}
+}
+
+func TestNotifyTimestamp(t *testing.T) {
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request, _ []byte) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer ts.Close()
+
+	c := newTestClient(t, ts, 2)
+	defer c.stop()
+
+	before := time.Now()
+	err := c.Notify(context.Background(), NotifyRequest{ Channel: "default", Message: "test" })
+	if err != ErrBuffered {
+		t.Fatalf("got error %v, want %v", err, ErrBuffered)
+	}
+
+	//the timestamp is set before the first attempt and sent unchanged in the retries
+	reqs := ts.getRequests()
+	first := decodeNotify(t, reqs[0].body)
+	if first.Timestamp == nil || first.Timestamp.Before(before.Add(-time.Second)) ||
+	   first.Timestamp.After(before.Add(c.opts.RetryDelay)) {
+		t.Fatalf("got timestamp %v in the first attempt, want about %v", first.Timestamp, before)
+	}
+	for idx := range reqs {
+		if r := decodeNotify(t, reqs[idx].body); r.Timestamp == nil || !r.Timestamp.Equal(*first.Timestamp) {
+			t.Fatalf("attempt #%d: got timestamp %v, want %v", idx, r.Timestamp, first.Timestamp)
+		}
+	}
+
+	c.bufferMtx.Lock()
+	buffered := c.buffer[0].Timestamp
+	c.bufferMtx.Unlock()
+	if buffered == nil || !buffered.Equal(*first.Timestamp) {
+		t.Fatalf("got buffered timestamp %v, want %v", buffered, first.Timestamp)
+	}
+}
+
+func TestBufferTrim(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		added   int
+		kept    []string
+		removed uint64
+	}{
+		{ "not full", 3, 2, []string{ "0", "1" }, 0 },
+		{ "full", 3, 3, []string{ "0", "1", "2" }, 0 },
+		{ "oldest discarded", 3, 5, []string{ "2", "3", "4" }, 2 },
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &Client{}
+			c.opts.BufferSize = tc.size
+
+			for idx := 0; idx < tc.added; idx++ {
+				c.addToBuffer(NotifyRequest{ Message: strconv.Itoa(idx) })
+			}
+
+			assertBuffer(t, c, tc.kept)
+			if c.bufferRemoved != tc.removed {
+				t.Fatalf("got %v removed notifications, want %v", c.bufferRemoved, tc.removed)
+			}
+		})
+	}
+}
+
+func TestFlush(t *testing.T) {
+	tests := []struct {
+		name        string
+		buffered    int
+		status      int
+		rateLimited int //number of notifications at the end of each batch rejected by the rate limit
+		err         int //status of the returned error, zero if none
+		requests    int
+		kept        int
+	}{
+		{ "all sent", 3, 200, 0, 0, 1, 0 },
+		{ "many batches", maxNotifyBatchSize + 50, 200, 0, 0, 2, 0 },
+		{ "partially rate limited", 5, 200, 2, 429, 1, 2 },
+		{ "rejected batch is discarded", 3, 400, 0, 0, 1, 0 },
+		{ "server unavailable", 3, 503, 0, 503, 1, 3 },
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := newTestServer(func(w http.ResponseWriter, r *http.Request, body []byte) {
+				if tc.status != 200 {
+					w.WriteHeader(tc.status)
+					return
+				}
+
+				var batch []NotifyRequest
+				_ = json.Unmarshal(body, &batch)
+
+				res := notifyBatchResponse{}
+				for idx := range batch {
+					if idx >= len(batch) - tc.rateLimited {
+						res.Results = append(res.Results, NotifyResult{ RateLimited: true })
+					} else {
+						res.Results = append(res.Results, NotifyResult{ Success: true })
+						res.Sent += 1
+					}
+				}
+				_ = json.NewEncoder(w).Encode(res)
+			})
+			defer ts.Close()
+
+			c := newTestClient(t, ts, -1)
+			defer c.stop()
+
+			c.opts.BufferSize = tc.buffered
+			expected := make([]string, 0)
+			for idx := 0; idx < tc.buffered; idx++ {
+				c.addToBuffer(NotifyRequest{ Channel: "default", Message: strconv.Itoa(idx) })
+				if idx >= tc.buffered - tc.kept {
+					expected = append(expected, strconv.Itoa(idx))
+				}
+			}
+
+			err := c.flush(context.Background())
+			if tc.err == 0 && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.err != 0 {
+				if apiErr, ok := err.(*APIError); !ok || apiErr.StatusCode != tc.err {
+					t.Fatalf("got error %v, want status %v", err, tc.err)
+				}
+			}
+
+			if reqs := ts.getRequests(); len(reqs) != tc.requests {
+				t.Fatalf("got %v requests, want %v", len(reqs), tc.requests)
+			}
+			assertBuffer(t, c.Client, expected)
+		})
+	}
+}
+
+func TestFlushWithDiscardedNotifications(t *testing.T) {
+	var c *testClient
+
+	//while the batch is being sent, the buffer gets full and the two oldest notifications of the batch are discarded
+	ts := newTestServer(func(w http.ResponseWriter, r *http.Request, body []byte) {
+		var batch []NotifyRequest
+		_ = json.Unmarshal(body, &batch)
+
+		if len(c.getRequests()) == 1 {
+			c.addToBuffer(NotifyRequest{ Channel: "default", Message: "new1" })
+			c.addToBuffer(NotifyRequest{ Channel: "default", Message: "new2" })
+		}
+
+		res := notifyBatchResponse{}
+		for range batch {
+			res.Results = append(res.Results, NotifyResult{ Success: true })
+		}
+		_ = json.NewEncoder(w).Encode(res)
+	})
+	defer ts.Close()
+
+	c = newTestClient(t, ts, -1)
+	defer c.stop()
+
+	c.opts.BufferSize = 3
+	for idx := 0; idx < 3; idx++ {
+		c.addToBuffer(NotifyRequest{ Channel: "default", Message: strconv.Itoa(idx) })
+	}
+
+	err := c.flush(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	//the new notifications are sent in a second batch
+	reqs := ts.getRequests()
+	if len(reqs) != 2 {
+		t.Fatalf("got %v requests, want 2", len(reqs))
+	}
+	second := make([]NotifyRequest, 0)
+	_ = json.Unmarshal(reqs[1].body, &second)
+	if len(second) != 2 || second[0].Message != "new1" || second[1].Message != "new2" {
+		t.Fatalf("got second batch %+v, want the new notifications", second)
+	}
+	assertBuffer(t, c.Client, []string{})
+}
+
+//------------------------------------------------------------------------------
+
+type testClient struct {
+	*Client
+	ts *testServer
+}
+
+func newTestServer(handler func(w http.ResponseWriter, r *http.Request, body []byte)) *testServer {
+	ts := &testServer{}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := make([]byte, 0)
+		if r.Body != nil {
+			var err error
+
+			body, err = readAll(r)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+
+		ts.mtx.Lock()
+		ts.requests = append(ts.requests, testRequest{
+			path:           r.URL.Path,
+			idempotencyKey: r.Header.Get("Idempotency-Key"),
+			body:           body,
+		})
+		ts.mtx.Unlock()
+
+		handler(w, r, body)
+	}))
+	return ts
+}
+
+func (ts *testServer) getRequests() []testRequest {
+	ts.mtx.Lock()
+	defer ts.mtx.Unlock()
+
+	return append([]testRequest{}, ts.requests...)
+}
+
+// newTestClient creates a client with short retry delays and no background flush
+func newTestClient(t *testing.T, ts *testServer, maxRetries int) *testClient {
+	c, err := New(Options{
+		Url:           ts.URL,
+		ApiKey:        "test",
+		MaxRetries:    maxRetries,
+		RetryDelay:    time.Millisecond,
+		MaxRetryDelay: 5 * time.Millisecond,
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	return &testClient{
+		Client: c,
+		ts:     ts,
+	}
+}
+
+func (c *testClient) getRequests() []testRequest {
+	return c.ts.getRequests()
+}
+
+// stop ends the background flush without sending the buffered notifications
+func (c *testClient) stop() {
+	c.bufferMtx.Lock()
+	c.buffer = nil
+	c.bufferMtx.Unlock()
+
+	_ = c.Close()
+}
+
+func readAll(r *http.Request) ([]byte, error) {
+	var body json.RawMessage
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func decodeNotify(t *testing.T, body []byte) NotifyRequest {
+	var r NotifyRequest
+
+	err := json.Unmarshal(body, &r)
+	if err != nil {
+		t.Fatalf("invalid notification: %v", err)
+	}
+	return r
+}
+
+func assertBuffer(t *testing.T, c *Client, messages []string) {
+	c.bufferMtx.Lock()
+	defer c.bufferMtx.Unlock()
+
+	if len(c.buffer) != len(messages) {
+		t.Fatalf("got %v buffered notifications, want %v", len(c.buffer), len(messages))
+	}
+	for idx := range messages {
+		if c.buffer[idx].Message != messages[idx] {
+			t.Fatalf("buffered notification #%d: got %v, want %v", idx, c.buffer[idx].Message, messages[idx])
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// NotifyRequest is a notification sent to a channel
+type NotifyRequest struct {
+	Channel   string     `json:"channel"`
+	Message   string     `json:"message"`
+	Severity  string     `json:"severity,omitempty"`
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+	Source    string     `json:"source,omitempty"`
+	Host      string     `json:"host,omitempty"`
+	DedupKey  string     `json:"dedupKey,omitempty"`
+}
+
+// NotifyResult is the outcome of each notification sent with NotifyBatch
+type NotifyResult struct {
+	Success   bool   `json:"success"`
+	Duplicate bool   `json:"duplicate,omitempty"`
+	Error     string `json:"error,omitempty"`
+
+	// RateLimited is true if the notification was not sent because the client exceeded the rate limit. It can be
+	// sent again later.
+	RateLimited bool `json:"rateLimited,omitempty"`
+}
+
+// WatchProcessRequest adds a process to the watch list
+type WatchProcessRequest struct {
+	Channel     string `json:"channel"`
+	Pid         int    `json:"pid"`
+	MaxMemUsage string `json:"maxMem,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Severity    string `json:"severity,omitempty"`
+}
+
+// UnwatchProcessRequest removes a process from the watch list
+type UnwatchProcessRequest struct {
+	Channel string `json:"channel"`
+	Pid     int    `json:"pid"`
+}
+
+// StartJobRequest marks the start of a job run. The fields are only needed for jobs not defined in the settings file.
+type StartJobRequest struct {
+	Channel     string `json:"channel,omitempty"`
+	Severity    string `json:"severity,omitempty"`
+	MaxDuration string `json:"maxDuration,omitempty"`
+}
+
+// EndJobRequest marks the end of a job run
+type EndJobRequest struct {
+	ExitCode *int   `json:"exitCode,omitempty"`
+	Output   string `json:"output,omitempty"`
+}
+
+//------------------------------------------------------------------------------
+
+type notifyBatchResponse struct {
+	Sent    int            `json:"sent"`
+	Results []NotifyResult `json:"results"`
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/kardianos/service"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	exitCodeSuccess = 0
+	exitCodeError   = 1
+	exitCodeUsage   = 2
+)
+
+// Environment variables used when the related option is not specified in the command line
+const (
+	envSettings = "SERVERWATCHDOG_SETTINGS"
+	envServer   = "SERVERWATCHDOG_SERVER"
+	envApiKey   = "SERVERWATCHDOG_API_KEY"
+)
+
+//------------------------------------------------------------------------------
+
+type cmdLineOptions struct {
+	settings    string
+	service     string
+	checkConfig bool
+	schema      bool
+	version     bool
+}
+
+//------------------------------------------------------------------------------
+
+// version is set at build time with -ldflags "-X main.version=x.y.z"
+var version = "dev"
+
+//------------------------------------------------------------------------------
+
+// parseCmdLine parses the options used to run the server. It returns flag.ErrHelp if the help was requested.
+func parseCmdLine(args []string) (*cmdLineOptions, error) {
+	var opts cmdLineOptions
+
+	fs := flag.NewFlagSet(appName(), flag.ContinueOnError)
+	fs.StringVar(&opts.settings, "settings", "", "The settings file. Defaults to settings.json in the application " +
+	             "folder. Can also be set with the " + envSettings + " environment variable.")
+	fs.StringVar(&opts.service, "service", "", "Controls the service. Valid actions are: " +
+	             strings.Join(service.ControlAction[:], ", ") + ".")
+	fs.BoolVar(&opts.checkConfig, "check-config", false, "Validates the settings file and exits.")
+	fs.BoolVar(&opts.schema, "schema", false, "Prints the JSON Schema of the settings file and exits.")
+	fs.BoolVar(&opts.version, "version", false, "Prints the version and exits.")
+	fs.Usage = func() {
+		printUsage(fs)
+	}
+
+	err := fs.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if fs.NArg() > 0 {
+		return nil, usageError(fs, errors.New("Unknown command: " + fs.Arg(0)))
+	}
+	if len(opts.service) > 0 && !isServiceAction(opts.service) {
+		return nil, usageError(fs, errors.New("Invalid service action: " + opts.service))
+	}
+
+	if len(opts.settings) == 0 {
+		opts.settings = os.Getenv(envSettings)
+	}
+
+	return &opts, nil
+}
+
+func printUsage(fs *flag.FlagSet) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := fs.Output()
+	fmt.Fprintf(w, "Usage:\n  %v [options]\n  %v <command> [options]\n\nOptions:\n", fs.Name(), fs.Name())
+	fs.PrintDefaults()
+	fmt.Fprintf(w, "\nCommands:\n")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10v%v\n", name, commands[name].summary)
+	}
+	fmt.Fprintf(w, "\nRun '%v <command> --help' for the options of each command.\n", fs.Name())
+	return
+}
+
+func printVersion() {
+	v := version
+	if v == "dev" {
+		//use the module version if built with go install
+		if bi, ok := debug.ReadBuildInfo(); ok && len(bi.Main.Version) > 0 && bi.Main.Version != "(devel)" {
+			v = bi.Main.Version
+		}
+	}
+
+	fmt.Printf("%v %v\n", appName(), v)
+	fmt.Printf("Go version: %v\n", runtime.Version())
+	fmt.Printf("Platform:   %v/%v\n", runtime.GOOS, runtime.GOARCH)
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// usageError prints the error along with the usage, the same way the flag package does with invalid options
+func usageError(fs *flag.FlagSet, err error) error {
+	fmt.Fprintln(fs.Output(), err.Error())
+	fs.Usage()
+	return err
+}
+
+func isServiceAction(action string) bool {
+	for _, a := range service.ControlAction {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+func appName() string {
+	return filepath.Base(os.Args[0])
+}
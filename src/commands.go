@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/randlabs/server-watchdog/client"
+	"github.com/randlabs/server-watchdog/settings"
+)
+
+//------------------------------------------------------------------------------
+
+type command struct {
+	usage   string
+	summary string
+	run     func(ctx context.Context, c *client.Client, fs *flag.FlagSet) error
+	flags   func(fs *flag.FlagSet)
+}
+
+type commandOptions struct {
+	server   string
+	apiKey   string
+	timeout  time.Duration
+	settings string
+}
+
+//------------------------------------------------------------------------------
+
+// Commands that call a running instance, so scripts can send notifications without curl
+var commands = map[string]command{
+	"notify": {
+		usage:   "notify --channel name [--severity level] [--source name] [--host name] [--dedup-key key] message",
+		summary: "Sends a notification.",
+		flags:   func(fs *flag.FlagSet) {
+			fs.String("channel", "", "The channel to notify.")
+			fs.String("severity", "", "The severity of the notification. Defaults to error.")
+			fs.String("source", "", "The source of the event.")
+			fs.String("host", "", "The host where the event happened.")
+			fs.String("dedup-key", "", "Discards notifications with the same key during the deduplication window.")
+		},
+		run:     runNotifyCommand,
+	},
+	"watch": {
+		usage:   "watch --pid pid --channel name [--name name] [--max-mem amount] [--severity level]",
+		summary: "Adds a process to the watch list.",
+		flags:   func(fs *flag.FlagSet) {
+			fs.Int("pid", 0, "The id of the process to watch.")
+			fs.String("channel", "", "The channel to notify when the process ends.")
+			fs.String("name", "", "The name of the process.")
+			fs.String("max-mem", "", "The maximum memory usage allowed, i.e. 512MB.")
+			fs.String("severity", "", "The severity of the notifications. Defaults to error.")
+		},
+		run:     runWatchCommand,
+	},
+	"unwatch": {
+		usage:   "unwatch --pid pid --channel name",
+		summary: "Removes a process from the watch list.",
+		flags:   func(fs *flag.FlagSet) {
+			fs.Int("pid", 0, "The id of the watched process.")
+			fs.String("channel", "", "The channel the process was watched with.")
+		},
+		run:     runUnwatchCommand,
+	},
+	"status": {
+		usage:   "status",
+		summary: "Prints the state of all the monitors.",
+		run:     runStatusCommand,
+	},
+	"ping": {
+		usage:   "ping",
+		summary: "Checks if the server is running.",
+		run:     runPingCommand,
+	},
+}
+
+//------------------------------------------------------------------------------
+
+// runCommand executes a client command and returns the exit code. The second value is false if args does not start
+// with a command name.
+func runCommand(args []string) (int, bool) {
+	var opts commandOptions
+
+	if len(args) == 0 {
+		return exitCodeSuccess, false
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return exitCodeSuccess, false
+	}
+
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.StringVar(&opts.server, "server", "", "The url of the server, i.e. http://my-server:3004. Defaults to the " +
+	             "address in the settings file. Can also be set with the " + envServer + " environment variable.")
+	fs.StringVar(&opts.apiKey, "api-key", "", "The API key. Defaults to the one in the settings file. Can also be " +
+	             "set with the " + envApiKey + " environment variable.")
+	fs.DurationVar(&opts.timeout, "timeout", 10 * time.Second, "The maximum time to wait for the server.")
+	fs.StringVar(&opts.settings, "settings", "", "The settings file to read the server address and the API key " +
+	             "from. Can also be set with the " + envSettings + " environment variable.")
+	if cmd.flags != nil {
+		cmd.flags(fs)
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %v %v\n\nOptions:\n", appName(), cmd.usage)
+		fs.PrintDefaults()
+	}
+
+	err := fs.Parse(args[1:])
+	if err != nil {
+		if err == flag.ErrHelp {
+			return exitCodeSuccess, true
+		}
+		return exitCodeUsage, true
+	}
+
+	if len(opts.server) == 0 {
+		opts.server = os.Getenv(envServer)
+	}
+	if len(opts.apiKey) == 0 {
+		opts.apiKey = os.Getenv(envApiKey)
+	}
+	if len(opts.settings) == 0 {
+		opts.settings = os.Getenv(envSettings)
+	}
+
+	c, err := newCommandClient(&opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err.Error())
+		return exitCodeError, true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+	err = cmd.run(ctx, c, fs)
+	cancel()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err.Error())
+		return exitCodeError, true
+	}
+	return exitCodeSuccess, true
+}
+
+//------------------------------------------------------------------------------
+
+func runNotifyCommand(ctx context.Context, c *client.Client, fs *flag.FlagSet) error {
+	r := client.NotifyRequest{
+		Channel:  flagString(fs, "channel"),
+		Message:  strings.Join(fs.Args(), " "),
+		Severity: flagString(fs, "severity"),
+		Source:   flagString(fs, "source"),
+		Host:     flagString(fs, "host"),
+		DedupKey: flagString(fs, "dedup-key"),
+	}
+	if len(r.Channel) == 0 {
+		return errors.New("Missing channel")
+	}
+	if len(r.Message) == 0 {
+		return errors.New("Missing message")
+	}
+
+	return c.Notify(ctx, r)
+}
+
+func runWatchCommand(ctx context.Context, c *client.Client, fs *flag.FlagSet) error {
+	r := client.WatchProcessRequest{
+		Channel:     flagString(fs, "channel"),
+		Pid:         flagInt(fs, "pid"),
+		MaxMemUsage: flagString(fs, "max-mem"),
+		Name:        flagString(fs, "name"),
+		Severity:    flagString(fs, "severity"),
+	}
+	if r.Pid <= 0 {
+		return errors.New("Missing process id")
+	}
+	if len(r.Channel) == 0 {
+		return errors.New("Missing channel")
+	}
+
+	return c.WatchProcess(ctx, r)
+}
+
+func runUnwatchCommand(ctx context.Context, c *client.Client, fs *flag.FlagSet) error {
+	r := client.UnwatchProcessRequest{
+		Channel: flagString(fs, "channel"),
+		Pid:     flagInt(fs, "pid"),
+	}
+	if r.Pid <= 0 {
+		return errors.New("Missing process id")
+	}
+	if len(r.Channel) == 0 {
+		return errors.New("Missing channel")
+	}
+
+	return c.UnwatchProcess(ctx, r)
+}
+
+func runStatusCommand(ctx context.Context, c *client.Client, _ *flag.FlagSet) error {
+	status, err := c.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	err = json.Indent(&out, status, "", "\t")
+	if err != nil {
+		return err
+	}
+	fmt.Println(out.String())
+	return nil
+}
+
+func runPingCommand(ctx context.Context, c *client.Client, _ *flag.FlagSet) error {
+	err := c.Ping(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("pong!")
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// newCommandClient creates a client to call the running instance. The address and the API key not specified in the
+// command line are taken from the settings file.
+func newCommandClient(opts *commandOptions) (*client.Client, error) {
+	clientOpts := client.Options{
+		Url:              opts.server,
+		ApiKey:           opts.apiKey,
+		Timeout:          opts.timeout,
+		MaxRetries:       -1,
+		DisableBuffering: true,
+	}
+
+	if len(clientOpts.Url) == 0 || len(clientOpts.ApiKey) == 0 {
+		settings.SetSettingsFilename(opts.settings)
+		err := settings.Load()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(clientOpts.ApiKey) == 0 {
+			//named keys only store the hash so the secret must be given in the command line
+			clientOpts.ApiKey = settings.Config.Server.ApiKey
+			if len(clientOpts.ApiKey) == 0 {
+				return nil, errors.New("No API key specified")
+			}
+		}
+
+		if len(clientOpts.Url) == 0 {
+			err = setLocalServerAddress(&clientOpts)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return client.New(clientOpts)
+}
+
+// setLocalServerAddress sets up the client to connect to the first address the server listens at
+func setLocalServerAddress(opts *client.Options) error {
+	if len(settings.Config.Server.ListenX) == 0 {
+		return errors.New("No server address found in the settings file")
+	}
+	addr := settings.Config.Server.ListenX[0]
+
+	transport := &http.Transport{}
+	opts.HttpClient = &http.Client{
+		Transport: transport,
+	}
+
+	if addr.Network == "unix" {
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", addr.Address)
+		}
+		opts.Url = "http://localhost"
+		return nil
+	}
+
+	host, port, err := net.SplitHostPort(addr.Address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); len(host) == 0 || (ip != nil && ip.IsUnspecified()) {
+		if addr.Network == "tcp6" {
+			host = "::1"
+		} else {
+			host = "127.0.0.1"
+		}
+	}
+
+	if t := settings.Config.Server.Tls; t != nil {
+		//the certificate is issued for the public name of the server so, instead of verifying it, check it is the
+		//same one the server is configured with
+		certPEM, err := ioutil.ReadFile(t.CertFile)
+		if err != nil {
+			return err
+		}
+		block, _ := pem.Decode(certPEM)
+		if block == nil {
+			return errors.New("Unable to read the server certificate")
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return err
+		}
+
+		transport.TLSClientConfig = &tls.Config{
+			InsecureSkipVerify: true,
+			VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+				if len(rawCerts) == 0 || !bytes.Equal(rawCerts[0], cert.Raw) {
+					return errors.New("The server certificate does not match the one in the settings file")
+				}
+				return nil
+			},
+		}
+		opts.Url = "https://" + net.JoinHostPort(host, port)
+	} else {
+		opts.Url = "http://" + net.JoinHostPort(host, port)
+	}
+	return nil
+}
+
+func flagString(fs *flag.FlagSet, name string) string {
+	return fs.Lookup(name).Value.String()
+}
+
+func flagInt(fs *flag.FlagSet, name string) int {
+	if getter, ok := fs.Lookup(name).Value.(flag.Getter); ok {
+		if v, ok := getter.Get().(int); ok {
+			return v
+		}
+	}
+	return 0
+}
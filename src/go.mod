@@ -3,10 +3,12 @@ module github.com/randlabs/server-watchdog
 go 1.13
 
 require (
+	github.com/BurntSushi/toml v0.3.1
 	github.com/RoaringBitmap/roaring v0.4.21
 	github.com/asaskevich/govalidator v0.0.0-20190424111038-f61b66f89f4a
 	github.com/bmatcuk/doublestar v1.2.2
 	github.com/buaazp/fasthttprouter v0.1.1
+	github.com/golang/protobuf v1.3.2
 	github.com/gookit/color v1.2.0
 	github.com/kardianos/service v1.0.0
 	github.com/minio/minio v0.0.0-20200129151003-f6a7d4d29b15
@@ -18,6 +20,8 @@ require (
 	github.com/valyala/fasthttp v1.5.0
 	github.com/vmihailenco/msgpack/v4 v4.2.1
 	google.golang.org/appengine v1.6.5 // indirect
+	google.golang.org/grpc v1.27.1
+	gopkg.in/yaml.v2 v2.4.0
 )
 
 replace github.com/shirou/gopsutil v2.19.12+incompatible => github.com/mxmauro/gopsutil v2.20.4+incompatible
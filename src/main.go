@@ -1,17 +1,27 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
 	"github.com/kardianos/service"
 	"github.com/randlabs/server-watchdog/console"
+	"github.com/randlabs/server-watchdog/modules/alerts"
+	"github.com/randlabs/server-watchdog/modules/apikeys"
 	"github.com/randlabs/server-watchdog/modules/backend"
 	"github.com/randlabs/server-watchdog/modules/freediskspacechecker"
+	"github.com/randlabs/server-watchdog/modules/heartbeats"
+	"github.com/randlabs/server-watchdog/modules/jobs"
 	"github.com/randlabs/server-watchdog/modules/logger"
 	"github.com/randlabs/server-watchdog/modules/processwatcher"
+	"github.com/randlabs/server-watchdog/modules/silences"
 	"github.com/randlabs/server-watchdog/modules/tcpports"
 	"github.com/randlabs/server-watchdog/modules/webchecker"
 	"github.com/randlabs/server-watchdog/settings"
-	"github.com/randlabs/server-watchdog/utils/process"
 	"runtime"
+	"strings"
 	"sync"
 )
 
@@ -39,6 +49,18 @@ func (p *program) Start(s service.Service) error {
 		goto Done
 	}
 
+	err = silences.Start() // Must be initialized before any module that sends notifications
+	if err != nil {
+		console.Error("Unable to load silences [%v]", err.Error())
+		goto Done
+	}
+
+	err = alerts.Start()
+	if err != nil {
+		console.Error("Unable to create alerts registry [%v]", err.Error())
+		goto Done
+	}
+
 	err = processwatcher.Start()
 	if err != nil {
 		console.Error("Unable to create process monitor [%v]", err.Error())
@@ -63,6 +85,24 @@ func (p *program) Start(s service.Service) error {
 		goto Done
 	}
 
+	err = heartbeats.Start()
+	if err != nil {
+		console.Error("Unable to create heartbeats monitor [%v]", err.Error())
+		goto Done
+	}
+
+	err = jobs.Start()
+	if err != nil {
+		console.Error("Unable to create jobs monitor [%v]", err.Error())
+		goto Done
+	}
+
+	err = apikeys.Start()
+	if err != nil {
+		console.Error("Unable to load API keys [%v]", err.Error())
+		goto Done
+	}
+
 	err = backend.Start()
 	if err != nil {
 		console.Error("Unable to create server [%v]", err.Error())
@@ -84,22 +124,37 @@ func (p *program) Stop(s service.Service) error {
 }
 
 func (p *program) run()  {
-	console.Info("Running server at port %v", settings.Config.Server.Port)
+	addresses := make([]string, len(settings.Config.Server.ListenX))
+	for idx, addr := range settings.Config.Server.ListenX {
+		addresses[idx] = addr.Address
+	}
+	console.Info("Running server at %v", strings.Join(addresses, ", "))
+	if g := settings.Config.Server.Grpc; g != nil {
+		console.Info("Running gRPC server at %v", g.ListenX.Address)
+	}
 
-	logger.Run(p.wg)
+	logger.Run(&p.wg)
+	alerts.Run(&p.wg)
 	processwatcher.Run(p.wg)
 	webchecker.Run(p.wg)
 	tcpports.Run(p.wg)
 	freediskspacechecker.Run(p.wg)
+	heartbeats.Run(&p.wg)
+	jobs.Run(&p.wg)
 	backend.Run(p.wg)
 }
 
 func (p *program) shutdown()  {
 	backend.Stop()
+	apikeys.Stop()
+	jobs.Stop()
+	heartbeats.Stop()
 	freediskspacechecker.Stop()
 	tcpports.Stop()
 	webchecker.Stop()
 	processwatcher.Stop()
+	alerts.Stop()
+	silences.Stop()
 	logger.Stop()
 
 	p.wg.Wait()
@@ -108,12 +163,34 @@ func (p *program) shutdown()  {
 //------------------------------------------------------------------------------
 
 func main() {
-	serviceCmdLineParam, err := process.GetCmdLineParam("service")
+	if code, ok := runCommand(os.Args[1:]); ok {
+		os.Exit(code)
+	}
+
+	opts, err := parseCmdLine(os.Args[1:])
 	if err != nil {
-		console.Error(err.Error())
-		return
+		if err == flag.ErrHelp {
+			os.Exit(exitCodeSuccess)
+		}
+		os.Exit(exitCodeUsage)
 	}
+	settings.SetSettingsFilename(opts.settings)
 
+	if opts.version {
+		printVersion()
+		os.Exit(exitCodeSuccess)
+	}
+	if opts.checkConfig {
+		os.Exit(checkConfig())
+	}
+	if opts.schema {
+		os.Exit(printSchema())
+	}
+
+	os.Exit(runServer(opts))
+}
+
+func runServer(opts *cmdLineOptions) int {
 	svcConfig := &service.Config{
 		Name:        "ServerWatchdog",
 		DisplayName: "Randlabs.IO Server Watcher service",
@@ -123,37 +200,67 @@ func main() {
 		svcConfig.Name = "serverwatchdog"
 	}
 
-	if serviceCmdLineParam == "install" {
-		settingsFilename, err := settings.GetSettingsFilename()
-		if err != nil {
-			console.Error(err.Error())
-			return
-		}
-
-		svcConfig.Arguments = append(svcConfig.Arguments, "--settings", settingsFilename)
+	if opts.service == "install" {
+		svcConfig.Arguments = append(svcConfig.Arguments, "--settings=" + settings.GetSettingsFilename())
 	}
 
 	prg := &program{}
 	s, err := service.New(prg, svcConfig)
 	if err != nil {
 		console.Error("Unable to initialize application [%v]", err.Error())
-		return
+		return exitCodeError
 	}
-	if len(serviceCmdLineParam) == 0 {
+	if len(opts.service) == 0 {
 		err = console.SetupService(s)
 		if err != nil {
 			console.Error("Unable to setup service logger [%v]", err.Error())
-			return
+			return exitCodeError
 		}
 
-		_ = s.Run()
-		// no need to print an error message because already printer by the start function
+		err = s.Run()
+		if err != nil {
+			// no need to print an error message because already printer by the start function
+			return exitCodeError
+		}
 	} else {
-		err = service.Control(s, serviceCmdLineParam)
+		err = service.Control(s, opts.service)
 		if err != nil {
 			console.Error("Unable to send control code [%v]", err.Error())
-			return
+			return exitCodeError
 		}
 	}
-	return
+	return exitCodeSuccess
+}
+
+func checkConfig() int {
+	errs, warnings, err := settings.Check()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err.Error())
+		return exitCodeError
+	}
+
+	for _, issue := range warnings {
+		fmt.Fprintf(os.Stderr, "WARNING: %v\n", issue.String())
+	}
+	for _, issue := range errs {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", issue.String())
+	}
+
+	if len(errs) > 0 {
+		fmt.Fprintf(os.Stderr, "Settings check failed with %v error(s) and %v warning(s).\n", len(errs), len(warnings))
+		return exitCodeError
+	}
+	fmt.Printf("Settings are valid (%v warning(s)).\n", len(warnings))
+	return exitCodeSuccess
+}
+
+func printSchema() int {
+	b, err := json.MarshalIndent(settings.GenerateSchema(), "", "\t")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err.Error())
+		return exitCodeError
+	}
+
+	fmt.Println(string(b))
+	return exitCodeSuccess
 }
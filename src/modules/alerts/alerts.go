@@ -0,0 +1,374 @@
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	rp "github.com/randlabs/rundown-protection"
+	"github.com/randlabs/server-watchdog/console"
+	"github.com/randlabs/server-watchdog/modules/logger"
+	"github.com/randlabs/server-watchdog/settings"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	escalationCheckInterval = 10 * time.Second
+	resolvedAlertsRetention = 7 * 24 * time.Hour
+)
+
+//------------------------------------------------------------------------------
+
+type Module struct {
+	shutdownSignal chan struct{}
+	alertsMtx      sync.Mutex
+	alerts         map[uint64]*Alert
+	r              rp.RundownProtection
+	saveStateMtx   sync.Mutex //serializes the writes to the state file
+}
+
+// Alert is a problem reported by a monitor. It is opened when the monitor goes down and resolved when it recovers.
+// Only the last alert of each monitor is kept.
+type Alert struct {
+	Id               string     `json:"id"`
+	State            string     `json:"state" msgpack:"-"`
+	Module           string     `json:"module"`
+	Target           string     `json:"target"`
+	Channel          string     `json:"channel"`
+	Severity         string     `json:"severity"`
+	Message          string     `json:"message"`
+	OpenedAt         time.Time  `json:"openedAt"`
+	AcknowledgedAt   *time.Time `json:"acknowledgedAt,omitempty"`
+	AcknowledgedBy   string     `json:"acknowledgedBy,omitempty"`
+	ResolvedAt       *time.Time `json:"resolvedAt,omitempty"`
+	EscalationPolicy string     `json:"escalationPolicy,omitempty"`
+	EscalationStep   int        `json:"escalationStep"`
+}
+
+//------------------------------------------------------------------------------
+
+var module *Module
+var lock sync.RWMutex
+
+var ErrNotFound = errors.New("Alert not found")
+var ErrResolved = errors.New("Alert already resolved")
+
+//------------------------------------------------------------------------------
+
+func Start() error {
+	//initialize module
+	module = &Module{}
+	module.shutdownSignal = make(chan struct{})
+	module.alerts = make(map[uint64]*Alert)
+	module.r.Initialize()
+
+	//load stored alerts
+	err := module.loadState()
+	if err != nil {
+		console.Error("Unable to load alerts state. [%v]", err)
+		return err
+	}
+
+	return nil
+}
+
+func Stop() {
+	lock.Lock()
+	localModule := module
+	module = nil
+	lock.Unlock()
+
+	if localModule != nil {
+		//signal shutdown
+		close(localModule.shutdownSignal)
+
+		//wait until all workers are done
+		localModule.r.Wait()
+	}
+	return
+}
+
+func Run(wg *sync.WaitGroup) {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule != nil {
+		//start background loop
+		wg.Add(1)
+
+		if localModule.r.Acquire() {
+			go func() {
+				loop := true
+				for loop {
+					select {
+					case <-localModule.shutdownSignal:
+						loop = false
+
+					case <-time.After(escalationCheckInterval):
+						localModule.escalate()
+						localModule.pruneResolved()
+					}
+				}
+
+				localModule.r.Release()
+
+				wg.Done()
+			}()
+		} else {
+			wg.Done()
+		}
+	}
+	return
+}
+
+// Open registers an alert for the monitored target identified by the hash code and returns its id, to be referenced
+// in notifications. If an alert for the same target is already open, nothing is done.
+func Open(moduleName string, hashCode uint64, target string, channel string, severity string, format string,
+          a ...interface{}) string {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	id := strconv.FormatUint(hashCode, 16)
+
+	if localModule == nil {
+		return id
+	}
+
+	opened := false
+
+	localModule.alertsMtx.Lock()
+	if existing, ok := localModule.alerts[hashCode]; !ok || existing.ResolvedAt != nil {
+		alert := &Alert{
+			Id:       id,
+			Module:   moduleName,
+			Target:   target,
+			Channel:  channel,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, a...),
+			OpenedAt: time.Now().UTC(),
+		}
+
+		//the first escalation policy that matches the alert applies
+		for idx := range settings.Config.Escalation {
+			esc := &settings.Config.Escalation[idx]
+
+			if esc.Matchers.Matches(moduleName, target, channel, severity) {
+				alert.EscalationPolicy = esc.Name
+				break
+			}
+		}
+
+		localModule.alerts[hashCode] = alert
+		opened = true
+	}
+	localModule.alertsMtx.Unlock()
+
+	if opened {
+		localModule.runSaveState()
+	}
+	return id
+}
+
+// Resolve closes the open alert of the monitored target identified by the hash code, if any
+func Resolve(hashCode uint64) {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return
+	}
+
+	resolved := false
+
+	localModule.alertsMtx.Lock()
+	if alert, ok := localModule.alerts[hashCode]; ok && alert.ResolvedAt == nil {
+		now := time.Now().UTC()
+		alert.ResolvedAt = &now
+		resolved = true
+	}
+	localModule.alertsMtx.Unlock()
+
+	if resolved {
+		localModule.runSaveState()
+	}
+	return
+}
+
+// Acknowledge marks an open alert as acknowledged by someone. Acknowledged alerts are not escalated any further.
+func Acknowledge(id string, by string) error {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return errors.New("Module is not active")
+	}
+
+	hashCode, err := strconv.ParseUint(strings.TrimSpace(id), 16, 64)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	localModule.alertsMtx.Lock()
+	alert, ok := localModule.alerts[hashCode]
+	if ok {
+		if alert.ResolvedAt != nil {
+			err = ErrResolved
+		} else if alert.AcknowledgedAt == nil {
+			now := time.Now().UTC()
+			alert.AcknowledgedAt = &now
+			alert.AcknowledgedBy = by
+		}
+	} else {
+		err = ErrNotFound
+	}
+	localModule.alertsMtx.Unlock()
+
+	if err == nil {
+		localModule.runSaveState()
+	}
+	return err
+}
+
+// GetAlerts returns the open alerts and the recently resolved ones, newest first
+func GetAlerts() []Alert {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	list := make([]Alert, 0)
+	if localModule == nil {
+		return list
+	}
+
+	localModule.alertsMtx.Lock()
+	for _, alert := range localModule.alerts {
+		a := *alert
+		if a.ResolvedAt != nil {
+			a.State = "resolved"
+		} else if a.AcknowledgedAt != nil {
+			a.State = "acknowledged"
+		} else {
+			a.State = "open"
+		}
+		list = append(list, a)
+	}
+	localModule.alertsMtx.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].OpenedAt.After(list[j].OpenedAt)
+	})
+
+	return list
+}
+
+//------------------------------------------------------------------------------
+
+func (m *Module) escalate() {
+	now := time.Now()
+
+	escalated := false
+
+	m.alertsMtx.Lock()
+	for _, alert := range m.alerts {
+		if alert.ResolvedAt != nil || alert.AcknowledgedAt != nil || len(alert.EscalationPolicy) == 0 {
+			continue
+		}
+
+		esc := findEscalationPolicy(alert.EscalationPolicy)
+		if esc == nil {
+			continue
+		}
+
+		//run all the steps whose delay elapsed since the last time
+		for alert.EscalationStep < len(esc.Steps) && now.Sub(alert.OpenedAt) >= esc.Steps[alert.EscalationStep].AfterX {
+			step := &esc.Steps[alert.EscalationStep]
+
+			channel := step.Channel
+			if len(channel) == 0 {
+				channel = alert.Channel
+			}
+			severity := step.Severity
+			if len(severity) == 0 {
+				severity = alert.Severity
+			}
+
+			m.runNotify(alert.Module, alert.Target, severity, channel, "Unacknowledged for %v: %v [alert %v]",
+			            step.After, alert.Message, alert.Id)
+
+			alert.EscalationStep += 1
+			escalated = true
+		}
+	}
+	m.alertsMtx.Unlock()
+
+	if escalated {
+		m.runSaveState()
+	}
+	return
+}
+
+func (m *Module) pruneResolved() {
+	now := time.Now()
+	pruned := false
+
+	m.alertsMtx.Lock()
+	for hashCode, alert := range m.alerts {
+		if alert.ResolvedAt != nil && now.Sub(*alert.ResolvedAt) > resolvedAlertsRetention {
+			delete(m.alerts, hashCode)
+			pruned = true
+		}
+	}
+	m.alertsMtx.Unlock()
+
+	if pruned {
+		m.runSaveState()
+	}
+	return
+}
+
+func (m *Module) runSaveState() {
+	if m.r.Acquire() {
+		go func(m *Module) {
+			m.saveStateMtx.Lock()
+			err := m.saveState()
+			m.saveStateMtx.Unlock()
+
+			if err != nil {
+				console.Error("Unable to save alerts state. [%v]", err)
+			}
+
+			m.r.Release()
+		}(m)
+	}
+	return
+}
+
+func (m *Module) runNotify(moduleName string, target string, severity string, channel string, format string,
+                           a ...interface{}) {
+	if m.r.Acquire() {
+		go func() {
+			_ = logger.LogTarget(moduleName, target, severity, channel, format, a...)
+
+			m.r.Release()
+		}()
+	}
+	return
+}
+
+func findEscalationPolicy(name string) *settings.SettingsJSON_Escalation {
+	for idx := range settings.Config.Escalation {
+		if settings.Config.Escalation[idx].Name == name {
+			return &settings.Config.Escalation[idx]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package alerts
+
+import (
+	"strconv"
+
+	"github.com/randlabs/server-watchdog/utils/state"
+	"github.com/vmihailenco/msgpack/v4"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	alertsStateFileName = "alerts.state"
+)
+
+//------------------------------------------------------------------------------
+
+func (m *Module) loadState() error {
+	b, err := state.LoadStateBlob(alertsStateFileName)
+	if err == nil && b != nil {
+		var loadedItems []Alert
+
+		err = msgpack.Unmarshal(b, &loadedItems)
+		if err == nil {
+			for idx := range loadedItems {
+				hashCode, err2 := strconv.ParseUint(loadedItems[idx].Id, 16, 64)
+				if err2 == nil {
+					m.alerts[hashCode] = &loadedItems[idx]
+				}
+			}
+		}
+	}
+
+	return err
+}
+
+func (m *Module) saveState() error {
+	m.alertsMtx.Lock()
+	toSave := make([]Alert, 0, len(m.alerts))
+	for _, alert := range m.alerts {
+		toSave = append(toSave, *alert)
+	}
+	b, err := msgpack.Marshal(toSave)
+	m.alertsMtx.Unlock()
+
+	if err == nil {
+		err = state.SaveStateBlob(alertsStateFileName, b)
+	}
+
+	return err
+}
@@ -0,0 +1,553 @@
+package apikeys
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	rp "github.com/randlabs/rundown-protection"
+	"github.com/randlabs/server-watchdog/console"
+	"github.com/randlabs/server-watchdog/settings"
+	"github.com/randlabs/server-watchdog/utils/timeutils"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	defaultKeyName = "default"
+
+	maxClockSkew        = 5 * time.Minute
+	nonceMinLength      = 16
+	nonceMaxLength      = 128
+	noncesPruneInterval = time.Minute
+)
+
+//------------------------------------------------------------------------------
+
+type Module struct {
+	keysMtx       sync.RWMutex
+	keys          map[string]*Key //indexed by key hash
+	certKeys      map[string]*Key //indexed by client certificate common name
+	revokedHashes map[string]struct{}
+	noncesMtx     sync.Mutex
+	nonces        map[string]time.Time //used nonces along with their expiration time
+	lastPrune     time.Time
+	r             rp.RundownProtection
+	saveStateMtx  sync.Mutex //serializes the writes to the state file
+}
+
+// Key is an API key along with the endpoints and channels it can access
+type Key struct {
+	LastUsedAt int64 //first to keep it 64-bit aligned on 32-bit platforms because it is accessed atomically
+	Name       string
+	Hash       string
+	Scopes     []string
+	Channels   []string
+	Source     string
+	CreatedAt  time.Time
+}
+
+type KeyInfo struct {
+	Name       string     `json:"name"`
+	Source     string     `json:"source"`
+	Scopes     []string   `json:"scopes"`
+	Channels   []string   `json:"channels,omitempty"`
+	CreatedAt  *time.Time `json:"createdAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+//------------------------------------------------------------------------------
+
+var module *Module
+var lock sync.RWMutex
+
+var ErrNotFound = errors.New("API key not found")
+var ErrAlreadyExists = errors.New("An API key with the same name already exists")
+var ErrReadOnly = errors.New("API keys defined in the settings file must be rotated in the settings file")
+var ErrInvalidSignature = errors.New("Invalid request signature")
+var ErrClockSkew = errors.New("Request timestamp is too old or in the future")
+var ErrNonceReused = errors.New("Request nonce was already used")
+
+//------------------------------------------------------------------------------
+
+func Start() error {
+	//initialize module
+	module = &Module{}
+	module.keys = make(map[string]*Key)
+	module.revokedHashes = make(map[string]struct{})
+	module.nonces = make(map[string]time.Time)
+	module.r.Initialize()
+
+	module.setSettingsKeys(settings.Config.Server.ApiKey, settings.Config.Server.ApiKeys)
+
+	//clients authenticated with a certificate get the access defined in the tls settings
+	module.certKeys = make(map[string]*Key)
+	if settings.Config.Server.Tls != nil && settings.Config.Server.Tls.ClientAuth != nil {
+		ca := settings.Config.Server.Tls.ClientAuth
+
+		for _, cn := range ca.AllowedCNs {
+			module.certKeys[cn] = &Key{
+				Name:     "cn:" + cn,
+				Scopes:   ca.Scopes,
+				Channels: ca.Channels,
+				Source:   "certificate",
+			}
+		}
+	}
+
+	//load stored keys
+	err := module.loadState()
+	if err != nil {
+		console.Error("Unable to load API keys state. [%v]", err)
+		return err
+	}
+
+	return nil
+}
+
+func Stop() {
+	lock.Lock()
+	localModule := module
+	module = nil
+	lock.Unlock()
+
+	if localModule != nil {
+		//wait until all workers are done
+		localModule.r.Wait()
+	}
+	return
+}
+
+// Authenticate returns the key that matches the provided secret or nil if it is unknown or was revoked
+func Authenticate(secret string) *Key {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil || len(secret) == 0 {
+		return nil
+	}
+
+	hash := hashSecret(secret)
+
+	localModule.keysMtx.RLock()
+	key, ok := localModule.keys[hash]
+	if ok {
+		if _, revoked := localModule.revokedHashes[hash]; revoked {
+			key = nil
+		}
+	}
+	localModule.keysMtx.RUnlock()
+
+	if key != nil {
+		atomic.StoreInt64(&key.LastUsedAt, time.Now().UnixNano())
+	}
+	return key
+}
+
+// AuthenticateCertificate returns the access granted to a verified client certificate or nil if its common name is
+// not allowed
+func AuthenticateCertificate(cn string) *Key {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return nil
+	}
+
+	//the map is not modified after the module starts
+	key, ok := localModule.certKeys[cn]
+	if !ok {
+		return nil
+	}
+
+	atomic.StoreInt64(&key.LastUsedAt, time.Now().UnixNano())
+	return key
+}
+
+// AuthenticateSignature verifies a signed request and returns the key used to sign it. The signature is the hex
+// encoded HMAC-SHA256 of the method, path, timestamp, nonce and body, one per line, using the SHA-256 hash of the
+// API key as the secret. Each nonce can be used once within the allowed clock skew.
+func AuthenticateSignature(name string, timestamp string, nonce string, signature string, method string, path string,
+                           body []byte) (*Key, error) {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return nil, errors.New("Module is not active")
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	now := time.Now()
+	skew := now.Sub(time.Unix(ts, 0))
+	if skew > maxClockSkew || skew < -maxClockSkew {
+		return nil, ErrClockSkew
+	}
+
+	if len(nonce) < nonceMinLength || len(nonce) > nonceMaxLength {
+		return nil, ErrInvalidSignature
+	}
+
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	localModule.keysMtx.RLock()
+	key := localModule.findKey(name)
+	localModule.keysMtx.RUnlock()
+	if key == nil {
+		return nil, ErrInvalidSignature
+	}
+
+	//only the hash of the key is stored so it is the shared secret
+	secret, err := hex.DecodeString(key.Hash)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	if !hmac.Equal(sig, signRequest(secret, method, path, timestamp, nonce, body)) {
+		return nil, ErrInvalidSignature
+	}
+
+	//only requests with a valid signature are tracked so the nonces list cannot be flooded by unknown clients
+	if !localModule.useNonce(name + ":" + nonce, now) {
+		return nil, ErrNonceReused
+	}
+
+	atomic.StoreInt64(&key.LastUsedAt, now.UnixNano())
+	return key, nil
+}
+
+// HasScope returns true if the key can access the given group of endpoints. Admin keys can access all of them.
+func (k *Key) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == "admin" {
+			return true
+		}
+	}
+	return false
+}
+
+// CanUseChannel returns true if the key is not restricted to a set of channels or the channel is one of them
+func (k *Key) CanUseChannel(channel string) bool {
+	if len(k.Channels) == 0 {
+		return true
+	}
+	for _, ch := range k.Channels {
+		if ch == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// Create adds a new API key and returns its secret. Only the hash of the secret is stored so it cannot be retrieved
+// later.
+func Create(name string, scopes []string, channels []string) (string, error) {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return "", errors.New("Module is not active")
+	}
+
+	cfg := settings.SettingsJSON_ApiKey{
+		Name:     name,
+		Scopes:   scopes,
+		Channels: channels,
+	}
+	err := settings.ValidateApiKey(&cfg)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return "", err
+	}
+
+	localModule.keysMtx.Lock()
+	if localModule.findKey(name) == nil {
+		key := &Key{
+			Name:      name,
+			Hash:      hashSecret(secret),
+			Scopes:    scopes,
+			Channels:  channels,
+			Source:    "api",
+			CreatedAt: time.Now().UTC(),
+		}
+		localModule.keys[key.Hash] = key
+	} else {
+		err = ErrAlreadyExists
+	}
+	localModule.keysMtx.Unlock()
+
+	if err != nil {
+		return "", err
+	}
+
+	localModule.runSaveState()
+	return secret, nil
+}
+
+// Rotate replaces the secret of a key created at runtime and returns the new one. The old secret stops working
+// immediately.
+func Rotate(name string) (string, error) {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return "", errors.New("Module is not active")
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return "", err
+	}
+
+	localModule.keysMtx.Lock()
+	key := localModule.findKey(name)
+	if key != nil {
+		if key.Source == "api" {
+			delete(localModule.keys, key.Hash)
+			key.Hash = hashSecret(secret)
+			localModule.keys[key.Hash] = key
+		} else {
+			err = ErrReadOnly
+		}
+	} else {
+		err = ErrNotFound
+	}
+	localModule.keysMtx.Unlock()
+
+	if err != nil {
+		return "", err
+	}
+
+	localModule.runSaveState()
+	return secret, nil
+}
+
+// Revoke disables a key. Keys created at runtime are deleted. Keys defined in the settings file remain revoked until
+// their hash is changed in the settings file.
+func Revoke(name string) error {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return errors.New("Module is not active")
+	}
+
+	var err error
+
+	localModule.keysMtx.Lock()
+	key := localModule.findKey(name)
+	if key != nil {
+		if key.Source == "api" {
+			delete(localModule.keys, key.Hash)
+		} else {
+			localModule.revokedHashes[key.Hash] = struct{}{}
+		}
+	} else {
+		err = ErrNotFound
+	}
+	localModule.keysMtx.Unlock()
+
+	if err == nil {
+		localModule.runSaveState()
+	}
+	return err
+}
+
+// Reload reads the keys defined in the settings file again. Keys created at runtime are not affected.
+func Reload() error {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return errors.New("Module is not active")
+	}
+
+	apiKey, apiKeys, err := settings.LoadApiKeys()
+	if err != nil {
+		return err
+	}
+
+	localModule.setSettingsKeys(apiKey, apiKeys)
+
+	localModule.runSaveState()
+	return nil
+}
+
+// GetKeys returns the active keys without their secrets
+func GetKeys() []KeyInfo {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	list := make([]KeyInfo, 0)
+	if localModule == nil {
+		return list
+	}
+
+	localModule.keysMtx.RLock()
+	for hash, key := range localModule.keys {
+		if _, revoked := localModule.revokedHashes[hash]; revoked {
+			continue
+		}
+
+		info := KeyInfo{
+			Name:       key.Name,
+			Source:     key.Source,
+			Scopes:     key.Scopes,
+			Channels:   key.Channels,
+			LastUsedAt: timeutils.FromUnixNano(atomic.LoadInt64(&key.LastUsedAt)),
+		}
+		if !key.CreatedAt.IsZero() {
+			createdAt := key.CreatedAt
+			info.CreatedAt = &createdAt
+		}
+		list = append(list, info)
+	}
+	localModule.keysMtx.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+//------------------------------------------------------------------------------
+
+//NOTE: Must be called with the keys lock held
+func (m *Module) findKey(name string) *Key {
+	for hash, key := range m.keys {
+		if key.Name == name {
+			if _, revoked := m.revokedHashes[hash]; !revoked {
+				return key
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Module) useNonce(nonce string, now time.Time) bool {
+	m.noncesMtx.Lock()
+	defer m.noncesMtx.Unlock()
+
+	if now.Sub(m.lastPrune) >= noncesPruneInterval {
+		for n, expiration := range m.nonces {
+			if now.After(expiration) {
+				delete(m.nonces, n)
+			}
+		}
+		m.lastPrune = now
+	}
+
+	if expiration, ok := m.nonces[nonce]; ok && !now.After(expiration) {
+		return false
+	}
+
+	//a nonce must be remembered while a request with the same timestamp is still accepted
+	m.nonces[nonce] = now.Add(2 * maxClockSkew)
+	return true
+}
+
+func (m *Module) setSettingsKeys(apiKey string, apiKeys []settings.SettingsJSON_ApiKey) {
+	m.keysMtx.Lock()
+
+	for hash, key := range m.keys {
+		if key.Source == "settings" {
+			delete(m.keys, hash)
+		}
+	}
+
+	//the global key has full access
+	if len(apiKey) > 0 {
+		key := &Key{
+			Name:   defaultKeyName,
+			Hash:   hashSecret(apiKey),
+			Scopes: []string{ "admin" },
+			Source: "settings",
+		}
+		m.keys[key.Hash] = key
+	}
+
+	for idx := range apiKeys {
+		key := &Key{
+			Name:     apiKeys[idx].Name,
+			Hash:     apiKeys[idx].KeyHash,
+			Scopes:   apiKeys[idx].Scopes,
+			Channels: apiKeys[idx].Channels,
+			Source:   "settings",
+		}
+		m.keys[key.Hash] = key
+	}
+
+	//forget revocations of keys no longer present in the settings file
+	for hash := range m.revokedHashes {
+		if _, ok := m.keys[hash]; !ok {
+			delete(m.revokedHashes, hash)
+		}
+	}
+
+	m.keysMtx.Unlock()
+	return
+}
+
+func (m *Module) runSaveState() {
+	if m.r.Acquire() {
+		go func(m *Module) {
+			m.saveStateMtx.Lock()
+			err := m.saveState()
+			m.saveStateMtx.Unlock()
+
+			if err != nil {
+				console.Error("Unable to save API keys state. [%v]", err)
+			}
+
+			m.r.Release()
+		}(m)
+	}
+	return
+}
+
+func hashSecret(secret string) string {
+	h := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:])
+}
+
+// signRequest returns the HMAC-SHA256 of a request, the one clients must send in the X-Signature header
+func signRequest(secret []byte, method string, path string, timestamp string, nonce string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(method + "\n" + path + "\n" + timestamp + "\n" + nonce + "\n"))
+	_, _ = mac.Write(body)
+	return mac.Sum(nil)
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,275 @@
+package apikeys
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/randlabs/server-watchdog/settings"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	testSecret      = "global-secret"
+	testNamedSecret = "named-secret"
+	testNonce       = "0123456789abcdef0123"
+)
+
+//------------------------------------------------------------------------------
+
+func TestAuthenticateSignature(t *testing.T) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-2 * maxClockSkew).Unix(), 10)
+	future := strconv.FormatInt(time.Now().Add(2 * maxClockSkew).Unix(), 10)
+
+	tests := []struct {
+		name      string
+		keyName   string
+		secret    string //used to sign the request
+		timestamp string
+		nonce     string
+		path      string //sent instead of the signed path if set
+		body      string //sent instead of the signed body if set
+		signature string //sent instead of the computed signature if set
+		err       error
+	}{
+		{
+			name:    "global key",
+			keyName: defaultKeyName,
+			secret:  testSecret,
+			nonce:   "nonce-global-0001",
+		},
+		{
+			name:    "named key",
+			keyName: "named",
+			secret:  testNamedSecret,
+			nonce:   "nonce-named-00001",
+		},
+		{
+			name:    "unknown key",
+			keyName: "unknown",
+			secret:  testSecret,
+			nonce:   "nonce-unknown-001",
+			err:     ErrInvalidSignature,
+		},
+		{
+			name:    "signed with another key",
+			keyName: defaultKeyName,
+			secret:  testNamedSecret,
+			nonce:   "nonce-other-00001",
+			err:     ErrInvalidSignature,
+		},
+		{
+			name:    "tampered body",
+			keyName: defaultKeyName,
+			secret:  testSecret,
+			nonce:   "nonce-body-000001",
+			body:    `{"message":"tampered"}`,
+			err:     ErrInvalidSignature,
+		},
+		{
+			name:    "tampered path",
+			keyName: defaultKeyName,
+			secret:  testSecret,
+			nonce:   "nonce-path-000001",
+			path:    "/notify/batch",
+			err:     ErrInvalidSignature,
+		},
+		{
+			name:      "old timestamp",
+			keyName:   defaultKeyName,
+			secret:    testSecret,
+			timestamp: old,
+			nonce:     "nonce-old-0000001",
+			err:       ErrClockSkew,
+		},
+		{
+			name:      "future timestamp",
+			keyName:   defaultKeyName,
+			secret:    testSecret,
+			timestamp: future,
+			nonce:     "nonce-future-0001",
+			err:       ErrClockSkew,
+		},
+		{
+			name:      "invalid timestamp",
+			keyName:   defaultKeyName,
+			secret:    testSecret,
+			timestamp: "yesterday",
+			nonce:     "nonce-ts-00000001",
+			err:       ErrInvalidSignature,
+		},
+		{
+			name:    "short nonce",
+			keyName: defaultKeyName,
+			secret:  testSecret,
+			nonce:   "short",
+			err:     ErrInvalidSignature,
+		},
+		{
+			name:      "malformed signature",
+			keyName:   defaultKeyName,
+			secret:    testSecret,
+			nonce:     "nonce-malformed-1",
+			signature: "not-hex",
+			err:       ErrInvalidSignature,
+		},
+		{
+			name:      "signed with the key instead of its hash",
+			keyName:   defaultKeyName,
+			secret:    testSecret,
+			nonce:     "nonce-raw-key-001",
+			signature: hex.EncodeToString(signRequest([]byte(testSecret), "POST", "/notify", now, "nonce-raw-key-001",
+			                                          []byte(`{"message":"test"}`))),
+			err:       ErrInvalidSignature,
+		},
+	}
+
+	startTestModule()
+	defer stopTestModule()
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			signedPath := "/notify"
+			signedBody := []byte(`{"message":"test"}`)
+
+			timestamp := tc.timestamp
+			if len(timestamp) == 0 {
+				timestamp = now
+			}
+			signature := tc.signature
+			if len(signature) == 0 {
+				signature = sign(tc.secret, "POST", signedPath, timestamp, tc.nonce, signedBody)
+			}
+			path := signedPath
+			if len(tc.path) > 0 {
+				path = tc.path
+			}
+			body := signedBody
+			if len(tc.body) > 0 {
+				body = []byte(tc.body)
+			}
+
+			key, err := AuthenticateSignature(tc.keyName, timestamp, tc.nonce, signature, "POST", path, body)
+			if err != tc.err {
+				t.Fatalf("got error %v, want %v", err, tc.err)
+			}
+			if err == nil && key.Name != tc.keyName {
+				t.Fatalf("got key %v, want %v", key.Name, tc.keyName)
+			}
+		})
+	}
+}
+
+func TestRevokedKeyCannotSign(t *testing.T) {
+	m := startTestModule()
+	defer stopTestModule()
+
+	m.revokedHashes[hashSecret(testNamedSecret)] = struct{}{}
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	sig := sign(testNamedSecret, "GET", "/status", now, testNonce, nil)
+
+	_, err := AuthenticateSignature("named", now, testNonce, sig, "GET", "/status", nil)
+	if err != ErrInvalidSignature {
+		t.Fatalf("got error %v, want %v", err, ErrInvalidSignature)
+	}
+}
+
+func TestNonceReplay(t *testing.T) {
+	startTestModule()
+	defer stopTestModule()
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+
+	tests := []struct {
+		name    string
+		keyName string
+		secret  string
+		err     error
+	}{
+		{ "first use", defaultKeyName, testSecret, nil },
+		{ "replayed", defaultKeyName, testSecret, ErrNonceReused },
+		{ "same nonce with another key", "named", testNamedSecret, nil },
+		{ "replayed with another key", "named", testNamedSecret, ErrNonceReused },
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sig := sign(tc.secret, "GET", "/status", now, testNonce, nil)
+			_, err := AuthenticateSignature(tc.keyName, now, testNonce, sig, "GET", "/status", nil)
+			if err != tc.err {
+				t.Fatalf("got error %v, want %v", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestNonceExpiration(t *testing.T) {
+	m := newTestModule()
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{ "first use", now, true },
+		{ "within the skew window", now.Add(maxClockSkew), false },
+		{ "at the expiration", now.Add(2 * maxClockSkew), false },
+		{ "after the expiration", now.Add(2 * maxClockSkew + time.Second), true },
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := m.useNonce(testNonce, tc.at); got != tc.want {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func newTestModule() *Module {
+	return &Module{
+		keys:          make(map[string]*Key),
+		certKeys:      make(map[string]*Key),
+		revokedHashes: make(map[string]struct{}),
+		nonces:        make(map[string]time.Time),
+	}
+}
+
+// startTestModule activates a module with the global key and a named key
+func startTestModule() *Module {
+	m := newTestModule()
+	m.setSettingsKeys(testSecret, []settings.SettingsJSON_ApiKey{
+		{
+			Name:    "named",
+			KeyHash: hashSecret(testNamedSecret),
+			Scopes:  []string{ "notify" },
+		},
+	})
+
+	lock.Lock()
+	module = m
+	lock.Unlock()
+
+	return m
+}
+
+func stopTestModule() {
+	lock.Lock()
+	module = nil
+	lock.Unlock()
+	return
+}
+
+// sign computes the signature the way clients do, from the API key itself
+func sign(secret string, method string, path string, timestamp string, nonce string, body []byte) string {
+	h := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(signRequest(h[:], method, path, timestamp, nonce, body))
+}
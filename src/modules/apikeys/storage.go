@@ -0,0 +1,97 @@
+package apikeys
+
+import (
+	"time"
+
+	"github.com/randlabs/server-watchdog/utils/state"
+	"github.com/vmihailenco/msgpack/v4"
+)
+
+//------------------------------------------------------------------------------
+
+type ApiKeysState struct {
+	Keys          []ApiKeysStateItem
+	RevokedHashes []string
+}
+
+type ApiKeysStateItem struct {
+	Name      string
+	Hash      string
+	Scopes    []string
+	Channels  []string
+	CreatedAt time.Time
+}
+
+//------------------------------------------------------------------------------
+
+const (
+	apiKeysStateFileName = "apikeys.state"
+)
+
+//------------------------------------------------------------------------------
+
+func (m *Module) loadState() error {
+	b, err := state.LoadStateBlob(apiKeysStateFileName)
+	if err == nil && b != nil {
+		var loaded ApiKeysState
+
+		err = msgpack.Unmarshal(b, &loaded)
+		if err == nil {
+			m.keysMtx.Lock()
+
+			for _, v := range loaded.Keys {
+				if _, ok := m.keys[v.Hash]; !ok {
+					m.keys[v.Hash] = &Key{
+						Name:      v.Name,
+						Hash:      v.Hash,
+						Scopes:    v.Scopes,
+						Channels:  v.Channels,
+						Source:    "api",
+						CreatedAt: v.CreatedAt,
+					}
+				}
+			}
+
+			//revocations only apply while the key is still defined in the settings file
+			for _, hash := range loaded.RevokedHashes {
+				if key, ok := m.keys[hash]; ok && key.Source == "settings" {
+					m.revokedHashes[hash] = struct{}{}
+				}
+			}
+
+			m.keysMtx.Unlock()
+		}
+	}
+
+	return err
+}
+
+func (m *Module) saveState() error {
+	var toSave ApiKeysState
+
+	m.keysMtx.RLock()
+	toSave.Keys = make([]ApiKeysStateItem, 0, len(m.keys))
+	for _, v := range m.keys {
+		if v.Source == "api" {
+			toSave.Keys = append(toSave.Keys, ApiKeysStateItem{
+				Name      : v.Name,
+				Hash      : v.Hash,
+				Scopes    : v.Scopes,
+				Channels  : v.Channels,
+				CreatedAt : v.CreatedAt,
+			})
+		}
+	}
+	toSave.RevokedHashes = make([]string, 0, len(m.revokedHashes))
+	for hash := range m.revokedHashes {
+		toSave.RevokedHashes = append(toSave.RevokedHashes, hash)
+	}
+	m.keysMtx.RUnlock()
+
+	b, err := msgpack.Marshal(toSave)
+	if err == nil {
+		err = state.SaveStateBlob(apiKeysStateFileName, b)
+	}
+
+	return err
+}
@@ -1,18 +1,31 @@
 package backend
 
 import (
+	"crypto/tls"
+	"net"
 	"sync"
+	"time"
 
 	"github.com/randlabs/server-watchdog/console"
+	"github.com/randlabs/server-watchdog/modules/backend/grpchandlers"
 	"github.com/randlabs/server-watchdog/modules/backend/handlers"
 	"github.com/randlabs/server-watchdog/server"
 	"github.com/randlabs/server-watchdog/settings"
+	"google.golang.org/grpc"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	grpcShutdownTimeout = 10 * time.Second
 )
 
 //------------------------------------------------------------------------------
 
 type Module struct {
-	svr *server.Server
+	svr          *server.Server
+	grpcSvr      *grpc.Server
+	grpcListener net.Listener
 }
 
 //------------------------------------------------------------------------------
@@ -25,8 +38,35 @@ var lock sync.RWMutex
 func Start() error {
 	var err error
 
+	var tlsConfig *tls.Config
+	if t := settings.Config.Server.Tls; t != nil {
+		opts := server.TLSOptions{
+			CertFile:   t.CertFile,
+			KeyFile:    t.KeyFile,
+			MinVersion: t.MinVersionX,
+		}
+		if t.ClientAuth != nil {
+			opts.ClientCAFile = t.ClientAuth.CAFile
+			opts.RequireClientCert = t.ClientAuth.Required
+		}
+
+		tlsConfig, err = server.NewTLSConfig(opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	addresses := make([]server.ListenAddress, len(settings.Config.Server.ListenX))
+	for idx, addr := range settings.Config.Server.ListenX {
+		addresses[idx] = server.ListenAddress{
+			Network:    addr.Network,
+			Address:    addr.Address,
+			SocketMode: settings.Config.Server.SocketModeX,
+		}
+	}
+
 	module = &Module{}
-	module.svr, err = server.Create(settings.Config.Server.Port, false)
+	module.svr, err = server.Create(addresses, false, tlsConfig)
 	if err != nil {
 		module = nil
 		return err
@@ -34,6 +74,17 @@ func Start() error {
 
 	handlers.Initialize(module.svr.Router)
 
+	//the gRPC API shares the authentication and processing of requests with the HTTP one
+	if g := settings.Config.Server.Grpc; g != nil {
+		module.grpcListener, err = net.Listen(g.ListenX.Network, g.ListenX.Address)
+		if err != nil {
+			module.svr.Stop()
+			module = nil
+			return err
+		}
+		module.grpcSvr = grpchandlers.NewServer(tlsConfig)
+	}
+
 	return nil
 }
 
@@ -45,6 +96,15 @@ func Stop() {
 
 	if localModule != nil {
 		localModule.svr.Stop()
+
+		if localModule.grpcSvr != nil {
+			//streams may be kept open by clients so don't wait forever for them
+			t := time.AfterFunc(grpcShutdownTimeout, localModule.grpcSvr.Stop)
+			localModule.grpcSvr.GracefulStop()
+			t.Stop()
+
+			_ = localModule.grpcListener.Close()
+		}
 	}
 
 	return
@@ -66,6 +126,19 @@ func Run(wg sync.WaitGroup) {
 
 			wg.Done()
 		}()
+
+		if localModule.grpcSvr != nil {
+			wg.Add(1)
+
+			go func() {
+				err := localModule.grpcSvr.Serve(localModule.grpcListener)
+				if err != nil {
+					console.Error("gRPC server error [%v]", err)
+				}
+
+				wg.Done()
+			}()
+		}
 	}
 
 	return
@@ -0,0 +1,154 @@
+package grpchandlers
+
+import (
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/randlabs/server-watchdog/modules/backend/handlers"
+	"github.com/randlabs/server-watchdog/modules/backend/pb"
+	"github.com/randlabs/server-watchdog/modules/jobs"
+)
+
+//------------------------------------------------------------------------------
+
+func fromNotifyRequest(req *pb.NotifyRequest) (*handlers.NotifyRequest, error) {
+	r := &handlers.NotifyRequest{
+		Channel:  req.Channel,
+		Message:  req.Message,
+		Severity: req.Severity,
+		Source:   req.Source,
+		Host:     req.Host,
+		DedupKey: req.DedupKey,
+	}
+	if req.Timestamp != nil {
+		ts, err := ptypes.Timestamp(req.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		r.Timestamp = &ts
+	}
+	return r, nil
+}
+
+func toStatusResponse(s handlers.StatusResponse) *pb.GetStatusResponse {
+	res := &pb.GetStatusResponse{
+		Webs:       make([]*pb.MonitorStatus, len(s.Webs)),
+		TcpPorts:   make([]*pb.MonitorStatus, len(s.TcpPorts)),
+		Disks:      make([]*pb.MonitorStatus, len(s.Disks)),
+		Processes:  make([]*pb.ProcessStatus, len(s.Processes)),
+		Heartbeats: make([]*pb.HeartbeatStatus, len(s.Heartbeats)),
+		Jobs:       make([]*pb.JobStatus, len(s.Jobs)),
+	}
+
+	for idx, v := range s.Webs {
+		res.Webs[idx] = &pb.MonitorStatus{
+			Id:                  v.Id,
+			Source:              v.Source,
+			Name:                v.Config.Url,
+			Channel:             v.Config.Channel,
+			Failing:             v.Failing,
+			Flapping:            v.Flapping,
+			Paused:              v.Paused,
+			ConsecutiveFailures: v.ConsecutiveFailures,
+			LastCheck:           toTimestamp(v.LastCheck),
+			NextCheck:           toTimestamp(v.NextCheck),
+			LastNotification:    toTimestamp(v.LastNotification),
+		}
+	}
+	for idx, v := range s.TcpPorts {
+		res.TcpPorts[idx] = &pb.MonitorStatus{
+			Id:                  v.Id,
+			Source:              v.Source,
+			Name:                v.Config.Name,
+			Channel:             v.Config.Channel,
+			Failing:             v.Failing,
+			Flapping:            v.Flapping,
+			Paused:              v.Paused,
+			ConsecutiveFailures: v.ConsecutiveFailures,
+			LastCheck:           toTimestamp(v.LastCheck),
+			NextCheck:           toTimestamp(v.NextCheck),
+			LastNotification:    toTimestamp(v.LastNotification),
+		}
+	}
+	for idx, v := range s.Disks {
+		res.Disks[idx] = &pb.MonitorStatus{
+			Id:                  v.Id,
+			Source:              v.Source,
+			Name:                v.Config.Device,
+			Channel:             v.Config.Channel,
+			Failing:             v.Failing,
+			Flapping:            v.Flapping,
+			Paused:              v.Paused,
+			ConsecutiveFailures: v.ConsecutiveFailures,
+			LastCheck:           toTimestamp(v.LastCheck),
+			NextCheck:           toTimestamp(v.NextCheck),
+			LastNotification:    toTimestamp(v.LastNotification),
+		}
+	}
+	for idx, v := range s.Processes {
+		res.Processes[idx] = &pb.ProcessStatus{
+			Pid:          int32(v.Pid),
+			Name:         v.Name,
+			Channel:      v.Channel,
+			Severity:     v.Severity,
+			MemoryUsage:  v.MemoryUsage,
+			WatchedSince: toTimestamp(&v.WatchedSince),
+			LastCheck:    toTimestamp(v.LastCheck),
+		}
+	}
+	for idx, v := range s.Heartbeats {
+		res.Heartbeats[idx] = &pb.HeartbeatStatus{
+			Name:             v.Config.Name,
+			Channel:          v.Config.Channel,
+			Overdue:          v.Overdue,
+			LastPing:         toTimestamp(v.LastPing),
+			Deadline:         toTimestamp(v.Deadline),
+			LastNotification: toTimestamp(v.LastNotification),
+		}
+	}
+	for idx, v := range s.Jobs {
+		res.Jobs[idx] = &pb.JobStatus{
+			Name:             v.Config.Name,
+			Source:           v.Source,
+			Channel:          v.Config.Channel,
+			Running:          v.Running,
+			Failing:          v.Failing,
+			CurrentRun:       toJobRun(v.CurrentRun),
+			LastRun:          toJobRun(v.LastRun),
+			ExpectedStart:    toTimestamp(v.ExpectedStart),
+			LastNotification: toTimestamp(v.LastNotification),
+		}
+	}
+
+	return res
+}
+
+func toJobRun(run *jobs.JobRun) *pb.JobRun {
+	if run == nil {
+		return nil
+	}
+
+	res := &pb.JobRun{
+		StartedAt:  toTimestamp(&run.StartedAt),
+		FinishedAt: toTimestamp(run.FinishedAt),
+		Succeeded:  run.Succeeded,
+	}
+	if run.ExitCode != nil {
+		res.HasExitCode = true
+		res.ExitCode = int32(*run.ExitCode)
+	}
+	return res
+}
+
+func toTimestamp(t *time.Time) *timestamp.Timestamp {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+
+	ts, err := ptypes.TimestampProto(*t)
+	if err != nil {
+		return nil
+	}
+	return ts
+}
@@ -0,0 +1,271 @@
+package grpchandlers
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strings"
+
+	"github.com/randlabs/server-watchdog/modules/apikeys"
+	"github.com/randlabs/server-watchdog/modules/backend/handlers"
+	"github.com/randlabs/server-watchdog/modules/backend/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+//go:generate protoc --go_out=plugins=grpc,paths=source_relative:../pb --proto_path=../pb watchdog.proto
+
+//------------------------------------------------------------------------------
+
+type service struct {
+}
+
+type apiKeyCtxKey struct{}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+//------------------------------------------------------------------------------
+
+// The scope required by each method. Same as their HTTP counterparts.
+var methodScopes = map[string]string{
+	"/watchdog.Watchdog/Notify":         "notify",
+	"/watchdog.Watchdog/NotifyStream":   "notify",
+	"/watchdog.Watchdog/WatchProcess":   "process",
+	"/watchdog.Watchdog/UnwatchProcess": "process",
+	"/watchdog.Watchdog/GetStatus":      "read",
+}
+
+//------------------------------------------------------------------------------
+
+// NewServer creates the gRPC server. Requests are authenticated and processed like the ones sent to the HTTP backend
+// so handlers.Initialize must be called first.
+func NewServer(tlsConfig *tls.Config) *grpc.Server {
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(unaryInterceptor),
+		grpc.StreamInterceptor(streamInterceptor),
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	svr := grpc.NewServer(opts...)
+	pb.RegisterWatchdogServer(svr, &service{})
+	return svr
+}
+
+//------------------------------------------------------------------------------
+
+func (s *service) Notify(ctx context.Context, req *pb.NotifyRequest) (*pb.NotifyResponse, error) {
+	key := apiKeyFromCtx(ctx)
+
+	retryAfter, ok := handlers.CheckRateLimit(peerIP(ctx), key)
+	if !ok {
+		return nil, status.Errorf(codes.ResourceExhausted, "Too many requests. Retry after %v seconds.",
+		                        math.Ceil(retryAfter.Seconds()))
+	}
+
+	r, err := fromNotifyRequest(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	sent, err := handlers.SendNotification(key, r)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.NotifyResponse{
+		Success:   true,
+		Duplicate: !sent,
+	}, nil
+}
+
+func (s *service) NotifyStream(stream pb.Watchdog_NotifyStreamServer) error {
+	key := apiKeyFromCtx(stream.Context())
+	ip := peerIP(stream.Context())
+
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		//each notification is processed on its own so a bad one does not end the stream
+		var res pb.NotifyResponse
+
+		if retryAfter, ok := handlers.CheckRateLimit(ip, key); ok {
+			r, err := fromNotifyRequest(req)
+			if err == nil {
+				var sent bool
+
+				sent, err = handlers.SendNotification(key, r)
+				if err == nil {
+					res.Success = true
+					res.Duplicate = !sent
+				}
+			}
+			if err != nil {
+				res.Error = err.Error()
+			}
+		} else {
+			res.Error = fmt.Sprintf("Too many requests. Retry after %v seconds.", math.Ceil(retryAfter.Seconds()))
+		}
+
+		err = stream.Send(&res)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *service) WatchProcess(ctx context.Context, req *pb.WatchProcessRequest) (*pb.WatchProcessResponse, error) {
+	key := apiKeyFromCtx(ctx)
+
+	retryAfter, ok := handlers.CheckRateLimit(peerIP(ctx), key)
+	if !ok {
+		return nil, status.Errorf(codes.ResourceExhausted, "Too many requests. Retry after %v seconds.",
+		                        math.Ceil(retryAfter.Seconds()))
+	}
+
+	err := handlers.WatchProcess(key, &handlers.WatchProcessRequest{
+		Channel:     req.Channel,
+		Pid:         int(req.Pid),
+		MaxMemUsage: req.MaxMem,
+		Name:        req.Name,
+		Severity:    req.Severity,
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.WatchProcessResponse{}, nil
+}
+
+func (s *service) UnwatchProcess(ctx context.Context, req *pb.UnwatchProcessRequest) (*pb.UnwatchProcessResponse,
+                                                                                       error) {
+	err := handlers.UnwatchProcess(apiKeyFromCtx(ctx), &handlers.UnwatchProcessRequest{
+		Channel: req.Channel,
+		Pid:     int(req.Pid),
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.UnwatchProcessResponse{}, nil
+}
+
+func (s *service) GetStatus(_ context.Context, _ *pb.GetStatusRequest) (*pb.GetStatusResponse, error) {
+	return toStatusResponse(handlers.GetStatus()), nil
+}
+
+//------------------------------------------------------------------------------
+
+func unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+                      handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+                       handler grpc.StreamHandler) error {
+	ctx, err := authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{
+		ServerStream: ss,
+		ctx:          ctx,
+	})
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// authenticate checks the API key or the client certificate sent with the request and stores the key in the context
+func authenticate(ctx context.Context, method string) (context.Context, error) {
+	var key *apikeys.Key
+
+	scope, ok := methodScopes[method]
+	if !ok {
+		scope = "admin"
+	}
+
+	apiKey := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("x-api-key"); len(values) > 0 {
+			apiKey = values[0]
+		} else if values = md.Get("authorization"); len(values) > 0 {
+			if len(values[0]) > 7 && strings.EqualFold(values[0][:7], "Bearer ") {
+				apiKey = values[0][7:]
+			}
+		}
+	}
+
+	if len(apiKey) > 0 {
+		key = apikeys.Authenticate(apiKey)
+	} else if cn, ok := clientCertificateCN(ctx); ok {
+		//clients with an allowed certificate do not need an API key
+		key = apikeys.AuthenticateCertificate(cn)
+	}
+	if key == nil {
+		return nil, status.Error(codes.Unauthenticated, "Invalid API key")
+	}
+	if !key.HasScope(scope) {
+		return nil, status.Error(codes.PermissionDenied, "API key not allowed to use this method")
+	}
+
+	return context.WithValue(ctx, apiKeyCtxKey{}, key), nil
+}
+
+func apiKeyFromCtx(ctx context.Context) *apikeys.Key {
+	key, _ := ctx.Value(apiKeyCtxKey{}).(*apikeys.Key)
+	return key
+}
+
+func clientCertificateCN(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.PeerCertificates) == 0 {
+		return "", false
+	}
+	return info.State.PeerCertificates[0].Subject.CommonName, true
+}
+
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	if addr, ok := p.Addr.(*net.TCPAddr); ok {
+		return addr.IP.String()
+	}
+	//unix socket clients have no address
+	return ""
+}
+
+func toStatusError(err error) error {
+	if err == handlers.ErrChannelNotAllowed {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
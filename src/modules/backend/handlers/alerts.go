@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"github.com/randlabs/server-watchdog/modules/alerts"
+	"github.com/randlabs/server-watchdog/server"
+)
+
+//------------------------------------------------------------------------------
+
+func onGetAlerts(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "read") {
+		return
+	}
+
+	server.SendJSON(ctx, alerts.GetAlerts())
+	return
+}
+
+func onPostAckAlert(ctx *server.RequestCtx) {
+	var r AckAlertRequest
+
+	if !checkApiKey(ctx, "ack") {
+		return
+	}
+
+	//the body is optional
+	if len(ctx.PostBody()) > 0 {
+		err := json.Unmarshal(ctx.PostBody(), &r)
+		if err != nil {
+			server.SendBadRequest(ctx, "")
+			return
+		}
+	}
+
+	//if not specified, the alert is acknowledged by the key used in the request
+	if len(r.By) == 0 {
+		if key := apiKeyFromCtx(ctx); key != nil {
+			r.By = key.Name
+		}
+	}
+
+	id, _ := ctx.UserValue("id").(string)
+	err := alerts.Acknowledge(id, r.By)
+	if err != nil {
+		if err == alerts.ErrNotFound {
+			server.SendNotFound(ctx, err.Error())
+		} else {
+			server.SendBadRequest(ctx, err.Error())
+		}
+		return
+	}
+
+	server.SendSuccess(ctx)
+	return
+}
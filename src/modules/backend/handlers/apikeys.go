@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"github.com/randlabs/server-watchdog/modules/apikeys"
+	"github.com/randlabs/server-watchdog/server"
+)
+
+//------------------------------------------------------------------------------
+
+func onGetApiKeys(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	server.SendJSON(ctx, apikeys.GetKeys())
+	return
+}
+
+func onPostApiKey(ctx *server.RequestCtx) {
+	var r CreateApiKeyRequest
+
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	err := json.Unmarshal(ctx.PostBody(), &r)
+	if err != nil {
+		server.SendBadRequest(ctx, "")
+		return
+	}
+
+	key, err := apikeys.Create(r.Name, r.Scopes, r.Channels)
+	if err != nil {
+		server.SendBadRequest(ctx, err.Error())
+		return
+	}
+
+	server.SendJSON(ctx, CreateApiKeyResponse{
+		Name: r.Name,
+		Key:  key,
+	})
+	return
+}
+
+func onPostRotateApiKey(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	name, _ := ctx.UserValue("name").(string)
+	key, err := apikeys.Rotate(name)
+	if err != nil {
+		sendApiKeyError(ctx, err)
+		return
+	}
+
+	server.SendJSON(ctx, CreateApiKeyResponse{
+		Name: name,
+		Key:  key,
+	})
+	return
+}
+
+func onDeleteApiKey(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	name, _ := ctx.UserValue("name").(string)
+	err := apikeys.Revoke(name)
+	if err != nil {
+		sendApiKeyError(ctx, err)
+		return
+	}
+
+	server.SendSuccess(ctx)
+	return
+}
+
+func onPostReloadApiKeys(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	err := apikeys.Reload()
+	if err != nil {
+		server.SendBadRequest(ctx, err.Error())
+		return
+	}
+
+	server.SendSuccess(ctx)
+	return
+}
+
+func sendApiKeyError(ctx *server.RequestCtx, err error) {
+	if err == apikeys.ErrNotFound {
+		server.SendNotFound(ctx, err.Error())
+	} else {
+		server.SendBadRequest(ctx, err.Error())
+	}
+	return
+}
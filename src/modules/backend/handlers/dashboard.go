@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/randlabs/server-watchdog/modules/freediskspacechecker"
+	"github.com/randlabs/server-watchdog/modules/heartbeats"
+	"github.com/randlabs/server-watchdog/modules/jobs"
+	"github.com/randlabs/server-watchdog/modules/logger"
+	"github.com/randlabs/server-watchdog/modules/logger/stats"
+	"github.com/randlabs/server-watchdog/modules/processwatcher"
+	"github.com/randlabs/server-watchdog/modules/tcpports"
+	"github.com/randlabs/server-watchdog/modules/webchecker"
+	"github.com/randlabs/server-watchdog/server"
+	"github.com/randlabs/server-watchdog/settings"
+)
+
+//------------------------------------------------------------------------------
+
+func initializeDashboard(router *server.Router) {
+	if !settings.Config.Server.Dashboard.Enabled {
+		return
+	}
+
+	router.GET("/", onGetDashboardRedirect)
+	router.GET("/dashboard", onGetDashboard)
+	router.GET("/dashboard/data", onGetDashboardData)
+	return
+}
+
+//------------------------------------------------------------------------------
+
+func onGetDashboardRedirect(ctx *server.RequestCtx) {
+	ctx.Redirect("/dashboard", 302)
+	return
+}
+
+func onGetDashboard(ctx *server.RequestCtx) {
+	ctx.SetContentType("text/html; charset=utf-8")
+	ctx.Response.Header.Set("Cache-Control", "no-cache")
+	_, _ = ctx.WriteString(dashboardPage)
+	server.SendSuccess(ctx)
+	return
+}
+
+func onGetDashboardData(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "read") {
+		return
+	}
+
+	deliveries := stats.GetDeliveryStats()
+
+	resp := DashboardResponse{
+		Name:          settings.Config.Name,
+		Timestamp:     time.Now().UTC(),
+		Webs:          webchecker.GetStatus(),
+		TcpPorts:      tcpports.GetStatus(),
+		Disks:         freediskspacechecker.GetStatus(),
+		Processes:     processwatcher.GetStatus(),
+		Heartbeats:    heartbeats.GetStatus(),
+		Jobs:          jobs.GetStatus(),
+		Notifications: logger.GetRecentNotifications(),
+		Deliveries:    make([]DashboardDelivery, len(deliveries)),
+	}
+	for idx, ds := range deliveries {
+		resp.Deliveries[idx] = DashboardDelivery{
+			Output:      ds.Output,
+			Channel:     ds.Channel,
+			Sent:        ds.Sent,
+			Failed:      ds.Failed,
+			LastSuccess: optionalTime(ds.LastSuccess),
+			LastFailure: optionalTime(ds.LastFailure),
+			LastError:   ds.LastError,
+		}
+	}
+
+	ctx.Response.Header.Set("Cache-Control", "no-cache")
+	server.SendJSON(ctx, resp)
+	return
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
@@ -0,0 +1,242 @@
+package handlers
+
+//------------------------------------------------------------------------------
+
+// Single page status dashboard. It polls /dashboard/data periodically using the API key entered by the user.
+const dashboardPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Server Watchdog</title>
+<style>
+	body { font-family: -apple-system, "Segoe UI", Roboto, Helvetica, Arial, sans-serif; margin: 0; background: #f4f5f7;
+	       color: #222; }
+	header { background: #263238; color: #fff; padding: 12px 24px; display: flex; justify-content: space-between;
+	         align-items: center; }
+	header h1 { font-size: 20px; margin: 0; }
+	header span { font-size: 13px; opacity: 0.8; }
+	main { padding: 16px 24px; }
+	section { background: #fff; border-radius: 4px; box-shadow: 0 1px 2px rgba(0,0,0,0.15); margin-bottom: 16px; }
+	section h2 { font-size: 16px; margin: 0; padding: 10px 14px; border-bottom: 1px solid #e0e0e0; }
+	table { width: 100%; border-collapse: collapse; font-size: 13px; }
+	th, td { text-align: left; padding: 6px 14px; border-bottom: 1px solid #f0f0f0; vertical-align: top; }
+	th { color: #666; font-weight: 600; }
+	.empty { padding: 10px 14px; color: #888; font-size: 13px; }
+	.badge { display: inline-block; padding: 2px 8px; border-radius: 10px; font-size: 12px; font-weight: 600;
+	         color: #fff; }
+	.ok { background: #2e7d32; }
+	.warn { background: #ef6c00; }
+	.bad { background: #c62828; }
+	.info { background: #1565c0; }
+	.muted { background: #757575; }
+	.error-text { color: #c62828; }
+	#summary { font-size: 14px; margin-bottom: 12px; }
+</style>
+</head>
+<body>
+<header>
+	<h1 id="title">Server Watchdog</h1>
+	<span id="updated">Loading...</span>
+</header>
+<main>
+	<div id="summary"></div>
+	<section><h2>Webs</h2><div id="webs"></div></section>
+	<section><h2>TCP ports</h2><div id="tcpPorts"></div></section>
+	<section><h2>Disks</h2><div id="disks"></div></section>
+	<section><h2>Processes</h2><div id="processes"></div></section>
+	<section><h2>Heartbeats</h2><div id="heartbeats"></div></section>
+	<section><h2>Jobs</h2><div id="jobs"></div></section>
+	<section><h2>Channel delivery</h2><div id="deliveries"></div></section>
+	<section><h2>Recent notifications</h2><div id="notifications"></div></section>
+</main>
+<script>
+(function () {
+	var refreshInterval = 5000;
+
+	function esc(s) {
+		return String(s === undefined || s === null ? "" : s).replace(/[&<>"']/g, function (c) {
+			return { "&": "&amp;", "<": "&lt;", ">": "&gt;", "\"": "&quot;", "'": "&#39;" }[c];
+		});
+	}
+
+	function badge(text, cls) {
+		return "<span class=\"badge " + cls + "\">" + esc(text) + "</span>";
+	}
+
+	function flapping(item) {
+		return item.flapping ? " " + badge("flapping", "warn") : "";
+	}
+
+	function when(t) {
+		return t ? esc(new Date(t).toLocaleString()) : "-";
+	}
+
+	function bytes(n) {
+		var units = [ "B", "KB", "MB", "GB", "TB" ];
+		var i = 0;
+		while (n >= 1024 && i < units.length - 1) {
+			n /= 1024;
+			i++;
+		}
+		return n.toFixed(i > 0 ? 1 : 0) + " " + units[i];
+	}
+
+	function table(id, headers, rows) {
+		var el = document.getElementById(id);
+		if (!rows || rows.length === 0) {
+			el.innerHTML = "<div class=\"empty\">Nothing to show</div>";
+			return;
+		}
+		var html = "<table><thead><tr>";
+		headers.forEach(function (h) {
+			html += "<th>" + esc(h) + "</th>";
+		});
+		html += "</tr></thead><tbody>";
+		rows.forEach(function (r) {
+			html += "<tr><td>" + r.join("</td><td>") + "</td></tr>";
+		});
+		el.innerHTML = html + "</tbody></table>";
+	}
+
+	function render(data) {
+		var down = 0;
+
+		document.title = data.name + " - Server Watchdog";
+		document.getElementById("title").textContent = data.name;
+		document.getElementById("updated").textContent = "Updated " + new Date(data.timestamp).toLocaleString();
+
+		table("webs", [ "Status", "URL", "Channel", "Response", "Failures", "Last check", "Last notification" ],
+			data.webs.map(function (w) {
+				var cls = w.status === "up" ? "ok" : (w.status === "stalled" ? "warn" : "bad");
+				if (w.paused) {
+					return [ badge("paused", "muted"), esc(w.config.url), esc(w.config.channel), "-",
+					         esc(w.consecutiveFailures), when(w.lastCheck), when(w.lastNotification) ];
+				}
+				if (w.status !== "up") {
+					down++;
+				}
+				return [ badge(w.status, cls) + flapping(w), esc(w.config.url), esc(w.config.channel), esc(w.responseTimeMs) + " ms",
+				         esc(w.consecutiveFailures), when(w.lastCheck), when(w.lastNotification) ];
+			}));
+
+		table("tcpPorts", [ "Status", "Name", "Address", "Ports", "Failures", "Last check" ],
+			data.tcpPorts.map(function (g) {
+				var ports = g.ports.map(function (p) {
+					return badge(p.port, p.up ? "ok" : "bad");
+				}).join(" ");
+				var allUp = g.ports.every(function (p) {
+					return p.up;
+				});
+				if (g.paused) {
+					return [ badge("paused", "muted"), esc(g.config.name), esc(g.config.address), ports,
+					         esc(g.consecutiveFailures), when(g.lastCheck) ];
+				}
+				if (!allUp) {
+					down++;
+				}
+				return [ badge(allUp ? "up" : "down", allUp ? "ok" : "bad") + flapping(g), esc(g.config.name),
+				         esc(g.config.address), ports, esc(g.consecutiveFailures), when(g.lastCheck) ];
+			}));
+
+		table("disks", [ "Status", "Device", "Free", "Minimum", "Last check" ],
+			data.disks.map(function (d) {
+				if (d.paused) {
+					return [ badge("paused", "muted"), esc(d.config.device), bytes(d.freeSpace),
+					         bytes(d.config.minimumSpace), when(d.lastCheck) ];
+				}
+				if (!d.ok) {
+					down++;
+				}
+				return [ badge(d.ok ? "ok" : "low", d.ok ? "ok" : "bad") + flapping(d), esc(d.config.device), bytes(d.freeSpace),
+				         bytes(d.config.minimumSpace), when(d.lastCheck) ];
+			}));
+
+		table("processes", [ "PID", "Name", "Channel", "Memory", "Watched since" ],
+			data.processes.map(function (p) {
+				return [ esc(p.pid), esc(p.name), esc(p.channel), bytes(p.memoryUsage), when(p.watchedSince) ];
+			}));
+
+		table("heartbeats", [ "Status", "Name", "Channel", "Interval", "Last ping", "Deadline" ],
+			data.heartbeats.map(function (h) {
+				if (h.overdue) {
+					down++;
+				}
+				return [ badge(h.overdue ? "overdue" : "ok", h.overdue ? "bad" : "ok"), esc(h.config.name),
+				         esc(h.config.channel), esc(h.config.interval), when(h.lastPing), when(h.deadline) ];
+			}));
+
+		table("jobs", [ "Status", "Name", "Channel", "Schedule", "Last run", "Duration", "Next start" ],
+			data.jobs.map(function (j) {
+				var status = j.running ? "running" : (j.lastRun ? (j.lastRun.succeeded ? "ok" : "failed") : "pending");
+				var last = j.currentRun || j.lastRun;
+				if (j.failing) {
+					down++;
+				}
+				return [ badge(j.failing ? "failing" : status, j.failing ? "bad" : "ok"), esc(j.config.name),
+				         esc(j.config.channel), esc(j.config.schedule), when(last ? last.startedAt : null),
+				         esc(last ? last.duration : ""), when(j.expectedStart) ];
+			}));
+
+		table("deliveries", [ "Health", "Channel", "Output", "Sent", "Failed", "Last success", "Last failure" ],
+			data.deliveries.map(function (d) {
+				var healthy = !d.lastFailure || (d.lastSuccess && new Date(d.lastSuccess) > new Date(d.lastFailure));
+				var failure = when(d.lastFailure);
+				if (d.lastError) {
+					failure += "<br><span class=\"error-text\">" + esc(d.lastError) + "</span>";
+				}
+				return [ badge(healthy ? "ok" : "failing", healthy ? "ok" : "bad"), esc(d.channel), esc(d.output),
+				         esc(d.sent), esc(d.failed), when(d.lastSuccess), failure ];
+			}));
+
+		table("notifications", [ "Time", "Severity", "Channel", "Message" ],
+			data.notifications.map(function (n) {
+				var cls = { error: "bad", warn: "warn", info: "info" }[n.severity] || "muted";
+				return [ esc(n.timestamp), badge(n.severity, cls), esc(n.channel), esc(n.message) ];
+			}));
+
+		document.getElementById("summary").innerHTML = down === 0 ?
+			badge("All systems operational", "ok") : badge(down + " item(s) need attention", "bad");
+	}
+
+	//the data requires an API key with the read scope, keep it for the browser session
+	function apiKey(reset) {
+		var key = reset ? null : sessionStorage.getItem("apiKey");
+		if (!key) {
+			key = window.prompt("API key with read access") || "";
+			sessionStorage.setItem("apiKey", key);
+		}
+		return key;
+	}
+
+	function refresh(resetKey) {
+		var xhr = new XMLHttpRequest();
+		xhr.open("GET", "/dashboard/data");
+		xhr.setRequestHeader("X-Api-Key", apiKey(resetKey));
+		xhr.onload = function () {
+			if (xhr.status === 200) {
+				render(JSON.parse(xhr.responseText));
+			} else if (xhr.status === 403) {
+				document.getElementById("updated").textContent = "Access denied";
+				setTimeout(function () {
+					refresh(true);
+				}, refreshInterval);
+				return;
+			} else {
+				document.getElementById("updated").textContent = "Unable to load data (" + xhr.status + ")";
+			}
+			setTimeout(refresh, refreshInterval);
+		};
+		xhr.onerror = function () {
+			document.getElementById("updated").textContent = "Server unreachable";
+			setTimeout(refresh, refreshInterval);
+		};
+		xhr.send();
+	}
+
+	refresh();
+})();
+</script>
+</body>
+</html>
+`
@@ -2,21 +2,60 @@ package handlers
 
 import (
 	"encoding/json"
-	"github.com/randlabs/server-watchdog/modules/processwatcher"
+	"fmt"
+	"github.com/randlabs/server-watchdog/modules/apikeys"
+	"github.com/randlabs/server-watchdog/modules/metrics"
 	"strings"
 
-	"github.com/randlabs/server-watchdog/modules/logger"
 	"github.com/randlabs/server-watchdog/server"
 	"github.com/randlabs/server-watchdog/settings"
 )
 
 //------------------------------------------------------------------------------
 
+const (
+	maxNotifyBatchSize = 100
+)
+
+//------------------------------------------------------------------------------
+
 func Initialize(router *server.Router) {
 	router.GET("/ping", onGetPing)
+	router.GET("/schema", onGetSchema)
+	router.GET("/metrics", onGetMetrics)
+	router.GET("/status", onGetStatus)
+	router.GET("/webs", onGetWebs)
+	router.GET("/tcpports", onGetTcpPorts)
+	router.GET("/disks", onGetDisks)
+	router.GET("/processes", onGetProcesses)
+	router.GET("/heartbeats", onGetHeartbeats)
 	router.POST("/notify", onPostNotify)
+	router.POST("/notify/batch", onPostNotifyBatch)
 	router.POST("/process/watch", onPostWatchProcess)
 	router.POST("/process/unwatch", onPostUnwatchProcess)
+	router.POST("/heartbeat/:name", onPostHeartbeat)
+	router.GET("/jobs", onGetJobs)
+	router.POST("/jobs/:name/start", onPostJobStart)
+	router.POST("/jobs/:name/finish", onPostJobFinish)
+	router.POST("/jobs/:name/fail", onPostJobFail)
+	router.DELETE("/jobs/:name", onDeleteJob)
+	router.GET("/silences", onGetSilences)
+	router.POST("/silences", onPostSilence)
+	router.DELETE("/silences/:id", onDeleteSilence)
+	router.GET("/maintenance", onGetMaintenance)
+	router.GET("/notifications", onGetNotifications)
+	router.GET("/alerts", onGetAlerts)
+	router.POST("/alerts/:id/ack", onPostAckAlert)
+	router.GET("/apikeys", onGetApiKeys)
+	router.POST("/apikeys", onPostApiKey)
+	router.POST("/apikeys/:name/rotate", onPostRotateApiKey)
+	router.DELETE("/apikeys/:name", onDeleteApiKey)
+	router.POST("/reload", onPostReloadApiKeys)
+
+	initializeRateLimits()
+	initializeNotify()
+	initializeMonitors(router)
+	initializeDashboard(router)
 	return
 }
 
@@ -28,36 +67,45 @@ func onGetPing(ctx *server.RequestCtx) {
 	return
 }
 
-func onPostNotify(ctx *server.RequestCtx) {
-	var r NotifyRequest
-	var err error
+func onGetSchema(ctx *server.RequestCtx) {
+	server.SendJSON(ctx, settings.GenerateSchema())
+	return
+}
 
-	if !checkApiKey(ctx) {
+func onGetMetrics(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "read") {
 		return
 	}
 
-	err = json.Unmarshal(ctx.PostBody(), &r)
-	if err != nil {
-		server.SendBadRequest(ctx, "")
+	ctx.SetContentType(metrics.ContentType)
+	metrics.Write(ctx)
+	server.SendSuccess(ctx)
+	return
+}
+
+func onPostNotify(ctx *server.RequestCtx) {
+	var r NotifyRequest
+	var err error
+
+	if !checkApiKey(ctx, "notify") || !checkRateLimit(ctx) {
 		return
 	}
 
-	//validate channel
-	if !settings.ValidateChannel(r.Channel) {
-		server.SendBadRequest(ctx, "Channel not found or not specified")
+	id, ok := beginIdempotentRequest(ctx)
+	if !ok {
 		return
 	}
+	defer endIdempotentRequest(ctx, id)
 
-	//validate message
-	if len(r.Message) == 0 {
-		server.SendBadRequest(ctx, "No message")
+	err = json.Unmarshal(ctx.PostBody(), &r)
+	if err != nil {
+		server.SendBadRequest(ctx, "")
 		return
 	}
 
-	//do log
-	err = logger.Log(r.Severity, r.Channel, "%v", r.Message)
+	_, err = SendNotification(apiKeyFromCtx(ctx), &r)
 	if err != nil {
-		server.SendBadRequest(ctx, err.Error())
+		sendRequestError(ctx, err)
 		return
 	}
 
@@ -66,36 +114,87 @@ func onPostNotify(ctx *server.RequestCtx) {
 	return
 }
 
-func onPostWatchProcess(ctx *server.RequestCtx) {
-	var r WatchProcessRequest
+func onPostNotifyBatch(ctx *server.RequestCtx) {
+	var r []NotifyRequest
 	var err error
 
-	if !checkApiKey(ctx) {
+	if !checkApiKey(ctx, "notify") || !checkRateLimit(ctx) {
 		return
 	}
 
+	id, ok := beginIdempotentRequest(ctx)
+	if !ok {
+		return
+	}
+	defer endIdempotentRequest(ctx, id)
+
 	err = json.Unmarshal(ctx.PostBody(), &r)
 	if err != nil {
 		server.SendBadRequest(ctx, "")
 		return
 	}
+	if len(r) == 0 {
+		server.SendBadRequest(ctx, "No notifications")
+		return
+	}
+	if len(r) > maxNotifyBatchSize {
+		server.SendBadRequest(ctx, fmt.Sprintf("Too many notifications. The maximum is %v.", maxNotifyBatchSize))
+		return
+	}
+
+	//each notification takes a token. the one taken by checkRateLimit covers the first one and the ones exceeding the
+	//limit are not sent
+	allowed, retryAfter := CheckRateLimitN(remoteIP(ctx), apiKeyFromCtx(ctx), len(r) - 1)
+	allowed += 1
+
+	//each notification is processed on its own so a bad one does not discard the rest
+	res := NotifyBatchResponse{
+		Results: make([]NotifyBatchResult, len(r)),
+	}
+	for idx := range r {
+		if idx >= allowed {
+			res.Results[idx].RateLimited = true
+			res.Results[idx].Error = "Too many requests"
+			continue
+		}
 
-	//validate channel
-	if !settings.ValidateChannel(r.Channel) {
-		server.SendBadRequest(ctx, "Channel not found or not specified")
+		sent, err := SendNotification(apiKeyFromCtx(ctx), &r[idx])
+		if err != nil {
+			res.Results[idx].Error = err.Error()
+		} else {
+			res.Results[idx].Success = true
+			if sent {
+				res.Sent += 1
+			} else {
+				res.Results[idx].Duplicate = true
+			}
+		}
+	}
+
+	server.SendJSON(ctx, res)
+	if allowed < len(r) {
+		server.SetRetryAfter(ctx, retryAfter)
+	}
+	return
+}
+
+func onPostWatchProcess(ctx *server.RequestCtx) {
+	var r WatchProcessRequest
+	var err error
+
+	if !checkApiKey(ctx, "process") || !checkRateLimit(ctx) {
 		return
 	}
 
-	//validate process id
-	if r.Pid < 1 || r.Pid > 0x7FFFFFFF {
-		server.SendBadRequest(ctx, "Invalid process id")
+	err = json.Unmarshal(ctx.PostBody(), &r)
+	if err != nil {
+		server.SendBadRequest(ctx, "")
 		return
 	}
 
-	//add to watch list
-	err = processwatcher.AddProcess(r.Pid, r.Name, r.MaxMemUsage, r.Severity, r.Channel)
+	err = WatchProcess(apiKeyFromCtx(ctx), &r)
 	if err != nil {
-		server.SendBadRequest(ctx, err.Error())
+		sendRequestError(ctx, err)
 		return
 	}
 
@@ -106,10 +205,9 @@ func onPostWatchProcess(ctx *server.RequestCtx) {
 
 func onPostUnwatchProcess(ctx *server.RequestCtx) {
 	var r UnwatchProcessRequest
-	var ok bool
 	var err error
 
-	if !checkApiKey(ctx) {
+	if !checkApiKey(ctx, "process") {
 		return
 	}
 
@@ -119,43 +217,84 @@ func onPostUnwatchProcess(ctx *server.RequestCtx) {
 		return
 	}
 
-	//validate channel
-	if len(r.Channel) == 0 {
-		server.SendBadRequest(ctx, "No channel")
-		return
-	}
-	r.Channel = strings.ToLower(r.Channel)
-	_, ok = settings.Config.Channels[r.Channel]
-	if !ok {
-		server.SendBadRequest(ctx, "Channel not found")
-		return
-	}
-
-	//validate process id
-	if r.Pid < 1 || r.Pid > 0x7FFFFFFF {
-		server.SendBadRequest(ctx, "Invalid process id")
+	err = UnwatchProcess(apiKeyFromCtx(ctx), &r)
+	if err != nil {
+		sendRequestError(ctx, err)
 		return
 	}
 
-	//remove from watch list
-	processwatcher.RemoveProcess(r.Pid, r.Channel)
-
 	//done
 	server.SendSuccess(ctx)
 	return
 }
 
-func checkApiKey(ctx *server.RequestCtx) bool {
+func checkApiKey(ctx *server.RequestCtx, scope string) bool {
 	var apiKey []byte
 
+	//signed requests don't send the key itself
+	if signature := ctx.Request.Header.Peek("X-Signature"); signature != nil {
+		key, err := apikeys.AuthenticateSignature(string(ctx.Request.Header.Peek("X-Key-Id")),
+		                                          string(ctx.Request.Header.Peek("X-Timestamp")),
+		                                          string(ctx.Request.Header.Peek("X-Nonce")), string(signature),
+		                                          string(ctx.Method()), string(ctx.URI().PathOriginal()),
+		                                          ctx.PostBody())
+		if err != nil {
+			server.SendAccessDenied(ctx, err.Error())
+			return false
+		}
+		if !key.HasScope(scope) {
+			server.SendAccessDenied(ctx, "")
+			return false
+		}
+		ctx.SetUserValue("apiKey", key)
+		return true
+	}
+
 	apiKey = ctx.Request.Header.Peek("X-Api-Key")
 	if apiKey == nil {
+		//also accept bearer tokens because some clients, like Prometheus, cannot send custom headers
+		auth := ctx.Request.Header.Peek("Authorization")
+		if len(auth) > 7 && strings.EqualFold(string(auth[:7]), "Bearer ") {
+			apiKey = auth[7:]
+		}
+	}
+
+	var key *apikeys.Key
+	if apiKey != nil {
+		key = apikeys.Authenticate(string(apiKey))
+	} else if cn, ok := server.GetClientCertificateCN(ctx); ok {
+		//clients with an allowed certificate do not need an API key
+		key = apikeys.AuthenticateCertificate(cn)
+	}
+	if key == nil || !key.HasScope(scope) {
 		server.SendAccessDenied(ctx, "")
 		return false
 	}
-	if string(apiKey) != settings.Config.Server.ApiKey {
-		server.SendAccessDenied(ctx, "")
+	ctx.SetUserValue("apiKey", key)
+	return true
+}
+
+// checkChannelAccess verifies the API key used in the request is allowed to send notifications to the channel
+func checkChannelAccess(ctx *server.RequestCtx, channel string) bool {
+	if !canUseChannel(apiKeyFromCtx(ctx), channel) {
+		server.SendAccessDenied(ctx, ErrChannelNotAllowed.Error())
 		return false
 	}
 	return true
 }
+
+// apiKeyFromCtx returns the API key authenticated by checkApiKey
+func apiKeyFromCtx(ctx *server.RequestCtx) *apikeys.Key {
+	key, _ := ctx.UserValue("apiKey").(*apikeys.Key)
+	return key
+}
+
+// sendRequestError sends the error returned by the shared request processing functions
+func sendRequestError(ctx *server.RequestCtx, err error) {
+	if err == ErrChannelNotAllowed {
+		server.SendAccessDenied(ctx, err.Error())
+	} else {
+		server.SendBadRequest(ctx, err.Error())
+	}
+	return
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"github.com/randlabs/server-watchdog/modules/heartbeats"
+	"github.com/randlabs/server-watchdog/server"
+)
+
+//------------------------------------------------------------------------------
+
+func onPostHeartbeat(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "ping") || !checkRateLimit(ctx) {
+		return
+	}
+
+	name, _ := ctx.UserValue("name").(string)
+	err := heartbeats.Ping(name)
+	if err != nil {
+		if err == heartbeats.ErrNotFound {
+			server.SendNotFound(ctx, err.Error())
+		} else {
+			server.SendBadRequest(ctx, err.Error())
+		}
+		return
+	}
+
+	server.SendSuccess(ctx)
+	return
+}
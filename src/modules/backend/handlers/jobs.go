@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"github.com/randlabs/server-watchdog/modules/jobs"
+	"github.com/randlabs/server-watchdog/server"
+	"github.com/randlabs/server-watchdog/settings"
+)
+
+//------------------------------------------------------------------------------
+
+func onPostJobStart(ctx *server.RequestCtx) {
+	var r StartJobRequest
+	var adhoc *settings.SettingsJSON_Jobs
+
+	if !checkApiKey(ctx, "ping") || !checkRateLimit(ctx) {
+		return
+	}
+
+	//the body is optional
+	if len(ctx.PostBody()) > 0 {
+		err := json.Unmarshal(ctx.PostBody(), &r)
+		if err != nil {
+			server.SendBadRequest(ctx, "")
+			return
+		}
+	}
+
+	name, _ := ctx.UserValue("name").(string)
+
+	//jobs not defined in the settings file must specify where to notify
+	if channel, ok := jobs.GetChannel(name); ok {
+		if !checkChannelAccess(ctx, channel) {
+			return
+		}
+	} else if len(r.Channel) > 0 {
+		if !settings.ValidateChannel(r.Channel) {
+			server.SendBadRequest(ctx, "Channel not found")
+			return
+		}
+		if !checkChannelAccess(ctx, r.Channel) {
+			return
+		}
+
+		adhoc = &settings.SettingsJSON_Jobs{
+			Name:        name,
+			MaxDuration: r.MaxDuration,
+			Channel:     r.Channel,
+			Severity:    r.Severity,
+		}
+		err := settings.ValidateJob(adhoc)
+		if err != nil {
+			server.SendBadRequest(ctx, err.Error())
+			return
+		}
+	}
+
+	err := jobs.StartRun(name, adhoc)
+	if err != nil {
+		sendJobError(ctx, err)
+		return
+	}
+
+	server.SendSuccess(ctx)
+	return
+}
+
+func onPostJobFinish(ctx *server.RequestCtx) {
+	onPostJobEnd(ctx, true)
+	return
+}
+
+func onPostJobFail(ctx *server.RequestCtx) {
+	onPostJobEnd(ctx, false)
+	return
+}
+
+func onPostJobEnd(ctx *server.RequestCtx, succeeded bool) {
+	var r EndJobRequest
+	var err error
+
+	if !checkApiKey(ctx, "ping") || !checkRateLimit(ctx) {
+		return
+	}
+
+	//the body is optional
+	if len(ctx.PostBody()) > 0 {
+		err = json.Unmarshal(ctx.PostBody(), &r)
+		if err != nil {
+			server.SendBadRequest(ctx, "")
+			return
+		}
+	}
+
+	name, _ := ctx.UserValue("name").(string)
+
+	//finishing a run can raise or resolve an alert in the channel of the job
+	channel, ok := jobs.GetChannel(name)
+	if !ok {
+		server.SendNotFound(ctx, jobs.ErrNotFound.Error())
+		return
+	}
+	if !checkChannelAccess(ctx, channel) {
+		return
+	}
+
+	if succeeded {
+		err = jobs.FinishRun(name, r.ExitCode, r.Output)
+	} else {
+		err = jobs.FailRun(name, r.ExitCode, r.Output)
+	}
+	if err != nil {
+		sendJobError(ctx, err)
+		return
+	}
+
+	server.SendSuccess(ctx)
+	return
+}
+
+func onDeleteJob(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	name, _ := ctx.UserValue("name").(string)
+	err := jobs.RemoveJob(name)
+	if err != nil {
+		sendJobError(ctx, err)
+		return
+	}
+
+	server.SendSuccess(ctx)
+	return
+}
+
+//------------------------------------------------------------------------------
+
+func sendJobError(ctx *server.RequestCtx, err error) {
+	if err == jobs.ErrNotFound {
+		server.SendNotFound(ctx, err.Error())
+	} else {
+		server.SendBadRequest(ctx, err.Error())
+	}
+	return
+}
@@ -1,11 +1,41 @@
 package handlers
 
+import (
+	"time"
+
+	"github.com/randlabs/server-watchdog/modules/freediskspacechecker"
+	"github.com/randlabs/server-watchdog/modules/heartbeats"
+	"github.com/randlabs/server-watchdog/modules/jobs"
+	"github.com/randlabs/server-watchdog/modules/logger"
+	"github.com/randlabs/server-watchdog/modules/logger/history"
+	"github.com/randlabs/server-watchdog/modules/processwatcher"
+	"github.com/randlabs/server-watchdog/modules/tcpports"
+	"github.com/randlabs/server-watchdog/modules/webchecker"
+	"github.com/randlabs/server-watchdog/settings"
+)
+
 //------------------------------------------------------------------------------
 
 type NotifyRequest struct {
-	Channel  string `json:"channel"`
-	Message  string `json:"message"`
-	Severity string `json:"severity,omitempty"`
+	Channel   string     `json:"channel"`
+	Message   string     `json:"message"`
+	Severity  string     `json:"severity,omitempty"`
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+	Source    string     `json:"source,omitempty"`
+	Host      string     `json:"host,omitempty"`
+	DedupKey  string     `json:"dedupKey,omitempty"`
+}
+
+type NotifyBatchResponse struct {
+	Sent    int                 `json:"sent"`
+	Results []NotifyBatchResult `json:"results"`
+}
+
+type NotifyBatchResult struct {
+	Success     bool   `json:"success"`
+	Duplicate   bool   `json:"duplicate,omitempty"`
+	RateLimited bool   `json:"rateLimited,omitempty"`
+	Error       string `json:"error,omitempty"`
 }
 
 type WatchProcessRequest struct {
@@ -20,3 +50,82 @@ type UnwatchProcessRequest struct {
 	Channel string `json:"channel"`
 	Pid     int `json:"pid"`
 }
+
+type CreateMonitorResponse struct {
+	Id string `json:"id"`
+}
+
+type CreateSilenceRequest struct {
+	Matchers settings.SettingsJSON_Matchers `json:"matchers"`
+	StartsAt *time.Time                     `json:"startsAt,omitempty"`
+	EndsAt   *time.Time                     `json:"endsAt,omitempty"`
+	Duration string                         `json:"duration,omitempty"`
+	Comment  string                         `json:"comment,omitempty"`
+}
+
+type CreateSilenceResponse struct {
+	Id string `json:"id"`
+}
+
+type AckAlertRequest struct {
+	By string `json:"by,omitempty"`
+}
+
+type StartJobRequest struct {
+	Channel     string `json:"channel,omitempty"`
+	Severity    string `json:"severity,omitempty"`
+	MaxDuration string `json:"maxDuration,omitempty"`
+}
+
+type EndJobRequest struct {
+	ExitCode *int   `json:"exitCode,omitempty"`
+	Output   string `json:"output,omitempty"`
+}
+
+type CreateApiKeyRequest struct {
+	Name     string   `json:"name"`
+	Scopes   []string `json:"scopes"`
+	Channels []string `json:"channels,omitempty"`
+}
+
+type CreateApiKeyResponse struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+type NotificationsResponse struct {
+	Notifications []history.Event `json:"notifications"`
+	Next          uint64          `json:"next,omitempty"`
+}
+
+type StatusResponse struct {
+	Webs       []webchecker.WebStatus              `json:"webs"`
+	TcpPorts   []tcpports.TcpPortStatus            `json:"tcpPorts"`
+	Disks      []freediskspacechecker.DeviceStatus `json:"disks"`
+	Processes  []processwatcher.ProcessStatus      `json:"processes"`
+	Heartbeats []heartbeats.HeartbeatStatus        `json:"heartbeats"`
+	Jobs       []jobs.JobStatus                    `json:"jobs"`
+}
+
+type DashboardResponse struct {
+	Name          string                              `json:"name"`
+	Timestamp     time.Time                           `json:"timestamp"`
+	Webs          []webchecker.WebStatus              `json:"webs"`
+	TcpPorts      []tcpports.TcpPortStatus            `json:"tcpPorts"`
+	Disks         []freediskspacechecker.DeviceStatus `json:"disks"`
+	Processes     []processwatcher.ProcessStatus      `json:"processes"`
+	Heartbeats    []heartbeats.HeartbeatStatus        `json:"heartbeats"`
+	Jobs          []jobs.JobStatus                    `json:"jobs"`
+	Notifications []logger.Notification               `json:"notifications"`
+	Deliveries    []DashboardDelivery                 `json:"deliveries"`
+}
+
+type DashboardDelivery struct {
+	Output      string     `json:"output"`
+	Channel     string     `json:"channel"`
+	Sent        uint64     `json:"sent"`
+	Failed      uint64     `json:"failed"`
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	LastFailure *time.Time `json:"lastFailure,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+}
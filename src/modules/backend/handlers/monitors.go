@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"github.com/randlabs/server-watchdog/modules/freediskspacechecker"
+	"github.com/randlabs/server-watchdog/modules/tcpports"
+	"github.com/randlabs/server-watchdog/modules/webchecker"
+	"github.com/randlabs/server-watchdog/server"
+	"github.com/randlabs/server-watchdog/settings"
+)
+
+//------------------------------------------------------------------------------
+
+func initializeMonitors(router *server.Router) {
+	router.POST("/webs", onPostWeb)
+	router.PUT("/webs/:id", onPutWeb)
+	router.DELETE("/webs/:id", onDeleteWeb)
+	router.POST("/webs/:id/pause", onPostPauseWeb)
+	router.POST("/webs/:id/resume", onPostResumeWeb)
+
+	router.POST("/tcpports", onPostTcpPort)
+	router.PUT("/tcpports/:id", onPutTcpPort)
+	router.DELETE("/tcpports/:id", onDeleteTcpPort)
+	router.POST("/tcpports/:id/pause", onPostPauseTcpPort)
+	router.POST("/tcpports/:id/resume", onPostResumeTcpPort)
+
+	router.POST("/disks", onPostDisk)
+	router.PUT("/disks/:id", onPutDisk)
+	router.DELETE("/disks/:id", onDeleteDisk)
+	router.POST("/disks/:id/pause", onPostPauseDisk)
+	router.POST("/disks/:id/resume", onPostResumeDisk)
+	return
+}
+
+//------------------------------------------------------------------------------
+
+func onPostWeb(ctx *server.RequestCtx) {
+	var r settings.SettingsJSON_Webs
+
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	err := json.Unmarshal(ctx.PostBody(), &r)
+	if err != nil {
+		server.SendBadRequest(ctx, "")
+		return
+	}
+
+	id, err := webchecker.AddWeb(r)
+	if err != nil {
+		sendMonitorError(ctx, err)
+		return
+	}
+
+	server.SendJSON(ctx, CreateMonitorResponse{
+		Id: id,
+	})
+	return
+}
+
+func onPutWeb(ctx *server.RequestCtx) {
+	var r settings.SettingsJSON_Webs
+
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	err := json.Unmarshal(ctx.PostBody(), &r)
+	if err != nil {
+		server.SendBadRequest(ctx, "")
+		return
+	}
+
+	err = webchecker.UpdateWeb(monitorId(ctx), r)
+	if err != nil {
+		sendMonitorError(ctx, err)
+		return
+	}
+
+	server.SendSuccess(ctx)
+	return
+}
+
+func onDeleteWeb(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	err := webchecker.RemoveWeb(monitorId(ctx))
+	if err != nil {
+		sendMonitorError(ctx, err)
+		return
+	}
+
+	server.SendSuccess(ctx)
+	return
+}
+
+func onPostPauseWeb(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	err := webchecker.PauseWeb(monitorId(ctx), true)
+	if err != nil {
+		sendMonitorError(ctx, err)
+		return
+	}
+
+	server.SendSuccess(ctx)
+	return
+}
+
+func onPostResumeWeb(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	err := webchecker.PauseWeb(monitorId(ctx), false)
+	if err != nil {
+		sendMonitorError(ctx, err)
+		return
+	}
+
+	server.SendSuccess(ctx)
+	return
+}
+
+//------------------------------------------------------------------------------
+
+func onPostTcpPort(ctx *server.RequestCtx) {
+	var r settings.SettingsJSON_TcpPorts
+
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	err := json.Unmarshal(ctx.PostBody(), &r)
+	if err != nil {
+		server.SendBadRequest(ctx, "")
+		return
+	}
+
+	id, err := tcpports.AddTcpPort(r)
+	if err != nil {
+		sendMonitorError(ctx, err)
+		return
+	}
+
+	server.SendJSON(ctx, CreateMonitorResponse{
+		Id: id,
+	})
+	return
+}
+
+func onPutTcpPort(ctx *server.RequestCtx) {
+	var r settings.SettingsJSON_TcpPorts
+
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	err := json.Unmarshal(ctx.PostBody(), &r)
+	if err != nil {
+		server.SendBadRequest(ctx, "")
+		return
+	}
+
+	err = tcpports.UpdateTcpPort(monitorId(ctx), r)
+	if err != nil {
+		sendMonitorError(ctx, err)
+		return
+	}
+
+	server.SendSuccess(ctx)
+	return
+}
+
+func onDeleteTcpPort(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	err := tcpports.RemoveTcpPort(monitorId(ctx))
+	if err != nil {
+		sendMonitorError(ctx, err)
+		return
+	}
+
+	server.SendSuccess(ctx)
+	return
+}
+
+func onPostPauseTcpPort(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	err := tcpports.PauseTcpPort(monitorId(ctx), true)
+	if err != nil {
+		sendMonitorError(ctx, err)
+		return
+	}
+
+	server.SendSuccess(ctx)
+	return
+}
+
+func onPostResumeTcpPort(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	err := tcpports.PauseTcpPort(monitorId(ctx), false)
+	if err != nil {
+		sendMonitorError(ctx, err)
+		return
+	}
+
+	server.SendSuccess(ctx)
+	return
+}
+
+//------------------------------------------------------------------------------
+
+func onPostDisk(ctx *server.RequestCtx) {
+	var r settings.SettingsJSON_FreeDiskSpace
+
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	err := json.Unmarshal(ctx.PostBody(), &r)
+	if err != nil {
+		server.SendBadRequest(ctx, "")
+		return
+	}
+
+	id, err := freediskspacechecker.AddDevice(r)
+	if err != nil {
+		sendMonitorError(ctx, err)
+		return
+	}
+
+	server.SendJSON(ctx, CreateMonitorResponse{
+		Id: id,
+	})
+	return
+}
+
+func onPutDisk(ctx *server.RequestCtx) {
+	var r settings.SettingsJSON_FreeDiskSpace
+
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	err := json.Unmarshal(ctx.PostBody(), &r)
+	if err != nil {
+		server.SendBadRequest(ctx, "")
+		return
+	}
+
+	err = freediskspacechecker.UpdateDevice(monitorId(ctx), r)
+	if err != nil {
+		sendMonitorError(ctx, err)
+		return
+	}
+
+	server.SendSuccess(ctx)
+	return
+}
+
+func onDeleteDisk(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	err := freediskspacechecker.RemoveDevice(monitorId(ctx))
+	if err != nil {
+		sendMonitorError(ctx, err)
+		return
+	}
+
+	server.SendSuccess(ctx)
+	return
+}
+
+func onPostPauseDisk(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	err := freediskspacechecker.PauseDevice(monitorId(ctx), true)
+	if err != nil {
+		sendMonitorError(ctx, err)
+		return
+	}
+
+	server.SendSuccess(ctx)
+	return
+}
+
+func onPostResumeDisk(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	err := freediskspacechecker.PauseDevice(monitorId(ctx), false)
+	if err != nil {
+		sendMonitorError(ctx, err)
+		return
+	}
+
+	server.SendSuccess(ctx)
+	return
+}
+
+//------------------------------------------------------------------------------
+
+func monitorId(ctx *server.RequestCtx) string {
+	id, _ := ctx.UserValue("id").(string)
+	return id
+}
+
+func sendMonitorError(ctx *server.RequestCtx, err error) {
+	switch err {
+	case webchecker.ErrNotFound, tcpports.ErrNotFound, freediskspacechecker.ErrNotFound:
+		server.SendNotFound(ctx, err.Error())
+	//the server is stopping so the client can retry later
+	case webchecker.ErrShuttingDown, tcpports.ErrShuttingDown, freediskspacechecker.ErrShuttingDown,
+	     webchecker.ErrNotActive, tcpports.ErrNotActive, freediskspacechecker.ErrNotActive:
+		server.SendServiceUnavailable(ctx, err.Error())
+	default:
+		server.SendBadRequest(ctx, err.Error())
+	}
+	return
+}
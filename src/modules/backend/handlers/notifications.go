@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/randlabs/server-watchdog/modules/logger/history"
+	"github.com/randlabs/server-watchdog/server"
+	"github.com/randlabs/server-watchdog/settings"
+)
+
+//------------------------------------------------------------------------------
+
+func onGetNotifications(ctx *server.RequestCtx) {
+	var q history.Query
+	var ok bool
+	var err error
+
+	if !checkApiKey(ctx, "read") {
+		return
+	}
+
+	args := ctx.QueryArgs()
+
+	q.Channel = string(args.Peek("channel"))
+	q.Text = string(args.Peek("q"))
+
+	if args.Has("severity") {
+		q.Severity = settings.ValidateSeverity(string(args.Peek("severity")))
+		if len(q.Severity) == 0 {
+			server.SendBadRequest(ctx, "Invalid severity")
+			return
+		}
+	}
+
+	if args.Has("since") {
+		q.Since, ok = parseTimeArg(string(args.Peek("since")))
+		if !ok {
+			server.SendBadRequest(ctx, "Invalid since time")
+			return
+		}
+	}
+	if args.Has("until") {
+		q.Until, ok = parseTimeArg(string(args.Peek("until")))
+		if !ok {
+			server.SendBadRequest(ctx, "Invalid until time")
+			return
+		}
+	}
+
+	if args.Has("limit") {
+		q.Limit, err = strconv.Atoi(string(args.Peek("limit")))
+		if err != nil || q.Limit < 1 {
+			server.SendBadRequest(ctx, "Invalid limit")
+			return
+		}
+	}
+	if args.Has("before") {
+		q.Before, err = strconv.ParseUint(string(args.Peek("before")), 10, 64)
+		if err != nil {
+			server.SendBadRequest(ctx, "Invalid before id")
+			return
+		}
+	}
+
+	list, next, err := history.Find(q)
+	if err != nil {
+		server.SendInternalServerError(ctx, err.Error())
+		return
+	}
+
+	server.SendJSON(ctx, NotificationsResponse{
+		Notifications: list,
+		Next:          next,
+	})
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// Accepts RFC 3339 timestamps or a time span, like "12h", relative to now
+func parseTimeArg(s string) (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339, s)
+	if err == nil {
+		return t, true
+	}
+
+	d, ok := settings.ValidateTimeSpan(s)
+	if ok {
+		return time.Now().Add(-d), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+
+	"github.com/randlabs/server-watchdog/modules/apikeys"
+	"github.com/randlabs/server-watchdog/server"
+	"github.com/randlabs/server-watchdog/settings"
+	"github.com/randlabs/server-watchdog/utils/ttlcache"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	maxIdempotencyKeyLength = 255
+)
+
+//------------------------------------------------------------------------------
+
+type idempotentResponse struct {
+	pending     bool
+	bodyHash    [32]byte
+	statusCode  int
+	contentType string
+	body        []byte
+}
+
+//------------------------------------------------------------------------------
+
+var dedupCache *ttlcache.Cache
+var idempotencyCache *ttlcache.Cache
+
+//------------------------------------------------------------------------------
+
+func initializeNotify() {
+	dedupCache = ttlcache.New(settings.Config.Server.Notify.DedupWindowX)
+	idempotencyCache = ttlcache.New(settings.Config.Server.Notify.IdempotencyWindowX)
+	return
+}
+
+// beginIdempotentRequest checks the Idempotency-Key header of the request. If the key was already used, the stored
+// response is sent again and false is returned. Otherwise, the returned id must be passed to endIdempotentRequest once
+// the request is processed.
+func beginIdempotentRequest(ctx *server.RequestCtx) (string, bool) {
+	idempotencyKey := ctx.Request.Header.Peek("Idempotency-Key")
+	if len(idempotencyKey) == 0 {
+		return "", true
+	}
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		server.SendBadRequest(ctx, "Invalid idempotency key")
+		return "", false
+	}
+
+	//keys are scoped to the API key and the endpoint
+	id := string(ctx.Path()) + "\n" + string(idempotencyKey)
+	if key, ok := ctx.UserValue("apiKey").(*apikeys.Key); ok {
+		id = key.Name + "\n" + id
+	}
+
+	bodyHash := sha256.Sum256(ctx.PostBody())
+
+	v, added := idempotencyCache.SetIfAbsent(id, &idempotentResponse{
+		pending:  true,
+		bodyHash: bodyHash,
+	})
+	if added {
+		return id, true
+	}
+
+	resp := v.(*idempotentResponse)
+	if !bytes.Equal(resp.bodyHash[:], bodyHash[:]) {
+		server.SendBadRequest(ctx, "Idempotency key already used with a different request")
+		return "", false
+	}
+	if resp.pending {
+		server.SendConflict(ctx, "A request with the same idempotency key is in progress")
+		return "", false
+	}
+
+	ctx.Response.SetStatusCode(resp.statusCode)
+	ctx.Response.Header.SetContentType(resp.contentType)
+	ctx.Response.Header.Set("Idempotent-Replayed", "true")
+	ctx.Response.SetBody(resp.body)
+	return "", false
+}
+
+// endIdempotentRequest stores the response of the request so retries get the same one. Server errors are not stored
+// so the client can retry them.
+func endIdempotentRequest(ctx *server.RequestCtx, id string) {
+	if len(id) == 0 {
+		return
+	}
+
+	statusCode := ctx.Response.StatusCode()
+	if statusCode >= 500 {
+		idempotencyCache.Delete(id)
+		return
+	}
+
+	idempotencyCache.Set(id, &idempotentResponse{
+		bodyHash:    sha256.Sum256(ctx.PostBody()),
+		statusCode:  statusCode,
+		contentType: string(ctx.Response.Header.ContentType()),
+		body:        append([]byte(nil), ctx.Response.Body()...),
+	})
+	return
+}
+
+// isDuplicateNotification returns true if a notification with the same deduplication key was sent to the channel
+// within the deduplication window
+func isDuplicateNotification(channel string, dedupKey string) bool {
+	if len(dedupKey) == 0 {
+		return false
+	}
+
+	_, added := dedupCache.SetIfAbsent(channel + "\n" + dedupKey, true)
+	return !added
+}
+
+func forgetNotification(channel string, dedupKey string) {
+	if len(dedupKey) > 0 {
+		dedupCache.Delete(channel + "\n" + dedupKey)
+	}
+	return
+}
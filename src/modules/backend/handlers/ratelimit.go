@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net"
+	"time"
+
+	"github.com/randlabs/server-watchdog/console"
+	"github.com/randlabs/server-watchdog/modules/apikeys"
+	"github.com/randlabs/server-watchdog/modules/logger"
+	"github.com/randlabs/server-watchdog/server"
+	"github.com/randlabs/server-watchdog/settings"
+	"github.com/randlabs/server-watchdog/utils/ratelimit"
+)
+
+//------------------------------------------------------------------------------
+
+var perKeyLimiter *ratelimit.Limiter
+var perIpLimiter *ratelimit.Limiter
+
+//------------------------------------------------------------------------------
+
+func initializeRateLimits() {
+	rl := settings.Config.Server.RateLimit
+	if rl == nil {
+		return
+	}
+
+	if rl.PerKey != nil {
+		perKeyLimiter = ratelimit.New(rl.PerKey.RateX, rl.PerKey.Burst)
+	}
+	if rl.PerIp != nil {
+		perIpLimiter = ratelimit.New(rl.PerIp.RateX, rl.PerIp.Burst)
+	}
+	return
+}
+
+// checkRateLimit takes a token from the buckets of the API key and the source address of the request. Must be called
+// after checkApiKey.
+func checkRateLimit(ctx *server.RequestCtx) bool {
+	retryAfter, ok := CheckRateLimit(remoteIP(ctx), apiKeyFromCtx(ctx))
+	if !ok {
+		server.SendTooManyRequests(ctx, retryAfter)
+		return false
+	}
+	return true
+}
+
+// CheckRateLimit takes a token from the buckets of the API key and the source address. If the client is being
+// throttled, returns false along with the time to wait. An empty address skips the per address limit.
+func CheckRateLimit(ip string, key *apikeys.Key) (time.Duration, bool) {
+	granted, retryAfter := CheckRateLimitN(ip, key, 1)
+	return retryAfter, granted == 1
+}
+
+// CheckRateLimitN takes one token per item from the buckets of the API key and the source address. Returns the number
+// of items allowed and, if lower than n, the time to wait. An empty address skips the per address limit.
+func CheckRateLimitN(ip string, key *apikeys.Key, n int) (int, time.Duration) {
+	var retryAfter time.Duration
+
+	if n <= 0 {
+		return 0, 0
+	}
+
+	ipGranted := 0
+	if perIpLimiter != nil && len(ip) > 0 {
+		res := perIpLimiter.AllowN(ip, n)
+		if !res.Allowed {
+			if res.ThrottlingStarted {
+				notifyThrottled("address " + ip)
+			}
+			retryAfter = res.RetryAfter
+		}
+		n = res.Granted
+		ipGranted = res.Granted
+	}
+
+	if perKeyLimiter != nil && key != nil && n > 0 {
+		res := perKeyLimiter.AllowN(key.Name, n)
+		if !res.Allowed {
+			if res.ThrottlingStarted {
+				notifyThrottled("API key \"" + key.Name + "\"")
+			}
+			if res.RetryAfter > retryAfter {
+				retryAfter = res.RetryAfter
+			}
+		}
+		n = res.Granted
+
+		//give back the tokens of the items rejected by the key limit so a throttled key does not drain the
+		//bucket of its address
+		if ipGranted > n {
+			perIpLimiter.Refund(ip, ipGranted - n)
+		}
+	}
+
+	return n, retryAfter
+}
+
+// remoteIP returns the address of the client. Connections to a unix socket don't have one so an empty string is
+// returned for them.
+func remoteIP(ctx *server.RequestCtx) string {
+	if addr, ok := ctx.RemoteAddr().(*net.TCPAddr); ok {
+		return addr.IP.String()
+	}
+	return ""
+}
+
+//------------------------------------------------------------------------------
+
+// Only the first rejected request is notified to avoid flooding the channel while the client keeps retrying
+func notifyThrottled(client string) {
+	rl := settings.Config.Server.RateLimit
+
+	if len(rl.Channel) > 0 {
+		_ = logger.LogTarget("rateLimit", client, rl.Severity, rl.Channel, "Requests from %v are being throttled.", client)
+	} else {
+		console.Warn("Requests from %v are being throttled.", client)
+	}
+	return
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/randlabs/server-watchdog/modules/apikeys"
+	"github.com/randlabs/server-watchdog/modules/logger"
+	"github.com/randlabs/server-watchdog/modules/processwatcher"
+	"github.com/randlabs/server-watchdog/settings"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	maxNotifyTimestampSkew = 5 * time.Minute
+)
+
+//------------------------------------------------------------------------------
+
+// ErrChannelNotAllowed is returned when the API key used in a request cannot use the channel
+var ErrChannelNotAllowed = errors.New("API key not allowed to use this channel")
+
+//------------------------------------------------------------------------------
+
+// The following functions process the requests of clients regardless of the API they used to send them
+
+// SendNotification validates a notification sent by a client and delivers it. Returns false if the notification was
+// discarded because it is a duplicate.
+func SendNotification(key *apikeys.Key, r *NotifyRequest) (bool, error) {
+	var at time.Time
+
+	//validate channel
+	if !settings.ValidateChannel(r.Channel) {
+		return false, errors.New("Channel not found or not specified")
+	}
+	if !canUseChannel(key, r.Channel) {
+		return false, ErrChannelNotAllowed
+	}
+
+	//validate message
+	if len(r.Message) == 0 {
+		return false, errors.New("No message")
+	}
+
+	//events buffered by the client keep the time they happened
+	if r.Timestamp != nil {
+		if r.Timestamp.After(time.Now().Add(maxNotifyTimestampSkew)) {
+			return false, errors.New("Timestamp in the future")
+		}
+		at = *r.Timestamp
+	}
+
+	//the origin of the event is shown in the message and can be used to silence it
+	target := r.Host
+	if len(r.Source) > 0 {
+		if len(target) > 0 {
+			target += "/"
+		}
+		target += r.Source
+	}
+
+	if isDuplicateNotification(r.Channel, r.DedupKey) {
+		return false, nil
+	}
+
+	//do log
+	var err error
+	if len(target) > 0 {
+		err = logger.LogTargetAt("notify", target, r.Severity, r.Channel, at, "[%v] %v", target, r.Message)
+	} else {
+		err = logger.LogTargetAt("notify", "", r.Severity, r.Channel, at, "%v", r.Message)
+	}
+	if err != nil {
+		forgetNotification(r.Channel, r.DedupKey)
+		return false, err
+	}
+	return true, nil
+}
+
+// WatchProcess validates a request to watch a process and adds it to the watch list
+func WatchProcess(key *apikeys.Key, r *WatchProcessRequest) error {
+	//validate channel
+	if !settings.ValidateChannel(r.Channel) {
+		return errors.New("Channel not found or not specified")
+	}
+	if !canUseChannel(key, r.Channel) {
+		return ErrChannelNotAllowed
+	}
+
+	//validate process id
+	if r.Pid < 1 || r.Pid > 0x7FFFFFFF {
+		return errors.New("Invalid process id")
+	}
+
+	//add to watch list
+	return processwatcher.AddProcess(r.Pid, r.Name, r.MaxMemUsage, r.Severity, r.Channel)
+}
+
+// UnwatchProcess validates a request to stop watching a process and removes it from the watch list
+func UnwatchProcess(key *apikeys.Key, r *UnwatchProcessRequest) error {
+	//validate channel
+	if len(r.Channel) == 0 {
+		return errors.New("No channel")
+	}
+	r.Channel = strings.ToLower(r.Channel)
+	if _, ok := settings.Config.Channels[r.Channel]; !ok {
+		return errors.New("Channel not found")
+	}
+	if !canUseChannel(key, r.Channel) {
+		return ErrChannelNotAllowed
+	}
+
+	//validate process id
+	if r.Pid < 1 || r.Pid > 0x7FFFFFFF {
+		return errors.New("Invalid process id")
+	}
+
+	//remove from watch list
+	processwatcher.RemoveProcess(r.Pid, r.Channel)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func canUseChannel(key *apikeys.Key, channel string) bool {
+	return key != nil && key.CanUseChannel(channel)
+}
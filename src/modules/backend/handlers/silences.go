@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/randlabs/server-watchdog/modules/silences"
+	"github.com/randlabs/server-watchdog/server"
+	"github.com/randlabs/server-watchdog/settings"
+)
+
+//------------------------------------------------------------------------------
+
+func onGetSilences(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "read") {
+		return
+	}
+
+	server.SendJSON(ctx, silences.GetSilences())
+	return
+}
+
+func onPostSilence(ctx *server.RequestCtx) {
+	var r CreateSilenceRequest
+
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	err := json.Unmarshal(ctx.PostBody(), &r)
+	if err != nil {
+		server.SendBadRequest(ctx, "")
+		return
+	}
+
+	silence := silences.Silence{
+		Matchers: r.Matchers,
+		Comment:  r.Comment,
+	}
+	if r.StartsAt != nil {
+		silence.StartsAt = r.StartsAt.UTC()
+	} else {
+		silence.StartsAt = time.Now().UTC()
+	}
+	if r.EndsAt != nil {
+		silence.EndsAt = r.EndsAt.UTC()
+	} else if len(r.Duration) > 0 {
+		d, ok := settings.ValidateTimeSpan(r.Duration)
+		if !ok {
+			server.SendBadRequest(ctx, "Invalid duration")
+			return
+		}
+		silence.EndsAt = silence.StartsAt.Add(d)
+	} else {
+		server.SendBadRequest(ctx, "No end time or duration")
+		return
+	}
+
+	id, err := silences.Add(silence)
+	if err != nil {
+		server.SendBadRequest(ctx, err.Error())
+		return
+	}
+
+	server.SendJSON(ctx, CreateSilenceResponse{
+		Id: id,
+	})
+	return
+}
+
+func onDeleteSilence(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "admin") {
+		return
+	}
+
+	id, _ := ctx.UserValue("id").(string)
+	err := silences.Expire(id)
+	if err != nil {
+		if err == silences.ErrNotFound {
+			server.SendNotFound(ctx, err.Error())
+		} else {
+			server.SendBadRequest(ctx, err.Error())
+		}
+		return
+	}
+
+	server.SendSuccess(ctx)
+	return
+}
+
+func onGetMaintenance(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "read") {
+		return
+	}
+
+	server.SendJSON(ctx, silences.GetMaintenanceWindows())
+	return
+}
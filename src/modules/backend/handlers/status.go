@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"github.com/randlabs/server-watchdog/modules/freediskspacechecker"
+	"github.com/randlabs/server-watchdog/modules/heartbeats"
+	"github.com/randlabs/server-watchdog/modules/jobs"
+	"github.com/randlabs/server-watchdog/modules/processwatcher"
+	"github.com/randlabs/server-watchdog/modules/tcpports"
+	"github.com/randlabs/server-watchdog/modules/webchecker"
+	"github.com/randlabs/server-watchdog/server"
+)
+
+//------------------------------------------------------------------------------
+
+func onGetStatus(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "read") {
+		return
+	}
+
+	server.SendJSON(ctx, GetStatus())
+	return
+}
+
+// GetStatus returns the state of all the monitors
+func GetStatus() StatusResponse {
+	return StatusResponse{
+		Webs:       webchecker.GetStatus(),
+		TcpPorts:   tcpports.GetStatus(),
+		Disks:      freediskspacechecker.GetStatus(),
+		Processes:  processwatcher.GetStatus(),
+		Heartbeats: heartbeats.GetStatus(),
+		Jobs:       jobs.GetStatus(),
+	}
+}
+
+func onGetWebs(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "read") {
+		return
+	}
+
+	server.SendJSON(ctx, webchecker.GetStatus())
+	return
+}
+
+func onGetTcpPorts(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "read") {
+		return
+	}
+
+	server.SendJSON(ctx, tcpports.GetStatus())
+	return
+}
+
+func onGetDisks(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "read") {
+		return
+	}
+
+	server.SendJSON(ctx, freediskspacechecker.GetStatus())
+	return
+}
+
+func onGetProcesses(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "read") {
+		return
+	}
+
+	server.SendJSON(ctx, processwatcher.GetStatus())
+	return
+}
+
+func onGetHeartbeats(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "read") {
+		return
+	}
+
+	server.SendJSON(ctx, heartbeats.GetStatus())
+	return
+}
+
+func onGetJobs(ctx *server.RequestCtx) {
+	if !checkApiKey(ctx, "read") {
+		return
+	}
+
+	server.SendJSON(ctx, jobs.GetStatus())
+	return
+}
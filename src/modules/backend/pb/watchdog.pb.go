@@ -0,0 +1,1278 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: watchdog.proto
+
+package pb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type NotifyRequest struct {
+	Channel              string               `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	Message              string               `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Severity             string               `protobuf:"bytes,3,opt,name=severity,proto3" json:"severity,omitempty"`
+	Timestamp            *timestamp.Timestamp `protobuf:"bytes,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Source               string               `protobuf:"bytes,5,opt,name=source,proto3" json:"source,omitempty"`
+	Host                 string               `protobuf:"bytes,6,opt,name=host,proto3" json:"host,omitempty"`
+	DedupKey             string               `protobuf:"bytes,7,opt,name=dedup_key,json=dedupKey,proto3" json:"dedup_key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *NotifyRequest) Reset()         { *m = NotifyRequest{} }
+func (m *NotifyRequest) String() string { return proto.CompactTextString(m) }
+func (*NotifyRequest) ProtoMessage()    {}
+func (*NotifyRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_554efde3f2d36ab7, []int{0}
+}
+
+func (m *NotifyRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NotifyRequest.Unmarshal(m, b)
+}
+func (m *NotifyRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NotifyRequest.Marshal(b, m, deterministic)
+}
+func (m *NotifyRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NotifyRequest.Merge(m, src)
+}
+func (m *NotifyRequest) XXX_Size() int {
+	return xxx_messageInfo_NotifyRequest.Size(m)
+}
+func (m *NotifyRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NotifyRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NotifyRequest proto.InternalMessageInfo
+
+func (m *NotifyRequest) GetChannel() string {
+	if m != nil {
+		return m.Channel
+	}
+	return ""
+}
+
+func (m *NotifyRequest) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *NotifyRequest) GetSeverity() string {
+	if m != nil {
+		return m.Severity
+	}
+	return ""
+}
+
+func (m *NotifyRequest) GetTimestamp() *timestamp.Timestamp {
+	if m != nil {
+		return m.Timestamp
+	}
+	return nil
+}
+
+func (m *NotifyRequest) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+func (m *NotifyRequest) GetHost() string {
+	if m != nil {
+		return m.Host
+	}
+	return ""
+}
+
+func (m *NotifyRequest) GetDedupKey() string {
+	if m != nil {
+		return m.DedupKey
+	}
+	return ""
+}
+
+type NotifyResponse struct {
+	Success              bool     `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Duplicate            bool     `protobuf:"varint,2,opt,name=duplicate,proto3" json:"duplicate,omitempty"`
+	Error                string   `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NotifyResponse) Reset()         { *m = NotifyResponse{} }
+func (m *NotifyResponse) String() string { return proto.CompactTextString(m) }
+func (*NotifyResponse) ProtoMessage()    {}
+func (*NotifyResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_554efde3f2d36ab7, []int{1}
+}
+
+func (m *NotifyResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NotifyResponse.Unmarshal(m, b)
+}
+func (m *NotifyResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NotifyResponse.Marshal(b, m, deterministic)
+}
+func (m *NotifyResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NotifyResponse.Merge(m, src)
+}
+func (m *NotifyResponse) XXX_Size() int {
+	return xxx_messageInfo_NotifyResponse.Size(m)
+}
+func (m *NotifyResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_NotifyResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NotifyResponse proto.InternalMessageInfo
+
+func (m *NotifyResponse) GetSuccess() bool {
+	if m != nil {
+		return m.Success
+	}
+	return false
+}
+
+func (m *NotifyResponse) GetDuplicate() bool {
+	if m != nil {
+		return m.Duplicate
+	}
+	return false
+}
+
+func (m *NotifyResponse) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type WatchProcessRequest struct {
+	Channel              string   `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	Pid                  int32    `protobuf:"varint,2,opt,name=pid,proto3" json:"pid,omitempty"`
+	MaxMem               string   `protobuf:"bytes,3,opt,name=max_mem,json=maxMem,proto3" json:"max_mem,omitempty"`
+	Name                 string   `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	Severity             string   `protobuf:"bytes,5,opt,name=severity,proto3" json:"severity,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WatchProcessRequest) Reset()         { *m = WatchProcessRequest{} }
+func (m *WatchProcessRequest) String() string { return proto.CompactTextString(m) }
+func (*WatchProcessRequest) ProtoMessage()    {}
+func (*WatchProcessRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_554efde3f2d36ab7, []int{2}
+}
+
+func (m *WatchProcessRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchProcessRequest.Unmarshal(m, b)
+}
+func (m *WatchProcessRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchProcessRequest.Marshal(b, m, deterministic)
+}
+func (m *WatchProcessRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchProcessRequest.Merge(m, src)
+}
+func (m *WatchProcessRequest) XXX_Size() int {
+	return xxx_messageInfo_WatchProcessRequest.Size(m)
+}
+func (m *WatchProcessRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchProcessRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchProcessRequest proto.InternalMessageInfo
+
+func (m *WatchProcessRequest) GetChannel() string {
+	if m != nil {
+		return m.Channel
+	}
+	return ""
+}
+
+func (m *WatchProcessRequest) GetPid() int32 {
+	if m != nil {
+		return m.Pid
+	}
+	return 0
+}
+
+func (m *WatchProcessRequest) GetMaxMem() string {
+	if m != nil {
+		return m.MaxMem
+	}
+	return ""
+}
+
+func (m *WatchProcessRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *WatchProcessRequest) GetSeverity() string {
+	if m != nil {
+		return m.Severity
+	}
+	return ""
+}
+
+type WatchProcessResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WatchProcessResponse) Reset()         { *m = WatchProcessResponse{} }
+func (m *WatchProcessResponse) String() string { return proto.CompactTextString(m) }
+func (*WatchProcessResponse) ProtoMessage()    {}
+func (*WatchProcessResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_554efde3f2d36ab7, []int{3}
+}
+
+func (m *WatchProcessResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchProcessResponse.Unmarshal(m, b)
+}
+func (m *WatchProcessResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchProcessResponse.Marshal(b, m, deterministic)
+}
+func (m *WatchProcessResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchProcessResponse.Merge(m, src)
+}
+func (m *WatchProcessResponse) XXX_Size() int {
+	return xxx_messageInfo_WatchProcessResponse.Size(m)
+}
+func (m *WatchProcessResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchProcessResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchProcessResponse proto.InternalMessageInfo
+
+type UnwatchProcessRequest struct {
+	Channel              string   `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	Pid                  int32    `protobuf:"varint,2,opt,name=pid,proto3" json:"pid,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UnwatchProcessRequest) Reset()         { *m = UnwatchProcessRequest{} }
+func (m *UnwatchProcessRequest) String() string { return proto.CompactTextString(m) }
+func (*UnwatchProcessRequest) ProtoMessage()    {}
+func (*UnwatchProcessRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_554efde3f2d36ab7, []int{4}
+}
+
+func (m *UnwatchProcessRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UnwatchProcessRequest.Unmarshal(m, b)
+}
+func (m *UnwatchProcessRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UnwatchProcessRequest.Marshal(b, m, deterministic)
+}
+func (m *UnwatchProcessRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UnwatchProcessRequest.Merge(m, src)
+}
+func (m *UnwatchProcessRequest) XXX_Size() int {
+	return xxx_messageInfo_UnwatchProcessRequest.Size(m)
+}
+func (m *UnwatchProcessRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UnwatchProcessRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UnwatchProcessRequest proto.InternalMessageInfo
+
+func (m *UnwatchProcessRequest) GetChannel() string {
+	if m != nil {
+		return m.Channel
+	}
+	return ""
+}
+
+func (m *UnwatchProcessRequest) GetPid() int32 {
+	if m != nil {
+		return m.Pid
+	}
+	return 0
+}
+
+type UnwatchProcessResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UnwatchProcessResponse) Reset()         { *m = UnwatchProcessResponse{} }
+func (m *UnwatchProcessResponse) String() string { return proto.CompactTextString(m) }
+func (*UnwatchProcessResponse) ProtoMessage()    {}
+func (*UnwatchProcessResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_554efde3f2d36ab7, []int{5}
+}
+
+func (m *UnwatchProcessResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UnwatchProcessResponse.Unmarshal(m, b)
+}
+func (m *UnwatchProcessResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UnwatchProcessResponse.Marshal(b, m, deterministic)
+}
+func (m *UnwatchProcessResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UnwatchProcessResponse.Merge(m, src)
+}
+func (m *UnwatchProcessResponse) XXX_Size() int {
+	return xxx_messageInfo_UnwatchProcessResponse.Size(m)
+}
+func (m *UnwatchProcessResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_UnwatchProcessResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UnwatchProcessResponse proto.InternalMessageInfo
+
+type GetStatusRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetStatusRequest) Reset()         { *m = GetStatusRequest{} }
+func (m *GetStatusRequest) String() string { return proto.CompactTextString(m) }
+func (*GetStatusRequest) ProtoMessage()    {}
+func (*GetStatusRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_554efde3f2d36ab7, []int{6}
+}
+
+func (m *GetStatusRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetStatusRequest.Unmarshal(m, b)
+}
+func (m *GetStatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetStatusRequest.Marshal(b, m, deterministic)
+}
+func (m *GetStatusRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetStatusRequest.Merge(m, src)
+}
+func (m *GetStatusRequest) XXX_Size() int {
+	return xxx_messageInfo_GetStatusRequest.Size(m)
+}
+func (m *GetStatusRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetStatusRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetStatusRequest proto.InternalMessageInfo
+
+type GetStatusResponse struct {
+	Webs                 []*MonitorStatus   `protobuf:"bytes,1,rep,name=webs,proto3" json:"webs,omitempty"`
+	TcpPorts             []*MonitorStatus   `protobuf:"bytes,2,rep,name=tcp_ports,json=tcpPorts,proto3" json:"tcp_ports,omitempty"`
+	Disks                []*MonitorStatus   `protobuf:"bytes,3,rep,name=disks,proto3" json:"disks,omitempty"`
+	Processes            []*ProcessStatus   `protobuf:"bytes,4,rep,name=processes,proto3" json:"processes,omitempty"`
+	Heartbeats           []*HeartbeatStatus `protobuf:"bytes,5,rep,name=heartbeats,proto3" json:"heartbeats,omitempty"`
+	Jobs                 []*JobStatus       `protobuf:"bytes,6,rep,name=jobs,proto3" json:"jobs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *GetStatusResponse) Reset()         { *m = GetStatusResponse{} }
+func (m *GetStatusResponse) String() string { return proto.CompactTextString(m) }
+func (*GetStatusResponse) ProtoMessage()    {}
+func (*GetStatusResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_554efde3f2d36ab7, []int{7}
+}
+
+func (m *GetStatusResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetStatusResponse.Unmarshal(m, b)
+}
+func (m *GetStatusResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetStatusResponse.Marshal(b, m, deterministic)
+}
+func (m *GetStatusResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetStatusResponse.Merge(m, src)
+}
+func (m *GetStatusResponse) XXX_Size() int {
+	return xxx_messageInfo_GetStatusResponse.Size(m)
+}
+func (m *GetStatusResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetStatusResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetStatusResponse proto.InternalMessageInfo
+
+func (m *GetStatusResponse) GetWebs() []*MonitorStatus {
+	if m != nil {
+		return m.Webs
+	}
+	return nil
+}
+
+func (m *GetStatusResponse) GetTcpPorts() []*MonitorStatus {
+	if m != nil {
+		return m.TcpPorts
+	}
+	return nil
+}
+
+func (m *GetStatusResponse) GetDisks() []*MonitorStatus {
+	if m != nil {
+		return m.Disks
+	}
+	return nil
+}
+
+func (m *GetStatusResponse) GetProcesses() []*ProcessStatus {
+	if m != nil {
+		return m.Processes
+	}
+	return nil
+}
+
+func (m *GetStatusResponse) GetHeartbeats() []*HeartbeatStatus {
+	if m != nil {
+		return m.Heartbeats
+	}
+	return nil
+}
+
+func (m *GetStatusResponse) GetJobs() []*JobStatus {
+	if m != nil {
+		return m.Jobs
+	}
+	return nil
+}
+
+// MonitorStatus is the state of a web, TCP port group or device. The name is the url, the group name or the device.
+type MonitorStatus struct {
+	Id                   string               `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Source               string               `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	Name                 string               `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Channel              string               `protobuf:"bytes,4,opt,name=channel,proto3" json:"channel,omitempty"`
+	Failing              bool                 `protobuf:"varint,5,opt,name=failing,proto3" json:"failing,omitempty"`
+	Flapping             bool                 `protobuf:"varint,6,opt,name=flapping,proto3" json:"flapping,omitempty"`
+	Paused               bool                 `protobuf:"varint,7,opt,name=paused,proto3" json:"paused,omitempty"`
+	ConsecutiveFailures  uint32               `protobuf:"varint,8,opt,name=consecutive_failures,json=consecutiveFailures,proto3" json:"consecutive_failures,omitempty"`
+	LastCheck            *timestamp.Timestamp `protobuf:"bytes,9,opt,name=last_check,json=lastCheck,proto3" json:"last_check,omitempty"`
+	NextCheck            *timestamp.Timestamp `protobuf:"bytes,10,opt,name=next_check,json=nextCheck,proto3" json:"next_check,omitempty"`
+	LastNotification     *timestamp.Timestamp `protobuf:"bytes,11,opt,name=last_notification,json=lastNotification,proto3" json:"last_notification,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *MonitorStatus) Reset()         { *m = MonitorStatus{} }
+func (m *MonitorStatus) String() string { return proto.CompactTextString(m) }
+func (*MonitorStatus) ProtoMessage()    {}
+func (*MonitorStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_554efde3f2d36ab7, []int{8}
+}
+
+func (m *MonitorStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MonitorStatus.Unmarshal(m, b)
+}
+func (m *MonitorStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MonitorStatus.Marshal(b, m, deterministic)
+}
+func (m *MonitorStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MonitorStatus.Merge(m, src)
+}
+func (m *MonitorStatus) XXX_Size() int {
+	return xxx_messageInfo_MonitorStatus.Size(m)
+}
+func (m *MonitorStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_MonitorStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MonitorStatus proto.InternalMessageInfo
+
+func (m *MonitorStatus) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *MonitorStatus) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+func (m *MonitorStatus) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *MonitorStatus) GetChannel() string {
+	if m != nil {
+		return m.Channel
+	}
+	return ""
+}
+
+func (m *MonitorStatus) GetFailing() bool {
+	if m != nil {
+		return m.Failing
+	}
+	return false
+}
+
+func (m *MonitorStatus) GetFlapping() bool {
+	if m != nil {
+		return m.Flapping
+	}
+	return false
+}
+
+func (m *MonitorStatus) GetPaused() bool {
+	if m != nil {
+		return m.Paused
+	}
+	return false
+}
+
+func (m *MonitorStatus) GetConsecutiveFailures() uint32 {
+	if m != nil {
+		return m.ConsecutiveFailures
+	}
+	return 0
+}
+
+func (m *MonitorStatus) GetLastCheck() *timestamp.Timestamp {
+	if m != nil {
+		return m.LastCheck
+	}
+	return nil
+}
+
+func (m *MonitorStatus) GetNextCheck() *timestamp.Timestamp {
+	if m != nil {
+		return m.NextCheck
+	}
+	return nil
+}
+
+func (m *MonitorStatus) GetLastNotification() *timestamp.Timestamp {
+	if m != nil {
+		return m.LastNotification
+	}
+	return nil
+}
+
+type ProcessStatus struct {
+	Pid                  int32                `protobuf:"varint,1,opt,name=pid,proto3" json:"pid,omitempty"`
+	Name                 string               `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Channel              string               `protobuf:"bytes,3,opt,name=channel,proto3" json:"channel,omitempty"`
+	Severity             string               `protobuf:"bytes,4,opt,name=severity,proto3" json:"severity,omitempty"`
+	MemoryUsage          uint64               `protobuf:"varint,5,opt,name=memory_usage,json=memoryUsage,proto3" json:"memory_usage,omitempty"`
+	WatchedSince         *timestamp.Timestamp `protobuf:"bytes,6,opt,name=watched_since,json=watchedSince,proto3" json:"watched_since,omitempty"`
+	LastCheck            *timestamp.Timestamp `protobuf:"bytes,7,opt,name=last_check,json=lastCheck,proto3" json:"last_check,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *ProcessStatus) Reset()         { *m = ProcessStatus{} }
+func (m *ProcessStatus) String() string { return proto.CompactTextString(m) }
+func (*ProcessStatus) ProtoMessage()    {}
+func (*ProcessStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_554efde3f2d36ab7, []int{9}
+}
+
+func (m *ProcessStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProcessStatus.Unmarshal(m, b)
+}
+func (m *ProcessStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProcessStatus.Marshal(b, m, deterministic)
+}
+func (m *ProcessStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProcessStatus.Merge(m, src)
+}
+func (m *ProcessStatus) XXX_Size() int {
+	return xxx_messageInfo_ProcessStatus.Size(m)
+}
+func (m *ProcessStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProcessStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProcessStatus proto.InternalMessageInfo
+
+func (m *ProcessStatus) GetPid() int32 {
+	if m != nil {
+		return m.Pid
+	}
+	return 0
+}
+
+func (m *ProcessStatus) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ProcessStatus) GetChannel() string {
+	if m != nil {
+		return m.Channel
+	}
+	return ""
+}
+
+func (m *ProcessStatus) GetSeverity() string {
+	if m != nil {
+		return m.Severity
+	}
+	return ""
+}
+
+func (m *ProcessStatus) GetMemoryUsage() uint64 {
+	if m != nil {
+		return m.MemoryUsage
+	}
+	return 0
+}
+
+func (m *ProcessStatus) GetWatchedSince() *timestamp.Timestamp {
+	if m != nil {
+		return m.WatchedSince
+	}
+	return nil
+}
+
+func (m *ProcessStatus) GetLastCheck() *timestamp.Timestamp {
+	if m != nil {
+		return m.LastCheck
+	}
+	return nil
+}
+
+type HeartbeatStatus struct {
+	Name                 string               `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Channel              string               `protobuf:"bytes,2,opt,name=channel,proto3" json:"channel,omitempty"`
+	Overdue              bool                 `protobuf:"varint,3,opt,name=overdue,proto3" json:"overdue,omitempty"`
+	LastPing             *timestamp.Timestamp `protobuf:"bytes,4,opt,name=last_ping,json=lastPing,proto3" json:"last_ping,omitempty"`
+	Deadline             *timestamp.Timestamp `protobuf:"bytes,5,opt,name=deadline,proto3" json:"deadline,omitempty"`
+	LastNotification     *timestamp.Timestamp `protobuf:"bytes,6,opt,name=last_notification,json=lastNotification,proto3" json:"last_notification,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *HeartbeatStatus) Reset()         { *m = HeartbeatStatus{} }
+func (m *HeartbeatStatus) String() string { return proto.CompactTextString(m) }
+func (*HeartbeatStatus) ProtoMessage()    {}
+func (*HeartbeatStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_554efde3f2d36ab7, []int{10}
+}
+
+func (m *HeartbeatStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HeartbeatStatus.Unmarshal(m, b)
+}
+func (m *HeartbeatStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HeartbeatStatus.Marshal(b, m, deterministic)
+}
+func (m *HeartbeatStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HeartbeatStatus.Merge(m, src)
+}
+func (m *HeartbeatStatus) XXX_Size() int {
+	return xxx_messageInfo_HeartbeatStatus.Size(m)
+}
+func (m *HeartbeatStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_HeartbeatStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HeartbeatStatus proto.InternalMessageInfo
+
+func (m *HeartbeatStatus) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *HeartbeatStatus) GetChannel() string {
+	if m != nil {
+		return m.Channel
+	}
+	return ""
+}
+
+func (m *HeartbeatStatus) GetOverdue() bool {
+	if m != nil {
+		return m.Overdue
+	}
+	return false
+}
+
+func (m *HeartbeatStatus) GetLastPing() *timestamp.Timestamp {
+	if m != nil {
+		return m.LastPing
+	}
+	return nil
+}
+
+func (m *HeartbeatStatus) GetDeadline() *timestamp.Timestamp {
+	if m != nil {
+		return m.Deadline
+	}
+	return nil
+}
+
+func (m *HeartbeatStatus) GetLastNotification() *timestamp.Timestamp {
+	if m != nil {
+		return m.LastNotification
+	}
+	return nil
+}
+
+type JobStatus struct {
+	Name                 string               `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Source               string               `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	Channel              string               `protobuf:"bytes,3,opt,name=channel,proto3" json:"channel,omitempty"`
+	Running              bool                 `protobuf:"varint,4,opt,name=running,proto3" json:"running,omitempty"`
+	Failing              bool                 `protobuf:"varint,5,opt,name=failing,proto3" json:"failing,omitempty"`
+	CurrentRun           *JobRun              `protobuf:"bytes,6,opt,name=current_run,json=currentRun,proto3" json:"current_run,omitempty"`
+	LastRun              *JobRun              `protobuf:"bytes,7,opt,name=last_run,json=lastRun,proto3" json:"last_run,omitempty"`
+	ExpectedStart        *timestamp.Timestamp `protobuf:"bytes,8,opt,name=expected_start,json=expectedStart,proto3" json:"expected_start,omitempty"`
+	LastNotification     *timestamp.Timestamp `protobuf:"bytes,9,opt,name=last_notification,json=lastNotification,proto3" json:"last_notification,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *JobStatus) Reset()         { *m = JobStatus{} }
+func (m *JobStatus) String() string { return proto.CompactTextString(m) }
+func (*JobStatus) ProtoMessage()    {}
+func (*JobStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_554efde3f2d36ab7, []int{11}
+}
+
+func (m *JobStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_JobStatus.Unmarshal(m, b)
+}
+func (m *JobStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_JobStatus.Marshal(b, m, deterministic)
+}
+func (m *JobStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_JobStatus.Merge(m, src)
+}
+func (m *JobStatus) XXX_Size() int {
+	return xxx_messageInfo_JobStatus.Size(m)
+}
+func (m *JobStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_JobStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_JobStatus proto.InternalMessageInfo
+
+func (m *JobStatus) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *JobStatus) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+func (m *JobStatus) GetChannel() string {
+	if m != nil {
+		return m.Channel
+	}
+	return ""
+}
+
+func (m *JobStatus) GetRunning() bool {
+	if m != nil {
+		return m.Running
+	}
+	return false
+}
+
+func (m *JobStatus) GetFailing() bool {
+	if m != nil {
+		return m.Failing
+	}
+	return false
+}
+
+func (m *JobStatus) GetCurrentRun() *JobRun {
+	if m != nil {
+		return m.CurrentRun
+	}
+	return nil
+}
+
+func (m *JobStatus) GetLastRun() *JobRun {
+	if m != nil {
+		return m.LastRun
+	}
+	return nil
+}
+
+func (m *JobStatus) GetExpectedStart() *timestamp.Timestamp {
+	if m != nil {
+		return m.ExpectedStart
+	}
+	return nil
+}
+
+func (m *JobStatus) GetLastNotification() *timestamp.Timestamp {
+	if m != nil {
+		return m.LastNotification
+	}
+	return nil
+}
+
+type JobRun struct {
+	StartedAt            *timestamp.Timestamp `protobuf:"bytes,1,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	FinishedAt           *timestamp.Timestamp `protobuf:"bytes,2,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	Succeeded            bool                 `protobuf:"varint,3,opt,name=succeeded,proto3" json:"succeeded,omitempty"`
+	HasExitCode          bool                 `protobuf:"varint,4,opt,name=has_exit_code,json=hasExitCode,proto3" json:"has_exit_code,omitempty"`
+	ExitCode             int32                `protobuf:"varint,5,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *JobRun) Reset()         { *m = JobRun{} }
+func (m *JobRun) String() string { return proto.CompactTextString(m) }
+func (*JobRun) ProtoMessage()    {}
+func (*JobRun) Descriptor() ([]byte, []int) {
+	return fileDescriptor_554efde3f2d36ab7, []int{12}
+}
+
+func (m *JobRun) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_JobRun.Unmarshal(m, b)
+}
+func (m *JobRun) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_JobRun.Marshal(b, m, deterministic)
+}
+func (m *JobRun) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_JobRun.Merge(m, src)
+}
+func (m *JobRun) XXX_Size() int {
+	return xxx_messageInfo_JobRun.Size(m)
+}
+func (m *JobRun) XXX_DiscardUnknown() {
+	xxx_messageInfo_JobRun.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_JobRun proto.InternalMessageInfo
+
+func (m *JobRun) GetStartedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.StartedAt
+	}
+	return nil
+}
+
+func (m *JobRun) GetFinishedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.FinishedAt
+	}
+	return nil
+}
+
+func (m *JobRun) GetSucceeded() bool {
+	if m != nil {
+		return m.Succeeded
+	}
+	return false
+}
+
+func (m *JobRun) GetHasExitCode() bool {
+	if m != nil {
+		return m.HasExitCode
+	}
+	return false
+}
+
+func (m *JobRun) GetExitCode() int32 {
+	if m != nil {
+		return m.ExitCode
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*NotifyRequest)(nil), "watchdog.NotifyRequest")
+	proto.RegisterType((*NotifyResponse)(nil), "watchdog.NotifyResponse")
+	proto.RegisterType((*WatchProcessRequest)(nil), "watchdog.WatchProcessRequest")
+	proto.RegisterType((*WatchProcessResponse)(nil), "watchdog.WatchProcessResponse")
+	proto.RegisterType((*UnwatchProcessRequest)(nil), "watchdog.UnwatchProcessRequest")
+	proto.RegisterType((*UnwatchProcessResponse)(nil), "watchdog.UnwatchProcessResponse")
+	proto.RegisterType((*GetStatusRequest)(nil), "watchdog.GetStatusRequest")
+	proto.RegisterType((*GetStatusResponse)(nil), "watchdog.GetStatusResponse")
+	proto.RegisterType((*MonitorStatus)(nil), "watchdog.MonitorStatus")
+	proto.RegisterType((*ProcessStatus)(nil), "watchdog.ProcessStatus")
+	proto.RegisterType((*HeartbeatStatus)(nil), "watchdog.HeartbeatStatus")
+	proto.RegisterType((*JobStatus)(nil), "watchdog.JobStatus")
+	proto.RegisterType((*JobRun)(nil), "watchdog.JobRun")
+}
+
+func init() { proto.RegisterFile("watchdog.proto", fileDescriptor_554efde3f2d36ab7) }
+
+var fileDescriptor_554efde3f2d36ab7 = []byte{
+	// 1103 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x56, 0xcd, 0x6e, 0xdb, 0x46,
+	0x10, 0x06, 0xa9, 0x3f, 0x72, 0x64, 0xb9, 0xce, 0x26, 0x75, 0x58, 0xb9, 0x6d, 0x5c, 0x5d, 0x6a,
+	0x20, 0x88, 0xd4, 0xb8, 0xbf, 0x81, 0x0f, 0x85, 0xeb, 0xa6, 0x29, 0x5a, 0x38, 0x30, 0xe8, 0x06,
+	0x01, 0x72, 0x28, 0xb1, 0x22, 0xc7, 0x12, 0x6b, 0x91, 0xcb, 0x72, 0x97, 0xb6, 0xfc, 0x0a, 0xb9,
+	0xf6, 0x19, 0xfa, 0x1e, 0x7d, 0x93, 0x02, 0x7d, 0x83, 0xde, 0x7a, 0x2c, 0x76, 0xb9, 0x2b, 0x4a,
+	0xb2, 0x6c, 0xa1, 0xee, 0x4d, 0xb3, 0xf3, 0x7d, 0xc3, 0x9d, 0x99, 0x6f, 0x56, 0x03, 0x9b, 0x97,
+	0x54, 0x84, 0xe3, 0x88, 0x8d, 0xfa, 0x59, 0xce, 0x04, 0x23, 0x8e, 0xb1, 0xbb, 0x8f, 0x46, 0x8c,
+	0x8d, 0x26, 0x38, 0x50, 0xe7, 0xc3, 0xe2, 0x6c, 0x20, 0xe2, 0x04, 0xb9, 0xa0, 0x49, 0x56, 0x42,
+	0x7b, 0x7f, 0x59, 0xd0, 0x79, 0xc9, 0x44, 0x7c, 0x76, 0xe5, 0xe3, 0xaf, 0x05, 0x72, 0x41, 0x3c,
+	0x68, 0x85, 0x63, 0x9a, 0xa6, 0x38, 0xf1, 0xac, 0x5d, 0x6b, 0xcf, 0xf5, 0x8d, 0x29, 0x3d, 0x09,
+	0x72, 0x4e, 0x47, 0xe8, 0xd9, 0xa5, 0x47, 0x9b, 0xa4, 0x0b, 0x0e, 0xc7, 0x0b, 0xcc, 0x63, 0x71,
+	0xe5, 0xd5, 0x94, 0x6b, 0x66, 0x93, 0xaf, 0xc0, 0x9d, 0x7d, 0xd4, 0xab, 0xef, 0x5a, 0x7b, 0xed,
+	0xfd, 0x6e, 0xbf, 0xbc, 0x56, 0xdf, 0x5c, 0xab, 0xff, 0x93, 0x41, 0xf8, 0x15, 0x98, 0x6c, 0x43,
+	0x93, 0xb3, 0x22, 0x0f, 0xd1, 0x6b, 0xa8, 0x98, 0xda, 0x22, 0x04, 0xea, 0x63, 0xc6, 0x85, 0xd7,
+	0x54, 0xa7, 0xea, 0x37, 0xd9, 0x01, 0x37, 0xc2, 0xa8, 0xc8, 0x82, 0x73, 0xbc, 0xf2, 0x5a, 0xe5,
+	0x15, 0xd4, 0xc1, 0x8f, 0x78, 0xd5, 0xfb, 0x19, 0x36, 0x4d, 0x8e, 0x3c, 0x63, 0x29, 0x47, 0x99,
+	0x0a, 0x2f, 0xc2, 0x10, 0x39, 0x57, 0x49, 0x3a, 0xbe, 0x31, 0xc9, 0xfb, 0xe0, 0x46, 0x45, 0x36,
+	0x89, 0x43, 0x2a, 0xca, 0x34, 0x1d, 0xbf, 0x3a, 0x20, 0x0f, 0xa0, 0x81, 0x79, 0xce, 0x72, 0x9d,
+	0x65, 0x69, 0xf4, 0xde, 0x5a, 0x70, 0xff, 0xb5, 0x2c, 0xf9, 0x49, 0xce, 0x64, 0x90, 0xf5, 0xa5,
+	0xdc, 0x82, 0x5a, 0x16, 0x47, 0x2a, 0x7e, 0xc3, 0x97, 0x3f, 0xc9, 0x43, 0x68, 0x25, 0x74, 0x1a,
+	0x24, 0x98, 0xe8, 0xd8, 0xcd, 0x84, 0x4e, 0x8f, 0x31, 0x91, 0xd9, 0xa6, 0x34, 0x41, 0x55, 0x3a,
+	0xd7, 0x57, 0xbf, 0x17, 0xea, 0xdd, 0x58, 0xac, 0x77, 0x6f, 0x1b, 0x1e, 0x2c, 0xde, 0xa5, 0x4c,
+	0xb9, 0x77, 0x04, 0xef, 0xbe, 0x4a, 0x2f, 0xff, 0xdf, 0x2d, 0x7b, 0x1e, 0x6c, 0x2f, 0x07, 0xd1,
+	0xe1, 0x09, 0x6c, 0xbd, 0x40, 0x71, 0x2a, 0xa8, 0x28, 0x4c, 0xe4, 0xde, 0x1f, 0x36, 0xdc, 0x9b,
+	0x3b, 0xd4, 0xb5, 0x7f, 0x0c, 0xf5, 0x4b, 0x1c, 0xca, 0xc2, 0xd7, 0xf6, 0xda, 0xfb, 0x0f, 0xfb,
+	0x33, 0xf1, 0x1e, 0xb3, 0x34, 0x16, 0x2c, 0xd7, 0x70, 0x05, 0x22, 0x9f, 0x81, 0x2b, 0xc2, 0x2c,
+	0xc8, 0x58, 0x2e, 0xb8, 0x67, 0xdf, 0xce, 0x70, 0x44, 0x98, 0x9d, 0x48, 0x20, 0x79, 0x02, 0x8d,
+	0x28, 0xe6, 0xe7, 0xdc, 0xab, 0xdd, 0xce, 0x28, 0x51, 0xe4, 0x73, 0x70, 0xb3, 0x32, 0x1d, 0xe4,
+	0x5e, 0x7d, 0x99, 0xa2, 0x33, 0xd5, 0x94, 0x0a, 0x49, 0x9e, 0x01, 0x8c, 0x91, 0xe6, 0x62, 0x88,
+	0x54, 0x70, 0xaf, 0xa1, 0x78, 0xef, 0x55, 0xbc, 0xef, 0x8d, 0x4f, 0x33, 0xe7, 0xc0, 0xe4, 0x63,
+	0xa8, 0xff, 0xc2, 0x86, 0xdc, 0x6b, 0x2a, 0xd2, 0xfd, 0x8a, 0xf4, 0x03, 0x1b, 0x9a, 0xfc, 0x25,
+	0xa0, 0xf7, 0x7b, 0x0d, 0x3a, 0x0b, 0x77, 0x26, 0x9b, 0x60, 0xc7, 0x91, 0xee, 0x94, 0x1d, 0x47,
+	0x73, 0x53, 0x62, 0x2f, 0x4f, 0x89, 0xd2, 0x4d, 0x6d, 0x4e, 0x37, 0x73, 0xad, 0xae, 0x5f, 0x9b,
+	0xed, 0x33, 0x1a, 0x4f, 0xe2, 0x74, 0xa4, 0x04, 0xe5, 0xf8, 0xc6, 0x94, 0x5a, 0x3b, 0x9b, 0xd0,
+	0x2c, 0x93, 0xae, 0xa6, 0x72, 0xcd, 0x6c, 0xf9, 0xed, 0x8c, 0x16, 0x1c, 0x23, 0x35, 0x72, 0x8e,
+	0xaf, 0x2d, 0xf2, 0x14, 0x1e, 0x84, 0xb2, 0xd7, 0x61, 0x21, 0xe2, 0x0b, 0x0c, 0x64, 0xa8, 0x22,
+	0x47, 0xee, 0x39, 0xbb, 0xd6, 0x5e, 0xc7, 0xbf, 0x3f, 0xe7, 0xfb, 0x4e, 0xbb, 0x64, 0x31, 0x27,
+	0x94, 0x8b, 0x20, 0x1c, 0x63, 0x78, 0xee, 0xb9, 0xeb, 0xdf, 0x09, 0x89, 0x3e, 0x92, 0x60, 0x49,
+	0x4d, 0x71, 0x6a, 0xa8, 0xb0, 0x9e, 0x2a, 0xd1, 0x25, 0xf5, 0x05, 0xdc, 0x53, 0x5f, 0x4d, 0xe5,
+	0xf3, 0x20, 0x47, 0x3c, 0x66, 0xa9, 0xd7, 0x5e, 0x1b, 0x61, 0x4b, 0x92, 0x5e, 0xce, 0x71, 0x7a,
+	0x6f, 0x6d, 0xe8, 0x2c, 0x08, 0xc5, 0x0c, 0x8f, 0x55, 0x8d, 0xb8, 0xe9, 0x88, 0xbd, 0xba, 0x23,
+	0xb5, 0xc5, 0x8e, 0xcc, 0xcf, 0x78, 0x7d, 0xe9, 0x4d, 0xfd, 0x08, 0x36, 0x12, 0x4c, 0x58, 0x7e,
+	0x15, 0x14, 0xea, 0x39, 0x96, 0x2d, 0xab, 0xfb, 0xed, 0xf2, 0xec, 0x95, 0x3c, 0x22, 0x5f, 0x43,
+	0x47, 0x89, 0x0a, 0xa3, 0x80, 0xc7, 0x69, 0x88, 0x5e, 0x73, 0x6d, 0x56, 0x1b, 0x9a, 0x70, 0x2a,
+	0xf1, 0x4b, 0x0d, 0x69, 0xfd, 0x87, 0x86, 0xf4, 0x7e, 0xb3, 0xe1, 0x9d, 0x25, 0xf5, 0xcf, 0x92,
+	0xb7, 0x56, 0x27, 0x6f, 0x5f, 0x93, 0x23, 0xbb, 0xc0, 0x3c, 0x2a, 0x4a, 0xfd, 0x3a, 0xbe, 0x31,
+	0xc9, 0x97, 0xa0, 0x3e, 0x14, 0x28, 0x3d, 0xae, 0xff, 0x3b, 0x71, 0x24, 0xf8, 0x44, 0x6a, 0xf5,
+	0x0b, 0x70, 0x22, 0xa4, 0xd1, 0x24, 0x4e, 0xcb, 0x7a, 0xad, 0xe1, 0x19, 0xec, 0x6a, 0x89, 0x34,
+	0xef, 0x20, 0x91, 0xbf, 0x6d, 0x70, 0x67, 0xe3, 0xbd, 0xb2, 0x1e, 0x37, 0x8d, 0xf2, 0xcd, 0x22,
+	0xf1, 0xa0, 0x95, 0x17, 0x69, 0x6a, 0x6a, 0xe1, 0xf8, 0xc6, 0xbc, 0x65, 0xa0, 0x9f, 0x42, 0x3b,
+	0x2c, 0xf2, 0x1c, 0x53, 0x11, 0xe4, 0x85, 0x49, 0x65, 0x6b, 0xe1, 0x09, 0xf2, 0x8b, 0xd4, 0x07,
+	0x0d, 0xf2, 0x8b, 0x94, 0x3c, 0x06, 0x55, 0x47, 0x85, 0x6f, 0xdd, 0x80, 0x6f, 0x49, 0x84, 0x04,
+	0x1f, 0xc2, 0x26, 0x4e, 0x33, 0x0c, 0x85, 0x94, 0x9e, 0xa0, 0xb9, 0xf0, 0x9c, 0xb5, 0xd5, 0xea,
+	0x18, 0xc6, 0xa9, 0x24, 0xac, 0xae, 0xb9, 0x7b, 0x87, 0x9a, 0xff, 0x69, 0x41, 0xb3, 0xbc, 0x9f,
+	0xd4, 0xb3, 0xba, 0x0d, 0x46, 0x01, 0x15, 0x9e, 0xb5, 0x36, 0x98, 0xab, 0xd1, 0x87, 0x82, 0x1c,
+	0x40, 0xfb, 0x2c, 0x4e, 0x63, 0x3e, 0x2e, 0xb9, 0xf6, 0x5a, 0x2e, 0x18, 0xf8, 0xa1, 0x90, 0x0b,
+	0x85, 0xda, 0x2d, 0x30, 0xc2, 0x48, 0x8b, 0xb9, 0x3a, 0x20, 0x3d, 0xe8, 0x8c, 0x29, 0x0f, 0x70,
+	0x1a, 0x8b, 0x20, 0x64, 0x11, 0xea, 0x36, 0xb6, 0xc7, 0x94, 0x3f, 0x9f, 0xc6, 0xe2, 0x88, 0x45,
+	0x28, 0x77, 0x9b, 0xca, 0xdf, 0x50, 0xef, 0x89, 0x83, 0xda, 0xb9, 0xff, 0x8f, 0x0d, 0xce, 0x6b,
+	0xdd, 0x0a, 0x72, 0x00, 0xcd, 0x72, 0xd1, 0x21, 0x73, 0xff, 0x5f, 0x0b, 0xeb, 0x5d, 0xd7, 0xbb,
+	0xee, 0xd0, 0xff, 0xcb, 0xcf, 0x61, 0xa3, 0x3c, 0x39, 0x15, 0x39, 0xd2, 0xe4, 0x0e, 0x21, 0xf6,
+	0xac, 0x4f, 0x2c, 0x72, 0x0c, 0x1b, 0xf3, 0xfb, 0x07, 0xf9, 0xa0, 0x42, 0xaf, 0xd8, 0x91, 0xba,
+	0x1f, 0xde, 0xe4, 0xd6, 0xb7, 0x3a, 0x85, 0xcd, 0xc5, 0x8d, 0x83, 0x3c, 0xaa, 0x18, 0x2b, 0x17,
+	0x9a, 0xee, 0xee, 0xcd, 0x00, 0x1d, 0xf4, 0x5b, 0x70, 0x67, 0x7b, 0x09, 0xe9, 0x56, 0xf0, 0xe5,
+	0x0d, 0xa6, 0xbb, 0xb3, 0xd2, 0x57, 0x46, 0xf9, 0xe6, 0x0d, 0xec, 0x84, 0x2c, 0xe9, 0xe7, 0x34,
+	0x8d, 0x26, 0x74, 0xc8, 0xfb, 0x1c, 0xf3, 0x0b, 0xcc, 0x0d, 0xe1, 0xc4, 0x7a, 0xf3, 0x6c, 0x14,
+	0x8b, 0x71, 0x31, 0xec, 0x87, 0x2c, 0x19, 0x18, 0xd4, 0xa0, 0x44, 0x3d, 0x31, 0xb0, 0x41, 0xc2,
+	0xa2, 0x62, 0x82, 0x7c, 0x30, 0xa4, 0xe1, 0x39, 0xa6, 0xd1, 0x20, 0x1b, 0x1e, 0x64, 0xc3, 0x61,
+	0x53, 0xa9, 0xea, 0xd3, 0x7f, 0x07, 0x00, 0x27, 0x6e, 0x73, 0xcd, 0xdb, 0x0b, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// WatchdogClient is the client API for Watchdog service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type WatchdogClient interface {
+	// Notify sends a notification to a channel.
+	Notify(ctx context.Context, in *NotifyRequest, opts ...grpc.CallOption) (*NotifyResponse, error)
+	// NotifyStream sends many notifications over a single stream. A response is sent back for each notification, in
+	// the same order.
+	NotifyStream(ctx context.Context, opts ...grpc.CallOption) (Watchdog_NotifyStreamClient, error)
+	// WatchProcess adds a process to the watch list.
+	WatchProcess(ctx context.Context, in *WatchProcessRequest, opts ...grpc.CallOption) (*WatchProcessResponse, error)
+	// UnwatchProcess removes a process from the watch list.
+	UnwatchProcess(ctx context.Context, in *UnwatchProcessRequest, opts ...grpc.CallOption) (*UnwatchProcessResponse, error)
+	// GetStatus returns the state of all the monitors.
+	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error)
+}
+
+type watchdogClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewWatchdogClient(cc *grpc.ClientConn) WatchdogClient {
+	return &watchdogClient{cc}
+}
+
+func (c *watchdogClient) Notify(ctx context.Context, in *NotifyRequest, opts ...grpc.CallOption) (*NotifyResponse, error) {
+	out := new(NotifyResponse)
+	err := c.cc.Invoke(ctx, "/watchdog.Watchdog/Notify", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *watchdogClient) NotifyStream(ctx context.Context, opts ...grpc.CallOption) (Watchdog_NotifyStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Watchdog_serviceDesc.Streams[0], "/watchdog.Watchdog/NotifyStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &watchdogNotifyStreamClient{stream}
+	return x, nil
+}
+
+type Watchdog_NotifyStreamClient interface {
+	Send(*NotifyRequest) error
+	Recv() (*NotifyResponse, error)
+	grpc.ClientStream
+}
+
+type watchdogNotifyStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *watchdogNotifyStreamClient) Send(m *NotifyRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *watchdogNotifyStreamClient) Recv() (*NotifyResponse, error) {
+	m := new(NotifyResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *watchdogClient) WatchProcess(ctx context.Context, in *WatchProcessRequest, opts ...grpc.CallOption) (*WatchProcessResponse, error) {
+	out := new(WatchProcessResponse)
+	err := c.cc.Invoke(ctx, "/watchdog.Watchdog/WatchProcess", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *watchdogClient) UnwatchProcess(ctx context.Context, in *UnwatchProcessRequest, opts ...grpc.CallOption) (*UnwatchProcessResponse, error) {
+	out := new(UnwatchProcessResponse)
+	err := c.cc.Invoke(ctx, "/watchdog.Watchdog/UnwatchProcess", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *watchdogClient) GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error) {
+	out := new(GetStatusResponse)
+	err := c.cc.Invoke(ctx, "/watchdog.Watchdog/GetStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// WatchdogServer is the server API for Watchdog service.
+type WatchdogServer interface {
+	// Notify sends a notification to a channel.
+	Notify(context.Context, *NotifyRequest) (*NotifyResponse, error)
+	// NotifyStream sends many notifications over a single stream. A response is sent back for each notification, in
+	// the same order.
+	NotifyStream(Watchdog_NotifyStreamServer) error
+	// WatchProcess adds a process to the watch list.
+	WatchProcess(context.Context, *WatchProcessRequest) (*WatchProcessResponse, error)
+	// UnwatchProcess removes a process from the watch list.
+	UnwatchProcess(context.Context, *UnwatchProcessRequest) (*UnwatchProcessResponse, error)
+	// GetStatus returns the state of all the monitors.
+	GetStatus(context.Context, *GetStatusRequest) (*GetStatusResponse, error)
+}
+
+// UnimplementedWatchdogServer can be embedded to have forward compatible implementations.
+type UnimplementedWatchdogServer struct {
+}
+
+func (*UnimplementedWatchdogServer) Notify(ctx context.Context, req *NotifyRequest) (*NotifyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Notify not implemented")
+}
+func (*UnimplementedWatchdogServer) NotifyStream(srv Watchdog_NotifyStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method NotifyStream not implemented")
+}
+func (*UnimplementedWatchdogServer) WatchProcess(ctx context.Context, req *WatchProcessRequest) (*WatchProcessResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WatchProcess not implemented")
+}
+func (*UnimplementedWatchdogServer) UnwatchProcess(ctx context.Context, req *UnwatchProcessRequest) (*UnwatchProcessResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnwatchProcess not implemented")
+}
+func (*UnimplementedWatchdogServer) GetStatus(ctx context.Context, req *GetStatusRequest) (*GetStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+
+func RegisterWatchdogServer(s *grpc.Server, srv WatchdogServer) {
+	s.RegisterService(&_Watchdog_serviceDesc, srv)
+}
+
+func _Watchdog_Notify_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NotifyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WatchdogServer).Notify(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/watchdog.Watchdog/Notify",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WatchdogServer).Notify(ctx, req.(*NotifyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Watchdog_NotifyStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(WatchdogServer).NotifyStream(&watchdogNotifyStreamServer{stream})
+}
+
+type Watchdog_NotifyStreamServer interface {
+	Send(*NotifyResponse) error
+	Recv() (*NotifyRequest, error)
+	grpc.ServerStream
+}
+
+type watchdogNotifyStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *watchdogNotifyStreamServer) Send(m *NotifyResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *watchdogNotifyStreamServer) Recv() (*NotifyRequest, error) {
+	m := new(NotifyRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Watchdog_WatchProcess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WatchProcessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WatchdogServer).WatchProcess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/watchdog.Watchdog/WatchProcess",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WatchdogServer).WatchProcess(ctx, req.(*WatchProcessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Watchdog_UnwatchProcess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnwatchProcessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WatchdogServer).UnwatchProcess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/watchdog.Watchdog/UnwatchProcess",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WatchdogServer).UnwatchProcess(ctx, req.(*UnwatchProcessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Watchdog_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WatchdogServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/watchdog.Watchdog/GetStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WatchdogServer).GetStatus(ctx, req.(*GetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Watchdog_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "watchdog.Watchdog",
+	HandlerType: (*WatchdogServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Notify",
+			Handler:    _Watchdog_Notify_Handler,
+		},
+		{
+			MethodName: "WatchProcess",
+			Handler:    _Watchdog_WatchProcess_Handler,
+		},
+		{
+			MethodName: "UnwatchProcess",
+			Handler:    _Watchdog_UnwatchProcess_Handler,
+		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _Watchdog_GetStatus_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "NotifyStream",
+			Handler:       _Watchdog_NotifyStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "watchdog.proto",
+}
@@ -1,15 +1,20 @@
 package freediskspacechecker
 
 import (
+	"errors"
 	"hash/fnv"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	rp "github.com/randlabs/rundown-protection"
 	"github.com/randlabs/server-watchdog/console"
+	"github.com/randlabs/server-watchdog/modules/alerts"
 	"github.com/randlabs/server-watchdog/modules/logger"
 	"github.com/randlabs/server-watchdog/settings"
+	"github.com/randlabs/server-watchdog/utils/statetracker"
+	"github.com/randlabs/server-watchdog/utils/timeutils"
 	"github.com/ricochet2200/go-disk-usage/du"
 )
 
@@ -17,21 +22,57 @@ import (
 
 type Module struct {
 	shutdownSignal chan struct{}
-	devicesList    []DeviceItem
+	devicesListMtx sync.Mutex
+	devicesList    []*DeviceItem
 	r              rp.RundownProtection
 	checkDone      chan struct{}
+	listChanged    chan struct{}
+	saveStateMtx   sync.Mutex //serializes the writes to the state file
 }
 
 type DeviceItem struct {
-	HashCode         uint64
-	Device           string
-	Channel          string
-	Severity         string
-	MinimumFreeSpace uint64
-	CheckPeriod      time.Duration
-	NextCheckPeriod  time.Duration
-	LastCheckStatus  int32
-	CheckInProgress  int32
+	//the fields accessed atomically go first to keep them 64-bit aligned on 32-bit platforms
+	LastFreeSpace        uint64
+	LastCheckTime        int64
+	NextCheckTime        int64
+	LastNotificationTime int64
+	HashCode             uint64 //used as id so it is kept when the settings are updated
+	ConfigHash           uint64
+	Device               string
+	Channel              string
+	Severity             string
+	MinimumFreeSpace     uint64
+	CheckPeriod          time.Duration
+	NextCheckPeriod      time.Duration
+	LastCheckStatus      int32
+	CheckInProgress      int32
+	ConsecutiveFailures  uint32
+	Paused               int32
+	State                *statetracker.Tracker
+	Config               *settings.SettingsJSON_FreeDiskSpace //only set on devices added at runtime
+}
+
+type DeviceStatus struct {
+	Id                  string              `json:"id"`
+	Source              string              `json:"source"`
+	Config              DeviceStatus_Config `json:"config"`
+	FreeSpace           uint64              `json:"freeSpace"`
+	Ok                  bool                `json:"ok"`
+	Failing             bool                `json:"failing"`
+	Flapping            bool                `json:"flapping"`
+	Paused              bool                `json:"paused"`
+	LastCheck           *time.Time          `json:"lastCheck,omitempty"`
+	NextCheck           *time.Time          `json:"nextCheck,omitempty"`
+	ConsecutiveFailures uint32              `json:"consecutiveFailures"`
+	LastNotification    *time.Time          `json:"lastNotification,omitempty"`
+}
+
+type DeviceStatus_Config struct {
+	Device           string `json:"device"`
+	MinimumFreeSpace uint64 `json:"minimumSpace"`
+	CheckPeriod      string `json:"checkPeriod"`
+	Channel          string `json:"channel"`
+	Severity         string `json:"severity"`
 }
 
 //------------------------------------------------------------------------------
@@ -39,6 +80,12 @@ type DeviceItem struct {
 var module *Module
 var lock sync.RWMutex
 
+var ErrNotFound = errors.New("Device not found")
+var ErrAlreadyExists = errors.New("A device with the same settings already exists")
+var ErrReadOnly = errors.New("Devices defined in the settings file cannot be modified or deleted")
+var ErrShuttingDown = errors.New("Module is shutting down")
+var ErrNotActive = errors.New("Module is not active")
+
 //------------------------------------------------------------------------------
 
 func Start() error {
@@ -48,27 +95,13 @@ func Start() error {
 	module.r.Initialize()
 
 	//build devices list from settings
-	module.devicesList = make([]DeviceItem, len(settings.Config.FreeDiskSpace))
-	for idx, fds := range settings.Config.FreeDiskSpace {
-		h := fnv.New64a()
-		h.Sum([]byte(fds.Device))
-		h.Sum([]byte(fds.Channel))
-		h.Sum([]byte(fds.Severity))
-
-		module.devicesList[idx] = DeviceItem{
-			h.Sum64(),
-			fds.Device,
-			fds.Channel,
-			fds.Severity,
-			fds.MinimumSpaceX,
-			fds.CheckPeriodX,
-			0,
-			1,
-			0,
-		}
+	module.devicesList = make([]*DeviceItem, len(settings.Config.FreeDiskSpace))
+	for idx := range settings.Config.FreeDiskSpace {
+		module.devicesList[idx] = newDeviceItem(&settings.Config.FreeDiskSpace[idx])
 	}
 
 	module.checkDone = make(chan struct{})
+	module.listChanged = make(chan struct{}, 1)
 
 	//load stored state
 	err := module.loadState()
@@ -109,53 +142,52 @@ func Run(wg sync.WaitGroup) {
 
 		if localModule.r.Acquire() {
 			go func() {
-				if len(localModule.devicesList) > 0 {
-					var timeToWait time.Duration
-
-					loop := true
-					for loop {
-						var start time.Time
-						var elapsed time.Duration
-
-						//find next device to check
-						timeToWait = -1
-						for i := len(localModule.devicesList); i > 0; i-- {
-							if atomic.LoadInt32(&localModule.devicesList[i-1].CheckInProgress) == 0 {
-								if timeToWait < 0 || timeToWait > localModule.devicesList[i-1].NextCheckPeriod {
-									timeToWait = localModule.devicesList[i-1].NextCheckPeriod
-								}
-							}
-						}
+				var timeToWait time.Duration
 
-						start = time.Now()
-						if timeToWait >= 0 {
-							select {
-							case <-localModule.shutdownSignal:
-								loop = false
+				loop := true
+				for loop {
+					var start time.Time
+					var elapsed time.Duration
 
-							case <-time.After(timeToWait):
-								//check devices when the time to wait elapses
-								localModule.checkDevices(timeToWait)
+					//find next device to check
+					timeToWait = localModule.getTimeToWait()
 
-							case <-localModule.checkDone:
-								//if a device check has finished, check again
-								elapsed = time.Since(start)
-								localModule.checkDevices(elapsed)
-							}
-						} else {
-							select {
-							case <-localModule.shutdownSignal:
-								loop = false
-
-							case <-localModule.checkDone:
-								//if a device check has finished, check for others
-								elapsed = time.Since(start)
-								localModule.checkDevices(elapsed)
-							}
+					start = time.Now()
+					if timeToWait >= 0 {
+						select {
+						case <-localModule.shutdownSignal:
+							loop = false
+
+						case <-time.After(timeToWait):
+							//check devices when the time to wait elapses
+							localModule.checkDevices(timeToWait)
+
+						case <-localModule.checkDone:
+							//if a device check has finished, check again
+							elapsed = time.Since(start)
+							localModule.checkDevices(elapsed)
+
+						case <-localModule.listChanged:
+							//if a device was added, modified or resumed, check again
+							elapsed = time.Since(start)
+							localModule.checkDevices(elapsed)
+						}
+					} else {
+						select {
+						case <-localModule.shutdownSignal:
+							loop = false
+
+						case <-localModule.checkDone:
+							//if a device check has finished, check for others
+							elapsed = time.Since(start)
+							localModule.checkDevices(elapsed)
+
+						case <-localModule.listChanged:
+							//if a device was added, modified or resumed, check again
+							elapsed = time.Since(start)
+							localModule.checkDevices(elapsed)
 						}
 					}
-				} else {
-					<-localModule.shutdownSignal
 				}
 
 				localModule.r.Release()
@@ -170,16 +202,328 @@ func Run(wg sync.WaitGroup) {
 	return
 }
 
+// GetStatus returns the current status of the monitored devices
+func GetStatus() []DeviceStatus {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return make([]DeviceStatus, 0)
+	}
+
+	localModule.devicesListMtx.Lock()
+	defer localModule.devicesListMtx.Unlock()
+
+	list := make([]DeviceStatus, len(localModule.devicesList))
+	for idx, dev := range localModule.devicesList {
+		list[idx] = DeviceStatus{
+			Id:     strconv.FormatUint(dev.HashCode, 16),
+			Source: sourceString(dev.Config),
+			Config: DeviceStatus_Config{
+				Device:           dev.Device,
+				MinimumFreeSpace: dev.MinimumFreeSpace,
+				CheckPeriod:      dev.CheckPeriod.String(),
+				Channel:          dev.Channel,
+				Severity:         dev.Severity,
+			},
+			FreeSpace:           atomic.LoadUint64(&dev.LastFreeSpace),
+			Ok:                  atomic.LoadInt32(&dev.LastCheckStatus) != 0,
+			Failing:             !dev.State.IsUp(),
+			Flapping:            dev.State.IsFlapping(),
+			Paused:              atomic.LoadInt32(&dev.Paused) != 0,
+			LastCheck:           timeutils.FromUnixNano(atomic.LoadInt64(&dev.LastCheckTime)),
+			NextCheck:           timeutils.FromUnixNano(atomic.LoadInt64(&dev.NextCheckTime)),
+			ConsecutiveFailures: atomic.LoadUint32(&dev.ConsecutiveFailures),
+			LastNotification:    timeutils.FromUnixNano(atomic.LoadInt64(&dev.LastNotificationTime)),
+		}
+	}
+
+	return list
+}
+
+// AddDevice starts monitoring the free space of a new device and returns its id. The device is stored and restored
+// on restart.
+func AddDevice(fds settings.SettingsJSON_FreeDiskSpace) (string, error) {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return "", ErrNotActive
+	}
+
+	err := settings.ValidateFreeDiskSpace(&fds)
+	if err != nil {
+		return "", err
+	}
+
+	id := ""
+	if localModule.r.Acquire() {
+		item := newDeviceItem(&fds)
+		item.Config = &fds
+
+		localModule.devicesListMtx.Lock()
+		if !localModule.isDuplicateDevice(item, -1) {
+			localModule.devicesList = append(localModule.devicesList, item)
+			id = strconv.FormatUint(item.HashCode, 16)
+		} else {
+			err = ErrAlreadyExists
+		}
+		localModule.devicesListMtx.Unlock()
+
+		if err == nil {
+			localModule.runSaveState()
+			localModule.signalListChanged()
+		}
+
+		localModule.r.Release()
+	} else {
+		err = ErrShuttingDown
+	}
+
+	return id, err
+}
+
+// UpdateDevice replaces the settings of a device added at runtime. The id is kept.
+func UpdateDevice(id string, fds settings.SettingsJSON_FreeDiskSpace) error {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return ErrNotActive
+	}
+
+	err := settings.ValidateFreeDiskSpace(&fds)
+	if err != nil {
+		return err
+	}
+
+	if localModule.r.Acquire() {
+		localModule.devicesListMtx.Lock()
+		idx := localModule.findDeviceById(id)
+		if idx >= 0 {
+			oldItem := localModule.devicesList[idx]
+			if oldItem.Config != nil {
+				item := newDeviceItem(&fds)
+				item.HashCode = oldItem.HashCode
+				item.Paused = atomic.LoadInt32(&oldItem.Paused)
+				item.Config = &fds
+
+				if !localModule.isDuplicateDevice(item, idx) {
+					localModule.devicesList[idx] = item
+				} else {
+					err = ErrAlreadyExists
+				}
+			} else {
+				err = ErrReadOnly
+			}
+		} else {
+			err = ErrNotFound
+		}
+		localModule.devicesListMtx.Unlock()
+
+		if err == nil {
+			localModule.runSaveState()
+			localModule.signalListChanged()
+		}
+
+		localModule.r.Release()
+	} else {
+		err = ErrShuttingDown
+	}
+
+	return err
+}
+
+// RemoveDevice stops monitoring a device added at runtime
+func RemoveDevice(id string) error {
+	var hashCode uint64
+	var err error
+
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return ErrNotActive
+	}
+
+	if localModule.r.Acquire() {
+		localModule.devicesListMtx.Lock()
+		idx := localModule.findDeviceById(id)
+		if idx >= 0 {
+			if localModule.devicesList[idx].Config != nil {
+				listLen := len(localModule.devicesList)
+
+				hashCode = localModule.devicesList[idx].HashCode
+
+				copy(localModule.devicesList[idx:], localModule.devicesList[idx + 1:])
+				localModule.devicesList[listLen - 1] = nil
+				localModule.devicesList = localModule.devicesList[:(listLen - 1)]
+			} else {
+				err = ErrReadOnly
+			}
+		} else {
+			err = ErrNotFound
+		}
+		localModule.devicesListMtx.Unlock()
+
+		if err == nil {
+			alerts.Resolve(hashCode)
+			localModule.runSaveState()
+		}
+
+		localModule.r.Release()
+	} else {
+		err = ErrShuttingDown
+	}
+
+	return err
+}
+
+// PauseDevice suspends or resumes the checks of a device. Devices defined in the settings file can also be paused.
+func PauseDevice(id string, pause bool) error {
+	var err error
+
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return ErrNotActive
+	}
+
+	if localModule.r.Acquire() {
+		changed := false
+
+		localModule.devicesListMtx.Lock()
+		idx := localModule.findDeviceById(id)
+		if idx >= 0 {
+			dev := localModule.devicesList[idx]
+
+			if pause {
+				changed = atomic.CompareAndSwapInt32(&dev.Paused, 0, 1)
+				if changed {
+					atomic.StoreInt64(&dev.NextCheckTime, 0)
+				}
+			} else {
+				changed = atomic.CompareAndSwapInt32(&dev.Paused, 1, 0)
+				if changed {
+					//check it as soon as possible
+					dev.NextCheckPeriod = 0
+				}
+			}
+		} else {
+			err = ErrNotFound
+		}
+		localModule.devicesListMtx.Unlock()
+
+		if changed {
+			localModule.runSaveState()
+			localModule.signalListChanged()
+		}
+
+		localModule.r.Release()
+	} else {
+		err = ErrShuttingDown
+	}
+
+	return err
+}
+
 //------------------------------------------------------------------------------
 
+func newDeviceItem(fds *settings.SettingsJSON_FreeDiskSpace) *DeviceItem {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(fds.Device))
+	_, _ = h.Write([]byte(fds.Channel))
+	_, _ = h.Write([]byte(fds.Severity))
+
+	return &DeviceItem{
+		HashCode:         h.Sum64(),
+		ConfigHash:       h.Sum64(),
+		Device:           fds.Device,
+		Channel:          fds.Channel,
+		Severity:         fds.Severity,
+		MinimumFreeSpace: fds.MinimumSpaceX,
+		CheckPeriod:      fds.CheckPeriodX,
+		LastCheckStatus:  1,
+		State:            statetracker.New(fds.FailureThreshold, fds.SuccessThreshold, fds.FlapThreshold,
+		                                   fds.FlapWindowX),
+	}
+}
+
+// Must be called with the list lock held. Ids are kept on updates so an item can have the id of other settings.
+func (m *Module) isDuplicateDevice(item *DeviceItem, skipIdx int) bool {
+	for idx, dev := range m.devicesList {
+		if idx != skipIdx && (dev.HashCode == item.HashCode || dev.ConfigHash == item.ConfigHash) {
+			return true
+		}
+	}
+	return false
+}
+
+// Must be called with the list lock held
+func (m *Module) findDevice(hashCode uint64) int {
+	for idx, dev := range m.devicesList {
+		if dev.HashCode == hashCode {
+			return idx
+		}
+	}
+	return -1
+}
+
+// Must be called with the list lock held
+func (m *Module) findDeviceById(id string) int {
+	hashCode, err := strconv.ParseUint(id, 16, 64)
+	if err != nil {
+		return -1
+	}
+	return m.findDevice(hashCode)
+}
+
+func (m *Module) signalListChanged() {
+	select {
+	case m.listChanged <- struct{}{}:
+	default:
+	}
+	return
+}
+
+func (m *Module) getTimeToWait() time.Duration {
+	timeToWait := time.Duration(-1)
+
+	m.devicesListMtx.Lock()
+	for _, dev := range m.devicesList {
+		if atomic.LoadInt32(&dev.CheckInProgress) == 0 && atomic.LoadInt32(&dev.Paused) == 0 {
+			if timeToWait < 0 || timeToWait > dev.NextCheckPeriod {
+				timeToWait = dev.NextCheckPeriod
+			}
+		}
+	}
+	m.devicesListMtx.Unlock()
+
+	return timeToWait
+}
+
 func (m *Module) checkDevices(elapsedTime time.Duration) {
+	m.devicesListMtx.Lock()
+	defer m.devicesListMtx.Unlock()
+
 	for idx := len(m.devicesList); idx > 0; idx-- {
-		dev := &m.devicesList[idx - 1]
+		dev := m.devicesList[idx - 1]
+
+		if atomic.LoadInt32(&dev.Paused) != 0 {
+			continue
+		}
 
 		if atomic.CompareAndSwapInt32(&dev.CheckInProgress, 0, 1) {
 			if elapsedTime >= dev.NextCheckPeriod {
 				//reset timer
 				dev.NextCheckPeriod = dev.CheckPeriod
+				atomic.StoreInt64(&dev.NextCheckTime, time.Now().Add(dev.CheckPeriod).UnixNano())
 
 				//check this device
 				if m.r.Acquire() {
@@ -187,29 +531,47 @@ func (m *Module) checkDevices(elapsedTime time.Duration) {
 						var newStatus int32
 
 						usage := du.NewDiskUsage(dev.Device)
+						freeSpace := usage.Free()
+						atomic.StoreUint64(&dev.LastFreeSpace, freeSpace)
 
-						if usage.Free() >= dev.MinimumFreeSpace {
+						if freeSpace >= dev.MinimumFreeSpace {
 							newStatus = 1
 						} else {
 							newStatus = 0
 						}
 
-						oldStatus := atomic.SwapInt32(&dev.LastCheckStatus, newStatus)
-						if oldStatus != newStatus {
-							m.runSaveState()
+						atomic.StoreInt64(&dev.LastCheckTime, time.Now().UnixNano())
+						if newStatus == 1 {
+							atomic.StoreUint32(&dev.ConsecutiveFailures, 0)
+						} else {
+							atomic.AddUint32(&dev.ConsecutiveFailures, 1)
 						}
 
-						//notify only if status changed from true to false
-						if oldStatus == 1 && newStatus == 0 {
-							if m.r.Acquire() {
-								go func(dev *DeviceItem) {
-									_ = logger.Log(dev.Severity, dev.Channel, "Disk space on '%s' is low.",
-										dev.Device)
+						atomic.StoreInt32(&dev.LastCheckStatus, newStatus)
+
+						//notify only if the confirmed status changed from up to down or on flapping changes
+						ev := dev.State.Update(newStatus == 1)
+						switch ev {
+						case statetracker.EventDown:
+							m.runAlert(dev, "Disk space on '%s' is low.", dev.Device)
+
+						case statetracker.EventUp:
+							alerts.Resolve(dev.HashCode)
 
-									m.r.Release()
-								}(dev)
+						case statetracker.EventFlappingStarted:
+							m.runNotify(dev, dev.Severity, "Disk space on '%s' is flapping.", dev.Device)
+
+						case statetracker.EventFlappingStopped:
+							if dev.State.IsUp() {
+								alerts.Resolve(dev.HashCode)
+								m.runNotify(dev, "info", "Disk space on '%s' stopped flapping and is ok.", dev.Device)
+							} else {
+								m.runAlert(dev, "Disk space on '%s' stopped flapping and is low.", dev.Device)
 							}
 						}
+						if ev != statetracker.EventNone {
+							m.runSaveState()
+						}
 
 						atomic.StoreInt32(&dev.CheckInProgress, 0)
 
@@ -234,10 +596,38 @@ func (m *Module) checkDevices(elapsedTime time.Duration) {
 	return
 }
 
+func sourceString(cfg *settings.SettingsJSON_FreeDiskSpace) string {
+	if cfg != nil {
+		return "api"
+	}
+	return "settings"
+}
+
+func (m *Module) runAlert(dev *DeviceItem, format string, a ...interface{}) {
+	id := alerts.Open("freeDiskSpace", dev.HashCode, dev.Device, dev.Channel, dev.Severity, format, a...)
+	m.runNotify(dev, dev.Severity, format + " [alert %v]", append(a, id)...)
+	return
+}
+
+func (m *Module) runNotify(dev *DeviceItem, severity string, format string, a ...interface{}) {
+	if m.r.Acquire() {
+		go func(dev *DeviceItem) {
+			atomic.StoreInt64(&dev.LastNotificationTime, time.Now().UnixNano())
+
+			_ = logger.LogTarget("freeDiskSpace", dev.Device, severity, dev.Channel, format, a...)
+
+			m.r.Release()
+		}(dev)
+	}
+	return
+}
+
 func (m *Module) runSaveState() {
 	if m.r.Acquire() {
 		go func(m *Module) {
+			m.saveStateMtx.Lock()
 			err := m.saveState()
+			m.saveStateMtx.Unlock()
 
 			if err != nil {
 				console.Error("Unable to save free disk space checker state. [%v]", err)
@@ -1,8 +1,11 @@
 package freediskspacechecker
 
 import (
+	"encoding/json"
 	"sync/atomic"
 
+	"github.com/randlabs/server-watchdog/console"
+	"github.com/randlabs/server-watchdog/settings"
 	"github.com/randlabs/server-watchdog/utils/state"
 	"github.com/vmihailenco/msgpack/v4"
 )
@@ -12,6 +15,8 @@ import (
 type FreeDiskSpaceCheckerStateItem struct {
 	HashCode         uint64
 	LastCheckStatus  bool
+	Paused           bool
+	Config           []byte //json encoded settings of devices added at runtime
 }
 
 //------------------------------------------------------------------------------
@@ -29,9 +34,16 @@ func (m *Module) loadState() error {
 
 		err = msgpack.Unmarshal(b, &loadedItems)
 		if err == nil {
-			for idx := range m.devicesList {
-				dev := &m.devicesList[idx]
+			m.migrateLegacyState(loadedItems)
 
+			//restore devices added at runtime
+			for _, v := range loadedItems {
+				if len(v.Config) > 0 {
+					m.restoreDevice(v)
+				}
+			}
+
+			for _, dev := range m.devicesList {
 				for _, v := range loadedItems {
 					if dev.HashCode == v.HashCode {
 						if v.LastCheckStatus {
@@ -39,6 +51,10 @@ func (m *Module) loadState() error {
 						} else {
 							atomic.StoreInt32(&dev.LastCheckStatus, 0)
 						}
+						dev.State.SetUp(v.LastCheckStatus)
+						if v.Paused {
+							atomic.StoreInt32(&dev.Paused, 1)
+						}
 						break
 					}
 				}
@@ -49,24 +65,75 @@ func (m *Module) loadState() error {
 	return err
 }
 
+// migrateLegacyState sets the current hash codes to a state saved by a version prior to the status API. Those
+// versions stored the same hash code for every device, in the order they are defined in the settings file, so they are
+// matched by position.
+func (m *Module) migrateLegacyState(loadedItems []FreeDiskSpaceCheckerStateItem) {
+	hashCodes := make([]uint64, len(loadedItems))
+	for idx := range loadedItems {
+		hashCodes[idx] = loadedItems[idx].HashCode
+	}
+	if !state.IsLegacyHashCode(hashCodes) {
+		return
+	}
+
+	for idx := range loadedItems {
+		if idx < len(m.devicesList) {
+			loadedItems[idx].HashCode = m.devicesList[idx].HashCode
+		}
+	}
+	return
+}
+
 func (m *Module) saveState() error {
+	var err error
+
+	m.devicesListMtx.Lock()
 	toSave := make([]FreeDiskSpaceCheckerStateItem, len(m.devicesList))
 	for idx, v := range m.devicesList {
-		status := false
-		if atomic.LoadInt32(&v.LastCheckStatus) != 0 {
-			status = true
-		}
-
 		toSave[idx] = FreeDiskSpaceCheckerStateItem{
 			HashCode        : v.HashCode,
-			LastCheckStatus : status,
+			LastCheckStatus : v.State.IsUp(),
+			Paused          : atomic.LoadInt32(&v.Paused) != 0,
+		}
+		if v.Config != nil && err == nil {
+			toSave[idx].Config, err = json.Marshal(v.Config)
 		}
 	}
+	m.devicesListMtx.Unlock()
 
-	b, err := msgpack.Marshal(toSave)
+	var b []byte
+	if err == nil {
+		b, err = msgpack.Marshal(toSave)
+	}
 	if err == nil {
 		err = state.SaveStateBlob(freeDiskSpaceCheckerStateFileName, b)
 	}
 
 	return err
 }
+
+func (m *Module) restoreDevice(v FreeDiskSpaceCheckerStateItem) {
+	var fds settings.SettingsJSON_FreeDiskSpace
+
+	err := json.Unmarshal(v.Config, &fds)
+	if err == nil {
+		err = settings.ValidateFreeDiskSpace(&fds)
+	}
+	if err != nil {
+		console.Error("Unable to restore device added at runtime. [%v]", err)
+		return
+	}
+
+	item := newDeviceItem(&fds)
+	item.HashCode = v.HashCode
+	item.Config = &fds
+
+	if m.isDuplicateDevice(item, -1) {
+		console.Error("Unable to restore device '%v' added at runtime. [%v]", fds.Device, ErrAlreadyExists)
+		return
+	}
+
+	m.devicesList = append(m.devicesList, item)
+	return
+}
@@ -0,0 +1,325 @@
+package heartbeats
+
+import (
+	"errors"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	rp "github.com/randlabs/rundown-protection"
+	"github.com/randlabs/server-watchdog/console"
+	"github.com/randlabs/server-watchdog/modules/alerts"
+	"github.com/randlabs/server-watchdog/modules/logger"
+	"github.com/randlabs/server-watchdog/settings"
+	"github.com/randlabs/server-watchdog/utils/timeutils"
+)
+
+//------------------------------------------------------------------------------
+
+type Module struct {
+	shutdownSignal chan struct{}
+	heartbeatsList []*HeartbeatItem
+	transitionMtx  sync.Mutex
+	r              rp.RundownProtection
+	pingReceived   chan struct{}
+	saveStateMtx   sync.Mutex //serializes the writes to the state file
+}
+
+type HeartbeatItem struct {
+	//the fields accessed atomically go first to keep them 64-bit aligned on 32-bit platforms
+	LastPingTime         int64
+	LastNotificationTime int64
+	HashCode             uint64
+	Name                 string
+	Interval             time.Duration
+	Grace                time.Duration
+	Channel              string
+	Severity             string
+	Overdue              int32
+}
+
+type HeartbeatStatus struct {
+	Id               string                 `json:"id"`
+	Config           HeartbeatStatus_Config `json:"config"`
+	Overdue          bool                   `json:"overdue"`
+	LastPing         *time.Time             `json:"lastPing,omitempty"`
+	Deadline         *time.Time             `json:"deadline,omitempty"`
+	LastNotification *time.Time             `json:"lastNotification,omitempty"`
+}
+
+type HeartbeatStatus_Config struct {
+	Name     string `json:"name"`
+	Interval string `json:"interval"`
+	Grace    string `json:"grace"`
+	Channel  string `json:"channel"`
+	Severity string `json:"severity"`
+}
+
+//------------------------------------------------------------------------------
+
+var module *Module
+var lock sync.RWMutex
+
+var ErrNotFound = errors.New("Heartbeat not found")
+
+//------------------------------------------------------------------------------
+
+func Start() error {
+	//initialize module
+	module = &Module{}
+	module.shutdownSignal = make(chan struct{})
+	module.r.Initialize()
+
+	//build heartbeats list from settings. until a ping arrives, consider the start time as the last one so new
+	//heartbeats are not reported as overdue right away.
+	now := time.Now().UnixNano()
+	module.heartbeatsList = make([]*HeartbeatItem, len(settings.Config.Heartbeats))
+	for idx := range settings.Config.Heartbeats {
+		hb := &settings.Config.Heartbeats[idx]
+
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(hb.Name))
+
+		module.heartbeatsList[idx] = &HeartbeatItem{
+			HashCode:     h.Sum64(),
+			Name:         hb.Name,
+			Interval:     hb.IntervalX,
+			Grace:        hb.GraceX,
+			Channel:      hb.Channel,
+			Severity:     hb.Severity,
+			LastPingTime: now,
+		}
+	}
+
+	module.pingReceived = make(chan struct{}, 1)
+
+	//load stored state
+	err := module.loadState()
+	if err != nil {
+		console.Error("Unable to load heartbeats state. [%v]", err)
+		return err
+	}
+
+	return nil
+}
+
+func Stop() {
+	lock.Lock()
+	localModule := module
+	module = nil
+	lock.Unlock()
+
+	if localModule != nil {
+		//signal shutdown
+		close(localModule.shutdownSignal)
+
+		//wait until all workers are done
+		localModule.r.Wait()
+	}
+	return
+}
+
+func Run(wg *sync.WaitGroup) {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule != nil && len(localModule.heartbeatsList) > 0 {
+		//start background loop
+		wg.Add(1)
+
+		if localModule.r.Acquire() {
+			go func() {
+				loop := true
+				for loop {
+					//wait until the next heartbeat becomes overdue
+					timeToWait := localModule.getTimeToWait()
+
+					if timeToWait >= 0 {
+						select {
+						case <-localModule.shutdownSignal:
+							loop = false
+
+						case <-time.After(timeToWait):
+							localModule.checkHeartbeats()
+
+						case <-localModule.pingReceived:
+							//a ping moves the deadline so calculate the time to wait again
+						}
+					} else {
+						select {
+						case <-localModule.shutdownSignal:
+							loop = false
+
+						case <-localModule.pingReceived:
+						}
+					}
+				}
+
+				localModule.r.Release()
+
+				wg.Done()
+			}()
+		} else {
+			wg.Done()
+		}
+	}
+	return
+}
+
+// Ping records a heartbeat. If the heartbeat was overdue, a recovery notification is sent.
+func Ping(name string) error {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return errors.New("Module is not active")
+	}
+
+	hb := localModule.findHeartbeat(name)
+	if hb == nil {
+		return ErrNotFound
+	}
+
+	if localModule.r.Acquire() {
+		localModule.transitionMtx.Lock()
+		atomic.StoreInt64(&hb.LastPingTime, time.Now().UnixNano())
+		if atomic.CompareAndSwapInt32(&hb.Overdue, 1, 0) {
+			alerts.Resolve(hb.HashCode)
+			localModule.runNotify(hb, "info", "Heartbeat '%s' recovered.", hb.Name)
+		}
+		localModule.transitionMtx.Unlock()
+
+		select {
+		case localModule.pingReceived <- struct{}{}:
+		default:
+		}
+
+		localModule.runSaveState()
+
+		localModule.r.Release()
+	}
+
+	return nil
+}
+
+// GetStatus returns the state of all the heartbeats
+func GetStatus() []HeartbeatStatus {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return make([]HeartbeatStatus, 0)
+	}
+
+	list := make([]HeartbeatStatus, len(localModule.heartbeatsList))
+	for idx, hb := range localModule.heartbeatsList {
+		lastPing := atomic.LoadInt64(&hb.LastPingTime)
+
+		list[idx] = HeartbeatStatus{
+			Id: strconv.FormatUint(hb.HashCode, 16),
+			Config: HeartbeatStatus_Config{
+				Name:     hb.Name,
+				Interval: hb.Interval.String(),
+				Grace:    hb.Grace.String(),
+				Channel:  hb.Channel,
+				Severity: hb.Severity,
+			},
+			Overdue:          atomic.LoadInt32(&hb.Overdue) != 0,
+			LastPing:         timeutils.FromUnixNano(lastPing),
+			Deadline:         timeutils.FromUnixNano(lastPing + int64(hb.Interval + hb.Grace)),
+			LastNotification: timeutils.FromUnixNano(atomic.LoadInt64(&hb.LastNotificationTime)),
+		}
+	}
+
+	return list
+}
+
+//------------------------------------------------------------------------------
+
+func (m *Module) findHeartbeat(name string) *HeartbeatItem {
+	for _, hb := range m.heartbeatsList {
+		if hb.Name == name {
+			return hb
+		}
+	}
+	return nil
+}
+
+func (m *Module) getTimeToWait() time.Duration {
+	timeToWait := time.Duration(-1)
+
+	now := time.Now().UnixNano()
+	for _, hb := range m.heartbeatsList {
+		if atomic.LoadInt32(&hb.Overdue) == 0 {
+			d := time.Duration(atomic.LoadInt64(&hb.LastPingTime) + int64(hb.Interval + hb.Grace) - now)
+			if d < 0 {
+				d = 0
+			}
+			if timeToWait < 0 || d < timeToWait {
+				timeToWait = d
+			}
+		}
+	}
+
+	return timeToWait
+}
+
+func (m *Module) checkHeartbeats() {
+	now := time.Now().UnixNano()
+
+	//the lock avoids flagging a heartbeat as overdue while a ping is being processed
+	m.transitionMtx.Lock()
+	for _, hb := range m.heartbeatsList {
+		lastPing := atomic.LoadInt64(&hb.LastPingTime)
+
+		if now >= lastPing + int64(hb.Interval + hb.Grace) && atomic.CompareAndSwapInt32(&hb.Overdue, 0, 1) {
+			m.runAlert(hb, "Heartbeat '%s' is overdue. Last ping received %v ago.", hb.Name,
+			           time.Duration(now - lastPing).Round(time.Second))
+
+			m.runSaveState()
+		}
+	}
+	m.transitionMtx.Unlock()
+	return
+}
+
+func (m *Module) runAlert(hb *HeartbeatItem, format string, a ...interface{}) {
+	id := alerts.Open("heartbeats", hb.HashCode, hb.Name, hb.Channel, hb.Severity, format, a...)
+	m.runNotify(hb, hb.Severity, format + " [alert %v]", append(a, id)...)
+	return
+}
+
+func (m *Module) runNotify(hb *HeartbeatItem, severity string, format string, a ...interface{}) {
+	if m.r.Acquire() {
+		go func(hb *HeartbeatItem) {
+			atomic.StoreInt64(&hb.LastNotificationTime, time.Now().UnixNano())
+
+			_ = logger.LogTarget("heartbeats", hb.Name, severity, hb.Channel, format, a...)
+
+			m.r.Release()
+		}(hb)
+	}
+	return
+}
+
+func (m *Module) runSaveState() {
+	if m.r.Acquire() {
+		go func(m *Module) {
+			m.saveStateMtx.Lock()
+			err := m.saveState()
+			m.saveStateMtx.Unlock()
+
+			if err != nil {
+				console.Error("Unable to save heartbeats state. [%v]", err)
+			}
+
+			m.r.Release()
+		}(m)
+	}
+	return
+}
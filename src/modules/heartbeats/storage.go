@@ -0,0 +1,66 @@
+package heartbeats
+
+import (
+	"sync/atomic"
+
+	"github.com/randlabs/server-watchdog/utils/state"
+	"github.com/vmihailenco/msgpack/v4"
+)
+
+//------------------------------------------------------------------------------
+
+type HeartbeatsStateItem struct {
+	HashCode     uint64
+	LastPingTime int64
+	Overdue      bool
+}
+
+//------------------------------------------------------------------------------
+
+const (
+	heartbeatsStateFileName = "heartbeats.state"
+)
+
+//------------------------------------------------------------------------------
+
+func (m *Module) loadState() error {
+	b, err := state.LoadStateBlob(heartbeatsStateFileName)
+	if err == nil && b != nil {
+		var loadedItems []HeartbeatsStateItem
+
+		err = msgpack.Unmarshal(b, &loadedItems)
+		if err == nil {
+			for _, hb := range m.heartbeatsList {
+				for _, v := range loadedItems {
+					if hb.HashCode == v.HashCode {
+						atomic.StoreInt64(&hb.LastPingTime, v.LastPingTime)
+						if v.Overdue {
+							atomic.StoreInt32(&hb.Overdue, 1)
+						}
+						break
+					}
+				}
+			}
+		}
+	}
+
+	return err
+}
+
+func (m *Module) saveState() error {
+	toSave := make([]HeartbeatsStateItem, len(m.heartbeatsList))
+	for idx, v := range m.heartbeatsList {
+		toSave[idx] = HeartbeatsStateItem{
+			HashCode     : v.HashCode,
+			LastPingTime : atomic.LoadInt64(&v.LastPingTime),
+			Overdue      : atomic.LoadInt32(&v.Overdue) != 0,
+		}
+	}
+
+	b, err := msgpack.Marshal(toSave)
+	if err == nil {
+		err = state.SaveStateBlob(heartbeatsStateFileName, b)
+	}
+
+	return err
+}
@@ -0,0 +1,571 @@
+package jobs
+
+import (
+	"errors"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	rp "github.com/randlabs/rundown-protection"
+	"github.com/randlabs/server-watchdog/console"
+	"github.com/randlabs/server-watchdog/modules/alerts"
+	"github.com/randlabs/server-watchdog/modules/logger"
+	"github.com/randlabs/server-watchdog/settings"
+	"github.com/randlabs/server-watchdog/utils/cron"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	jobsCheckInterval = 5 * time.Second
+	maxOutputLength   = 4096
+	maxAdHocJobs      = 100
+)
+
+//------------------------------------------------------------------------------
+
+type Module struct {
+	shutdownSignal chan struct{}
+	jobsListMtx    sync.Mutex
+	jobsList       []*JobItem
+	r              rp.RundownProtection
+	saveStateMtx   sync.Mutex //serializes the writes to the state file
+}
+
+type JobItem struct {
+	HashCode         uint64
+	Name             string
+	Schedule         string
+	ScheduleX        *cron.Schedule
+	Timezone         *time.Location
+	StartGrace       time.Duration
+	MaxDuration      time.Duration
+	Channel          string
+	Severity         string
+	CurrentRun       *JobRun
+	LastRun          *JobRun
+	ExpectedStart    time.Time
+	Failing          bool
+	Overrun          bool
+	LastNotification time.Time
+	Config           *settings.SettingsJSON_Jobs //only set on jobs reported at runtime
+}
+
+// JobRun is a single execution of a job
+type JobRun struct {
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	Duration   string     `json:"duration,omitempty" msgpack:"-"`
+	Succeeded  bool       `json:"succeeded"`
+	ExitCode   *int       `json:"exitCode,omitempty"`
+	Output     string     `json:"output,omitempty"`
+}
+
+type JobStatus struct {
+	Id               string           `json:"id"`
+	Source           string           `json:"source"`
+	Config           JobStatus_Config `json:"config"`
+	Running          bool             `json:"running"`
+	Failing          bool             `json:"failing"`
+	CurrentRun       *JobRun          `json:"currentRun,omitempty"`
+	LastRun          *JobRun          `json:"lastRun,omitempty"`
+	ExpectedStart    *time.Time       `json:"expectedStart,omitempty"`
+	LastNotification *time.Time       `json:"lastNotification,omitempty"`
+}
+
+type JobStatus_Config struct {
+	Name        string `json:"name"`
+	Schedule    string `json:"schedule,omitempty"`
+	Timezone    string `json:"timezone,omitempty"`
+	StartGrace  string `json:"startGrace,omitempty"`
+	MaxDuration string `json:"maxDuration,omitempty"`
+	Channel     string `json:"channel"`
+	Severity    string `json:"severity"`
+}
+
+//------------------------------------------------------------------------------
+
+var module *Module
+var lock sync.RWMutex
+
+var ErrNotFound = errors.New("Job not found")
+var ErrAlreadyRunning = errors.New("Job is already running")
+var ErrNotRunning = errors.New("Job is not running")
+var ErrTooManyJobs = errors.New("Too many jobs reported at runtime. Remove unused ones or define them in the settings file.")
+var ErrReadOnly = errors.New("Jobs defined in the settings file cannot be removed")
+
+//------------------------------------------------------------------------------
+
+func Start() error {
+	//initialize module
+	module = &Module{}
+	module.shutdownSignal = make(chan struct{})
+	module.r.Initialize()
+
+	//build jobs list from settings. scheduled jobs are expected to start at the first activation after now.
+	now := time.Now()
+	module.jobsList = make([]*JobItem, len(settings.Config.Jobs))
+	for idx := range settings.Config.Jobs {
+		module.jobsList[idx] = newJobItem(&settings.Config.Jobs[idx])
+		module.jobsList[idx].updateExpectedStart(now)
+	}
+
+	//load stored state
+	err := module.loadState()
+	if err != nil {
+		console.Error("Unable to load jobs state. [%v]", err)
+		return err
+	}
+
+	return nil
+}
+
+func Stop() {
+	lock.Lock()
+	localModule := module
+	module = nil
+	lock.Unlock()
+
+	if localModule != nil {
+		//signal shutdown
+		close(localModule.shutdownSignal)
+
+		//wait until all workers are done
+		localModule.r.Wait()
+	}
+	return
+}
+
+func Run(wg *sync.WaitGroup) {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule != nil {
+		//start background loop
+		wg.Add(1)
+
+		if localModule.r.Acquire() {
+			go func() {
+				loop := true
+				for loop {
+					select {
+					case <-localModule.shutdownSignal:
+						loop = false
+
+					case <-time.After(jobsCheckInterval):
+						localModule.checkJobs()
+					}
+				}
+
+				localModule.r.Release()
+
+				wg.Done()
+			}()
+		} else {
+			wg.Done()
+		}
+	}
+	return
+}
+
+// StartRun records the start of a job run. Jobs not defined in the settings file are accepted if a definition is
+// provided, which is kept for later runs.
+func StartRun(name string, adhoc *settings.SettingsJSON_Jobs) error {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return errors.New("Module is not active")
+	}
+
+	var err error
+
+	localModule.jobsListMtx.Lock()
+	job := localModule.findJob(name)
+	if job == nil {
+		if adhoc == nil {
+			err = ErrNotFound
+		} else if localModule.countAdHocJobs() >= maxAdHocJobs {
+			//they are kept in memory and stored so the number is limited
+			err = ErrTooManyJobs
+		} else {
+			job = newJobItem(adhoc)
+			job.Config = adhoc
+			localModule.jobsList = append(localModule.jobsList, job)
+		}
+	}
+	if job != nil {
+		if job.CurrentRun == nil {
+			now := time.Now()
+
+			job.CurrentRun = &JobRun{
+				StartedAt: now.UTC(),
+			}
+			job.Overrun = false
+			job.updateExpectedStart(now)
+		} else {
+			err = ErrAlreadyRunning
+		}
+	}
+	localModule.jobsListMtx.Unlock()
+
+	if err == nil {
+		localModule.runSaveState()
+	}
+	return err
+}
+
+// FinishRun records the end of the current run of a job. If the job was failing, the alert is resolved.
+func FinishRun(name string, exitCode *int, output string) error {
+	return finishRun(name, true, exitCode, output)
+}
+
+// FailRun records the current run of a job as failed and raises an alert
+func FailRun(name string, exitCode *int, output string) error {
+	return finishRun(name, false, exitCode, output)
+}
+
+// GetChannel returns the channel a job notifies to. The second value is false if the job does not exist.
+func GetChannel(name string) (string, bool) {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return "", false
+	}
+
+	channel := ""
+
+	localModule.jobsListMtx.Lock()
+	job := localModule.findJob(name)
+	if job != nil {
+		channel = job.Channel
+	}
+	localModule.jobsListMtx.Unlock()
+
+	return channel, job != nil
+}
+
+// RemoveJob deletes a job reported at runtime
+func RemoveJob(name string) error {
+	var hashCode uint64
+	var err error
+
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return errors.New("Module is not active")
+	}
+
+	localModule.jobsListMtx.Lock()
+	idx := -1
+	for i, job := range localModule.jobsList {
+		if job.Name == name {
+			idx = i
+			break
+		}
+	}
+	if idx >= 0 {
+		if localModule.jobsList[idx].Config != nil {
+			listLen := len(localModule.jobsList)
+
+			hashCode = localModule.jobsList[idx].HashCode
+
+			copy(localModule.jobsList[idx:], localModule.jobsList[idx + 1:])
+			localModule.jobsList[listLen - 1] = nil
+			localModule.jobsList = localModule.jobsList[:(listLen - 1)]
+		} else {
+			err = ErrReadOnly
+		}
+	} else {
+		err = ErrNotFound
+	}
+	localModule.jobsListMtx.Unlock()
+
+	if err == nil {
+		alerts.Resolve(hashCode)
+		localModule.runSaveState()
+	}
+	return err
+}
+
+// GetStatus returns the state of all the jobs
+func GetStatus() []JobStatus {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return make([]JobStatus, 0)
+	}
+
+	now := time.Now()
+
+	localModule.jobsListMtx.Lock()
+	list := make([]JobStatus, len(localModule.jobsList))
+	for idx, job := range localModule.jobsList {
+		list[idx] = JobStatus{
+			Id:     strconv.FormatUint(job.HashCode, 16),
+			Source: "settings",
+			Config: JobStatus_Config{
+				Name:     job.Name,
+				Schedule: job.Schedule,
+				Channel:  job.Channel,
+				Severity: job.Severity,
+			},
+			Running:          job.CurrentRun != nil,
+			Failing:          job.Failing,
+			CurrentRun:       job.CurrentRun.toStatus(now),
+			LastRun:          job.LastRun.toStatus(now),
+			ExpectedStart:    timeOrNil(job.ExpectedStart),
+			LastNotification: timeOrNil(job.LastNotification),
+		}
+		if job.Config != nil {
+			list[idx].Source = "api"
+		}
+		if len(job.Schedule) > 0 {
+			list[idx].Config.Timezone = job.Timezone.String()
+			list[idx].Config.StartGrace = job.StartGrace.String()
+		}
+		if job.MaxDuration > 0 {
+			list[idx].Config.MaxDuration = job.MaxDuration.String()
+		}
+	}
+	localModule.jobsListMtx.Unlock()
+
+	return list
+}
+
+//------------------------------------------------------------------------------
+
+func finishRun(name string, succeeded bool, exitCode *int, output string) error {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return errors.New("Module is not active")
+	}
+
+	var err error
+
+	localModule.jobsListMtx.Lock()
+	job := localModule.findJob(name)
+	if job != nil {
+		if job.CurrentRun != nil {
+			now := time.Now()
+
+			run := job.CurrentRun
+			finishedAt := now.UTC()
+			run.FinishedAt = &finishedAt
+			run.Succeeded = succeeded
+			run.ExitCode = exitCode
+			run.Output = tail(output, maxOutputLength)
+
+			job.CurrentRun = nil
+			job.LastRun = run
+
+			//activations that passed while the job was running are not considered missed
+			if !job.ExpectedStart.IsZero() && job.ExpectedStart.Before(now) {
+				job.updateExpectedStart(now)
+			}
+
+			duration := finishedAt.Sub(run.StartedAt).Round(time.Second)
+			if succeeded {
+				if job.Failing {
+					job.Failing = false
+					alerts.Resolve(job.HashCode)
+					localModule.runNotify(job, "info", "Job '%s' completed successfully after %v.", job.Name, duration)
+				}
+			} else {
+				msg := "Job '%s' failed after %v."
+				a := []interface{}{ job.Name, duration }
+				if exitCode != nil {
+					msg += " Exit code: %v."
+					a = append(a, *exitCode)
+				}
+				if len(run.Output) > 0 {
+					msg += " Output: %v"
+					a = append(a, run.Output)
+				}
+				localModule.runAlert(job, msg, a...)
+			}
+		} else {
+			err = ErrNotRunning
+		}
+	} else {
+		err = ErrNotFound
+	}
+	localModule.jobsListMtx.Unlock()
+
+	if err == nil {
+		localModule.runSaveState()
+	}
+	return err
+}
+
+func (m *Module) findJob(name string) *JobItem {
+	for _, job := range m.jobsList {
+		if job.Name == name {
+			return job
+		}
+	}
+	return nil
+}
+
+// Must be called with the list lock held
+func (m *Module) countAdHocJobs() int {
+	count := 0
+	for _, job := range m.jobsList {
+		if job.Config != nil {
+			count += 1
+		}
+	}
+	return count
+}
+
+func (m *Module) checkJobs() {
+	now := time.Now()
+	changed := false
+
+	m.jobsListMtx.Lock()
+	for _, job := range m.jobsList {
+		if job.CurrentRun != nil {
+			//check if the current run takes too long
+			if job.MaxDuration > 0 && !job.Overrun && now.Sub(job.CurrentRun.StartedAt) >= job.MaxDuration {
+				job.Overrun = true
+				m.runAlert(job, "Job '%s' is running for more than %v.", job.Name, job.MaxDuration)
+				changed = true
+			}
+		} else if !job.ExpectedStart.IsZero() && !now.Before(job.ExpectedStart.Add(job.StartGrace)) {
+			//the job did not start within its schedule
+			m.runAlert(job, "Job '%s' did not start at its scheduled time %v.", job.Name,
+			           job.ExpectedStart.In(job.Timezone).Format(time.RFC3339))
+			job.updateExpectedStart(now)
+			changed = true
+		}
+	}
+	m.jobsListMtx.Unlock()
+
+	if changed {
+		m.runSaveState()
+	}
+	return
+}
+
+//NOTE: Must be called with the jobs list lock held
+func (m *Module) runAlert(job *JobItem, format string, a ...interface{}) {
+	job.Failing = true
+
+	id := alerts.Open("jobs", job.HashCode, job.Name, job.Channel, job.Severity, format, a...)
+	m.runNotify(job, job.Severity, format + " [alert %v]", append(a, id)...)
+	return
+}
+
+//NOTE: Must be called with the jobs list lock held
+func (m *Module) runNotify(job *JobItem, severity string, format string, a ...interface{}) {
+	job.LastNotification = time.Now()
+
+	if m.r.Acquire() {
+		go func(name string, channel string) {
+			_ = logger.LogTarget("jobs", name, severity, channel, format, a...)
+
+			m.r.Release()
+		}(job.Name, job.Channel)
+	}
+	return
+}
+
+func (m *Module) runSaveState() {
+	if m.r.Acquire() {
+		go func(m *Module) {
+			m.saveStateMtx.Lock()
+			err := m.saveState()
+			m.saveStateMtx.Unlock()
+
+			if err != nil {
+				console.Error("Unable to save jobs state. [%v]", err)
+			}
+
+			m.r.Release()
+		}(m)
+	}
+	return
+}
+
+func newJobItem(job *settings.SettingsJSON_Jobs) *JobItem {
+	//the prefix keeps the hash apart from the one of a heartbeat with the same name because alerts are indexed by it
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("job:" + job.Name))
+
+	return &JobItem{
+		HashCode:    h.Sum64(),
+		Name:        job.Name,
+		Schedule:    job.Schedule,
+		ScheduleX:   job.ScheduleX,
+		Timezone:    job.TimezoneX,
+		StartGrace:  job.StartGraceX,
+		MaxDuration: job.MaxDurationX,
+		Channel:     job.Channel,
+		Severity:    job.Severity,
+	}
+}
+
+func (job *JobItem) updateExpectedStart(after time.Time) {
+	if job.ScheduleX != nil {
+		job.ExpectedStart = job.ScheduleX.Next(after.In(job.Timezone))
+	} else {
+		job.ExpectedStart = time.Time{}
+	}
+	return
+}
+
+func (run *JobRun) clone() *JobRun {
+	if run == nil {
+		return nil
+	}
+
+	r := *run
+	return &r
+}
+
+func (run *JobRun) toStatus(now time.Time) *JobRun {
+	r := run.clone()
+	if r == nil {
+		return nil
+	}
+
+	if r.FinishedAt != nil {
+		r.Duration = r.FinishedAt.Sub(r.StartedAt).Round(time.Millisecond).String()
+	} else {
+		r.Duration = now.Sub(r.StartedAt).Round(time.Second).String()
+	}
+	return r
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	tm := t.UTC()
+	return &tm
+}
+
+// tail returns the last bytes of the output without splitting a multi-byte character
+func tail(s string, maxLength int) string {
+	if len(s) <= maxLength {
+		return s
+	}
+	s = s[len(s) - maxLength:]
+	for len(s) > 0 && !utf8.RuneStart(s[0]) {
+		s = s[1:]
+	}
+	return s
+}
@@ -0,0 +1,123 @@
+package jobs
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/randlabs/server-watchdog/console"
+	"github.com/randlabs/server-watchdog/settings"
+	"github.com/randlabs/server-watchdog/utils/state"
+	"github.com/vmihailenco/msgpack/v4"
+)
+
+//------------------------------------------------------------------------------
+
+type JobsStateItem struct {
+	HashCode      uint64
+	CurrentRun    *JobRun
+	LastRun       *JobRun
+	ExpectedStart time.Time
+	Failing       bool
+	Overrun       bool
+	Config        []byte //json encoded settings of jobs reported at runtime
+}
+
+//------------------------------------------------------------------------------
+
+const (
+	jobsStateFileName = "jobs.state"
+)
+
+//------------------------------------------------------------------------------
+
+func (m *Module) loadState() error {
+	b, err := state.LoadStateBlob(jobsStateFileName)
+	if err == nil && b != nil {
+		var loadedItems []JobsStateItem
+
+		err = msgpack.Unmarshal(b, &loadedItems)
+		if err == nil {
+			//restore jobs reported at runtime
+			for _, v := range loadedItems {
+				if len(v.Config) > 0 {
+					m.restoreJob(v)
+				}
+			}
+
+			for _, job := range m.jobsList {
+				for _, v := range loadedItems {
+					if job.HashCode == v.HashCode {
+						job.CurrentRun = v.CurrentRun
+						job.LastRun = v.LastRun
+						job.Failing = v.Failing
+						job.Overrun = v.Overrun
+
+						//keep the stored expected start only if the schedule was not removed. a pending activation
+						//that was missed while the service was stopped is reported on the first check.
+						if job.ScheduleX != nil && !v.ExpectedStart.IsZero() {
+							job.ExpectedStart = v.ExpectedStart
+						}
+						break
+					}
+				}
+			}
+		}
+	}
+
+	return err
+}
+
+func (m *Module) saveState() error {
+	var err error
+
+	m.jobsListMtx.Lock()
+	toSave := make([]JobsStateItem, len(m.jobsList))
+	for idx, v := range m.jobsList {
+		toSave[idx] = JobsStateItem{
+			HashCode      : v.HashCode,
+			CurrentRun    : v.CurrentRun.clone(),
+			LastRun       : v.LastRun.clone(),
+			ExpectedStart : v.ExpectedStart,
+			Failing       : v.Failing,
+			Overrun       : v.Overrun,
+		}
+		if v.Config != nil && err == nil {
+			toSave[idx].Config, err = json.Marshal(v.Config)
+		}
+	}
+	m.jobsListMtx.Unlock()
+
+	var b []byte
+	if err == nil {
+		b, err = msgpack.Marshal(toSave)
+	}
+	if err == nil {
+		err = state.SaveStateBlob(jobsStateFileName, b)
+	}
+
+	return err
+}
+
+func (m *Module) restoreJob(v JobsStateItem) {
+	var job settings.SettingsJSON_Jobs
+
+	err := json.Unmarshal(v.Config, &job)
+	if err == nil {
+		err = settings.ValidateJob(&job)
+	}
+	if err != nil {
+		console.Error("Unable to restore job reported at runtime. [%v]", err)
+		return
+	}
+
+	//a job with the same name may have been added to the settings file in the meantime
+	if m.findJob(job.Name) != nil {
+		return
+	}
+
+	item := newJobItem(&job)
+	item.Config = &job
+
+	m.jobsList = append(m.jobsList, item)
+	return
+}
@@ -12,6 +12,8 @@ import (
 	"sync"
 
 	"github.com/randlabs/server-watchdog/console"
+	"github.com/randlabs/server-watchdog/modules/logger/history"
+	"github.com/randlabs/server-watchdog/modules/logger/stats"
 	"github.com/randlabs/server-watchdog/settings"
 )
 
@@ -66,29 +68,30 @@ func Run(wg sync.WaitGroup) {
 	return
 }
 
-func Error(channel string, timestamp string, msg string) {
-	emailModule.sendEmailNotification(channel, "[ERROR]", timestamp, msg)
+func Error(eventId uint64, channel string, timestamp string, msg string) {
+	emailModule.sendEmailNotification(eventId, channel, "[ERROR]", timestamp, msg)
 	return
 }
 
-func Warn(channel string, timestamp string, msg string) {
-	emailModule.sendEmailNotification(channel, "[WARN]", timestamp, msg)
+func Warn(eventId uint64, channel string, timestamp string, msg string) {
+	emailModule.sendEmailNotification(eventId, channel, "[WARN]", timestamp, msg)
 	return
 }
 
-func Info(channel string, timestamp string, msg string) {
-	emailModule.sendEmailNotification(channel, "[INFO]", timestamp, msg)
+func Info(eventId uint64, channel string, timestamp string, msg string) {
+	emailModule.sendEmailNotification(eventId, channel, "[INFO]", timestamp, msg)
 	return
 }
 
-func Debug(channel string, timestamp string, msg string) {
-	emailModule.sendEmailNotification(channel, "[DEBUG]", timestamp, msg)
+func Debug(eventId uint64, channel string, timestamp string, msg string) {
+	emailModule.sendEmailNotification(eventId, channel, "[DEBUG]", timestamp, msg)
 	return
 }
 
 //------------------------------------------------------------------------------
 
-func (module *Module) sendEmailNotification(channel string, title string, timestamp string, msg string) {
+func (module *Module) sendEmailNotification(eventId uint64, channel string, title string, timestamp string,
+                                            msg string) {
 	module.wg.Add(1)
 
 	//retrieve channel info and check if enabled
@@ -183,6 +186,8 @@ func (module *Module) sendEmailNotification(channel string, title string, timest
 		if err != nil {
 			console.Error("Unable to deliver notification to EMail channel. [%v]", err)
 		}
+		history.AddDelivery(eventId, "email", err)
+		stats.AddDelivery("email", channel, err)
 
 		module.wg.Done()
 	}(ch.EMail, channel, title, timestamp, msg)
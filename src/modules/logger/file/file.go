@@ -10,6 +10,8 @@ import (
 	"time"
 
 	"github.com/randlabs/server-watchdog/console"
+	"github.com/randlabs/server-watchdog/modules/logger/history"
+	"github.com/randlabs/server-watchdog/modules/logger/stats"
 	"github.com/randlabs/server-watchdog/settings"
 )
 
@@ -136,23 +138,23 @@ func Run(wg sync.WaitGroup) {
 	return
 }
 
-func Error(channel string, timestamp string, msg string) {
-	fileModule.writeFileLog(channel, "[ERROR]", timestamp, msg)
+func Error(eventId uint64, channel string, timestamp string, msg string) {
+	fileModule.writeFileLog(eventId, channel, "[ERROR]", timestamp, msg)
 	return
 }
 
-func Warn(channel string, timestamp string, msg string) {
-	fileModule.writeFileLog(channel, "[WARN]", timestamp, msg)
+func Warn(eventId uint64, channel string, timestamp string, msg string) {
+	fileModule.writeFileLog(eventId, channel, "[WARN]", timestamp, msg)
 	return
 }
 
-func Info(channel string, timestamp string, msg string) {
-	fileModule.writeFileLog(channel, "[INFO]", timestamp, msg)
+func Info(eventId uint64, channel string, timestamp string, msg string) {
+	fileModule.writeFileLog(eventId, channel, "[INFO]", timestamp, msg)
 	return
 }
 
-func Debug(channel string, timestamp string, msg string) {
-	fileModule.writeFileLog(channel, "[DEBUG]", timestamp, msg)
+func Debug(eventId uint64, channel string, timestamp string, msg string) {
+	fileModule.writeFileLog(eventId, channel, "[DEBUG]", timestamp, msg)
 	return
 }
 
@@ -213,7 +215,7 @@ func (module *Module) getActiveLogFile(channel string) *ActiveLogFile {
 	return &newF
 }
 
-func (module *Module) writeFileLog(channel string, title string, timestamp string, msg string) {
+func (module *Module) writeFileLog(eventId uint64, channel string, title string, timestamp string, msg string) {
 	module.wg.Add(1)
 
 	ch, ok := settings.Config.Channels[channel]
@@ -267,6 +269,8 @@ func (module *Module) writeFileLog(channel string, title string, timestamp strin
 		if err != nil {
 			console.Error("Unable to save notification in file. [%v]", err)
 		}
+		history.AddDelivery(eventId, "file", err)
+		stats.AddDelivery("file", f.appName, err)
 
 		module.wg.Done()
 	}(f, timestamp, msg)
@@ -0,0 +1,647 @@
+package history
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/randlabs/server-watchdog/console"
+	"github.com/randlabs/server-watchdog/settings"
+	"github.com/randlabs/server-watchdog/utils/state"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	dataFileName  = "history.dat"
+	indexFileName = "history.idx"
+
+	indexEntrySize = 32
+
+	kindEvent    = 1
+	kindDelivery = 2
+
+	compactInterval = time.Hour
+
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+//------------------------------------------------------------------------------
+
+type Module struct {
+	shutdownSignal chan struct{}
+	mtx            sync.Mutex
+	filesMtx       sync.RWMutex //held for write while the files are replaced, and for read while Find reads them
+	generation     uint64       //incremented each time the files are replaced
+	folder         string
+	dataFd         *os.File
+	indexFd        *os.File
+	dataSize       int64
+	events         []indexEntry
+	deliveries     map[uint64][]indexEntry
+	nextId         uint64
+}
+
+// Event is a notification stored in the history along with the result of its delivery to each output
+type Event struct {
+	Id           uint64     `json:"id"`
+	Timestamp    time.Time  `json:"timestamp"`
+	Severity     string     `json:"severity"`
+	Channel      string     `json:"channel"`
+	Message      string     `json:"message"`
+	SuppressedBy string     `json:"suppressedBy,omitempty"`
+	Deliveries   []Delivery `json:"deliveries"`
+}
+
+type Delivery struct {
+	Output    string    `json:"output"`
+	Timestamp time.Time `json:"timestamp"`
+	Ok        bool      `json:"ok"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Query filters the events returned by Find. Empty fields match anything.
+type Query struct {
+	Channel  string
+	Severity string
+	Since    time.Time
+	Until    time.Time
+	Text     string
+	Before   uint64 //only return events older than this id, used for pagination
+	Limit    int
+}
+
+// Each line of the data file is a json encoded record. Events and delivery results are appended as they happen.
+type record struct {
+	Kind         int       `json:"kind"`
+	Id           uint64    `json:"id"`
+	Timestamp    time.Time `json:"timestamp"`
+	Severity     string    `json:"severity,omitempty"`
+	Channel      string    `json:"channel,omitempty"`
+	Message      string    `json:"message,omitempty"`
+	SuppressedBy string    `json:"suppressedBy,omitempty"`
+	Output       string    `json:"output,omitempty"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// The index file contains a fixed size entry for each record of the data file
+type indexEntry struct {
+	Id        uint64
+	Timestamp int64
+	Offset    int64
+	Length    uint32
+	Kind      uint32
+}
+
+// An event that can match a query along with its deliveries
+type candidate struct {
+	event      indexEntry
+	deliveries []indexEntry
+}
+
+//------------------------------------------------------------------------------
+
+var historyModule *Module
+
+//------------------------------------------------------------------------------
+
+func Start() error {
+	//initialize module
+	historyModule = &Module{}
+	historyModule.shutdownSignal = make(chan struct{})
+	historyModule.deliveries = make(map[uint64][]indexEntry)
+	historyModule.nextId = 1
+
+	folder, err := state.GetFolder()
+	if err == nil {
+		historyModule.folder = folder
+
+		err = historyModule.open()
+	}
+	if err == nil {
+		err = historyModule.compact()
+	}
+	if err != nil {
+		historyModule.close()
+		historyModule = nil
+	}
+	return err
+}
+
+func Stop() {
+	if historyModule != nil {
+		//signal shutdown
+		close(historyModule.shutdownSignal)
+
+		historyModule.mtx.Lock()
+		historyModule.filesMtx.Lock()
+		historyModule.close()
+		historyModule.filesMtx.Unlock()
+		historyModule.mtx.Unlock()
+
+		historyModule = nil
+	}
+	return
+}
+
+func Run(wg *sync.WaitGroup) {
+	if historyModule != nil {
+		//start background loop
+		wg.Add(1)
+
+		go func(module *Module) {
+			loop := true
+			for loop {
+				select {
+				case <-module.shutdownSignal:
+					loop = false
+
+				case <-time.After(compactInterval):
+					//discard events older than the log files max age
+					err := module.compact()
+					if err != nil {
+						console.Error("Unable to compact notifications history. [%v]", err)
+					}
+				}
+			}
+
+			wg.Done()
+		}(historyModule)
+	}
+	return
+}
+
+// Add stores a notification and returns its id. The time is when the notification happened, or zero for the current
+// time. If the notification was suppressed, the reason must be specified.
+func Add(severity string, channel string, at time.Time, msg string, suppressedBy string) uint64 {
+	if historyModule == nil {
+		return 0
+	}
+
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	historyModule.mtx.Lock()
+	defer historyModule.mtx.Unlock()
+
+	id := historyModule.nextId
+	historyModule.nextId += 1
+
+	err := historyModule.append(record{
+		Kind:         kindEvent,
+		Id:           id,
+		Timestamp:    at.UTC(),
+		Severity:     severity,
+		Channel:      channel,
+		Message:      msg,
+		SuppressedBy: suppressedBy,
+	})
+	if err != nil {
+		console.Error("Unable to save notification in history. [%v]", err)
+	}
+	return id
+}
+
+// AddDelivery stores the result of the delivery of a notification to an output
+func AddDelivery(eventId uint64, output string, deliveryErr error) {
+	if historyModule == nil || eventId == 0 {
+		return
+	}
+
+	rec := record{
+		Kind:      kindDelivery,
+		Id:        eventId,
+		Timestamp: time.Now().UTC(),
+		Output:    output,
+	}
+	if deliveryErr != nil {
+		rec.Error = deliveryErr.Error()
+	}
+
+	historyModule.mtx.Lock()
+	defer historyModule.mtx.Unlock()
+
+	err := historyModule.append(rec)
+	if err != nil {
+		console.Error("Unable to save notification delivery in history. [%v]", err)
+	}
+	return
+}
+
+// Find returns the events that match the query, newest first. If there can be more events, it also returns the id
+// to use as the Before field of the query to get the next page. Otherwise zero is returned.
+func Find(q Query) ([]Event, uint64, error) {
+	if historyModule == nil {
+		return make([]Event, 0), 0, errors.New("Notifications history is not available")
+	}
+
+	if q.Limit <= 0 {
+		q.Limit = DefaultLimit
+	} else if q.Limit > MaxLimit {
+		q.Limit = MaxLimit
+	}
+	q.Text = strings.ToLower(q.Text)
+
+	//the records are read without blocking new notifications. if the files are compacted meanwhile, the offsets
+	//of the candidates are no longer valid so the search is repeated.
+	for {
+		candidates, generation := historyModule.findCandidates(&q)
+
+		list, next, err, ok := historyModule.readCandidates(&q, candidates, generation)
+		if ok {
+			return list, next, err
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Returns the events, newest first, that can match the query along with the current files generation
+func (module *Module) findCandidates(q *Query) ([]candidate, uint64) {
+	module.mtx.Lock()
+	defer module.mtx.Unlock()
+
+	events := module.events
+
+	//only the time is stored in the index so, if other filters are used, all the events in range must be read
+	maxCandidates := len(events)
+	if len(q.Channel) == 0 && len(q.Severity) == 0 && len(q.Text) == 0 {
+		maxCandidates = q.Limit + 1
+	}
+
+	//events are sorted by id so skip the newer ones if a page was requested
+	idx := len(events)
+	if q.Before > 0 {
+		idx = sort.Search(len(events), func(i int) bool {
+			return events[i].Id >= q.Before
+		})
+	}
+
+	candidates := make([]candidate, 0)
+	for idx > 0 && len(candidates) < maxCandidates {
+		idx--
+		entry := &events[idx]
+
+		if !q.Until.IsZero() && entry.Timestamp > q.Until.UnixNano() {
+			continue
+		}
+		//events sent by clients can be older than the previous ones so all of them must be checked
+		if !q.Since.IsZero() && entry.Timestamp < q.Since.UnixNano() {
+			continue
+		}
+
+		//new deliveries are appended so the copied slice is not modified
+		candidates = append(candidates, candidate{
+			event:      *entry,
+			deliveries: module.deliveries[entry.Id],
+		})
+	}
+
+	return candidates, module.generation
+}
+
+// Reads the records of the candidates and returns the ones matching the query. Returns false if the files were
+// replaced after the candidates were selected.
+func (module *Module) readCandidates(q *Query, candidates []candidate, generation uint64) ([]Event, uint64,
+                                     error, bool) {
+	var next uint64
+
+	module.filesMtx.RLock()
+	defer module.filesMtx.RUnlock()
+
+	if module.generation != generation {
+		return nil, 0, nil, false
+	}
+	if module.dataFd == nil {
+		return nil, 0, errors.New("history files are closed"), true
+	}
+
+	list := make([]Event, 0)
+	for idx := range candidates {
+		rec, err := module.readRecord(&candidates[idx].event)
+		if err != nil {
+			return nil, 0, err, true
+		}
+
+		if len(q.Channel) > 0 && rec.Channel != q.Channel {
+			continue
+		}
+		if len(q.Severity) > 0 && rec.Severity != q.Severity {
+			continue
+		}
+		if len(q.Text) > 0 && !strings.Contains(strings.ToLower(rec.Message), q.Text) {
+			continue
+		}
+
+		ev := Event{
+			Id:           rec.Id,
+			Timestamp:    rec.Timestamp,
+			Severity:     rec.Severity,
+			Channel:      rec.Channel,
+			Message:      rec.Message,
+			SuppressedBy: rec.SuppressedBy,
+			Deliveries:   make([]Delivery, 0),
+		}
+		for dIdx := range candidates[idx].deliveries {
+			dRec, err := module.readRecord(&candidates[idx].deliveries[dIdx])
+			if err != nil {
+				return nil, 0, err, true
+			}
+
+			ev.Deliveries = append(ev.Deliveries, Delivery{
+				Output:    dRec.Output,
+				Timestamp: dRec.Timestamp,
+				Ok:        len(dRec.Error) == 0,
+				Error:     dRec.Error,
+			})
+		}
+
+		list = append(list, ev)
+		if len(list) == q.Limit {
+			if idx < len(candidates) - 1 {
+				next = rec.Id
+			}
+			break
+		}
+	}
+
+	return list, next, nil, true
+}
+
+//------------------------------------------------------------------------------
+
+func (module *Module) open() error {
+	var err error
+
+	module.dataFd, err = os.OpenFile(module.folder + dataFileName, os.O_RDWR|os.O_CREATE, 0644)
+	if err == nil {
+		module.indexFd, err = os.OpenFile(module.folder + indexFileName, os.O_RDWR|os.O_CREATE, 0644)
+	}
+	if err == nil {
+		err = module.loadIndex()
+	}
+	return err
+}
+
+func (module *Module) close() {
+	if module.dataFd != nil {
+		_ = module.dataFd.Close()
+		module.dataFd = nil
+	}
+	if module.indexFd != nil {
+		_ = module.indexFd.Close()
+		module.indexFd = nil
+	}
+	return
+}
+
+func (module *Module) loadIndex() error {
+	fi, err := module.dataFd.Stat()
+	if err != nil {
+		return err
+	}
+	module.dataSize = fi.Size()
+
+	_, err = module.indexFd.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	b, err := ioutil.ReadAll(module.indexFd)
+	if err != nil {
+		return err
+	}
+
+	//load entries until the first one that does not match the data file
+	validEnd := int64(0)
+	indexLen := int64(0)
+	for ofs := 0; ofs + indexEntrySize <= len(b); ofs += indexEntrySize {
+		entry := decodeIndexEntry(b[ofs:])
+		if entry.Offset != validEnd || entry.Offset + int64(entry.Length) > module.dataSize {
+			break
+		}
+
+		module.addIndexEntry(entry)
+
+		validEnd = entry.Offset + int64(entry.Length)
+		indexLen += indexEntrySize
+	}
+
+	err = module.indexFd.Truncate(indexLen)
+	if err == nil {
+		_, err = module.indexFd.Seek(indexLen, io.SeekStart)
+	}
+	if err != nil {
+		return err
+	}
+
+	//if the process ended before the index was updated, index the remaining records
+	if validEnd < module.dataSize {
+		_, err = module.dataFd.Seek(validEnd, io.SeekStart)
+		if err != nil {
+			return err
+		}
+
+		reader := bufio.NewReader(module.dataFd)
+		for {
+			line, err2 := reader.ReadBytes('\n')
+			if err2 != nil {
+				//discard incomplete records
+				break
+			}
+
+			var rec record
+
+			if json.Unmarshal(line, &rec) != nil {
+				break
+			}
+
+			entry := indexEntry{
+				Id:        rec.Id,
+				Timestamp: rec.Timestamp.UnixNano(),
+				Offset:    validEnd,
+				Length:    uint32(len(line)),
+				Kind:      uint32(rec.Kind),
+			}
+			err = module.writeIndexEntry(entry)
+			if err != nil {
+				return err
+			}
+			module.addIndexEntry(entry)
+
+			validEnd += int64(len(line))
+		}
+
+		err = module.dataFd.Truncate(validEnd)
+		if err != nil {
+			return err
+		}
+		module.dataSize = validEnd
+	}
+
+	_, err = module.dataFd.Seek(module.dataSize, io.SeekStart)
+	return err
+}
+
+func (module *Module) addIndexEntry(entry indexEntry) {
+	if entry.Kind == kindEvent {
+		module.events = append(module.events, entry)
+		if entry.Id >= module.nextId {
+			module.nextId = entry.Id + 1
+		}
+	} else {
+		module.deliveries[entry.Id] = append(module.deliveries[entry.Id], entry)
+	}
+	return
+}
+
+// Must be called with the lock held
+func (module *Module) append(rec record) error {
+	if module.dataFd == nil {
+		return errors.New("history files are closed")
+	}
+
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	_, err = module.dataFd.Write(b)
+	if err != nil {
+		return err
+	}
+
+	entry := indexEntry{
+		Id:        rec.Id,
+		Timestamp: rec.Timestamp.UnixNano(),
+		Offset:    module.dataSize,
+		Length:    uint32(len(b)),
+		Kind:      uint32(rec.Kind),
+	}
+	module.dataSize += int64(len(b))
+
+	err = module.writeIndexEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	module.addIndexEntry(entry)
+	return nil
+}
+
+func (module *Module) writeIndexEntry(entry indexEntry) error {
+	var b [indexEntrySize]byte
+
+	binary.LittleEndian.PutUint64(b[0:], entry.Id)
+	binary.LittleEndian.PutUint64(b[8:], uint64(entry.Timestamp))
+	binary.LittleEndian.PutUint64(b[16:], uint64(entry.Offset))
+	binary.LittleEndian.PutUint32(b[24:], entry.Length)
+	binary.LittleEndian.PutUint32(b[28:], entry.Kind)
+
+	_, err := module.indexFd.Write(b[:])
+	return err
+}
+
+func decodeIndexEntry(b []byte) indexEntry {
+	return indexEntry{
+		Id:        binary.LittleEndian.Uint64(b[0:]),
+		Timestamp: int64(binary.LittleEndian.Uint64(b[8:])),
+		Offset:    int64(binary.LittleEndian.Uint64(b[16:])),
+		Length:    binary.LittleEndian.Uint32(b[24:]),
+		Kind:      binary.LittleEndian.Uint32(b[28:]),
+	}
+}
+
+// Must be called with the lock or the files lock held
+func (module *Module) readRecord(entry *indexEntry) (record, error) {
+	var rec record
+
+	b := make([]byte, entry.Length)
+	_, err := module.dataFd.ReadAt(b, entry.Offset)
+	if err == nil {
+		err = json.Unmarshal(b, &rec)
+	}
+	return rec, err
+}
+
+// Rewrites the history files without the events older than the log files max age
+func (module *Module) compact() error {
+	module.mtx.Lock()
+	defer module.mtx.Unlock()
+
+	if module.dataFd == nil {
+		return nil
+	}
+
+	lowestTime := time.Now().Add(-settings.Config.Log.MaxAgeX).UnixNano()
+
+	//events sent by clients keep their own time so they are not sorted by timestamp
+	dropped := false
+	toKeep := make([]indexEntry, 0, len(module.events))
+	for idx := range module.events {
+		if module.events[idx].Timestamp >= lowestTime {
+			toKeep = append(toKeep, module.events[idx])
+			toKeep = append(toKeep, module.deliveries[module.events[idx].Id]...)
+		} else {
+			dropped = true
+		}
+	}
+	if !dropped {
+		return nil
+	}
+
+	//copy the records sorted by offset
+	sort.Slice(toKeep, func(i, j int) bool {
+		return toKeep[i].Offset < toKeep[j].Offset
+	})
+
+	tempDataFd, err := os.OpenFile(module.folder + dataFileName + ".tmp", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	for idx := range toKeep {
+		b := make([]byte, toKeep[idx].Length)
+
+		_, err = module.dataFd.ReadAt(b, toKeep[idx].Offset)
+		if err == nil {
+			_, err = tempDataFd.Write(b)
+		}
+		if err != nil {
+			break
+		}
+	}
+	_ = tempDataFd.Close()
+	if err != nil {
+		_ = os.Remove(module.folder + dataFileName + ".tmp")
+		return err
+	}
+
+	//replace the files and rebuild the index from the new data file
+	module.filesMtx.Lock()
+	defer module.filesMtx.Unlock()
+
+	module.generation += 1
+	module.close()
+
+	err = os.Rename(module.folder + dataFileName + ".tmp", module.folder + dataFileName)
+	if err == nil {
+		err = os.Remove(module.folder + indexFileName)
+	}
+	if err == nil {
+		module.events = nil
+		module.deliveries = make(map[uint64][]indexEntry)
+
+		err = module.open()
+	}
+	return err
+}
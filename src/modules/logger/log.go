@@ -9,14 +9,22 @@ import (
 	"github.com/randlabs/server-watchdog/console"
 	"github.com/randlabs/server-watchdog/modules/logger/email"
 	"github.com/randlabs/server-watchdog/modules/logger/file"
+	"github.com/randlabs/server-watchdog/modules/logger/history"
 	"github.com/randlabs/server-watchdog/modules/logger/slack"
+	"github.com/randlabs/server-watchdog/modules/silences"
 	"github.com/randlabs/server-watchdog/settings"
 )
 
 //------------------------------------------------------------------------------
 
 func Start() error {
-	err := file.Start()
+	err := history.Start()
+	if err != nil {
+		console.Error("Unable to open notifications history. [%v]", err)
+		//the history is not essential so keep going without it
+	}
+
+	err = file.Start()
 	if err == nil {
 		err = email.Start()
 	}
@@ -33,29 +41,84 @@ func Stop() {
 	email.Stop()
 	slack.Stop()
 	file.Stop()
+	history.Stop()
 	return
 }
 
-func Run(wg sync.WaitGroup) {
-	email.Run(wg)
-	slack.Run(wg)
-	file.Run(wg)
+func Run(wg *sync.WaitGroup) {
+	email.Run(*wg)
+	slack.Run(*wg)
+	file.Run(*wg)
+	history.Run(wg)
 	return
 }
 
 func Log(severity string, channel string, format string, a ...interface{}) error {
+	return logAt(severity, channel, time.Time{}, fmt.Sprintf(format, a...))
+}
+
+// LogTarget sends a notification about a monitored target of a module. Active silences and maintenance windows can
+// suppress the notification or lower its severity.
+func LogTarget(module string, target string, severity string, channel string, format string,
+               a ...interface{}) error {
+	return LogTargetAt(module, target, severity, channel, time.Time{}, format, a...)
+}
+
+// LogTargetAt is like LogTarget but uses the given time, if not zero, as the timestamp of the notification instead of
+// the current time.
+func LogTargetAt(module string, target string, severity string, channel string, at time.Time, format string,
+                 a ...interface{}) error {
+	severity = settings.ValidateSeverity(severity)
+	if len(severity) == 0 {
+		return errors.New("Invalid severity")
+	}
+
+	msg := fmt.Sprintf(format, a...)
+
+	newSeverity, reason := silences.Check(module, target, channel, severity)
+	if len(newSeverity) == 0 {
+		console.Info("Notification to channel \"%v\" suppressed by %v: %v", channel, reason, msg)
+		history.Add(severity, channel, at, msg, reason)
+		return nil
+	}
+	return logAt(newSeverity, channel, at, msg)
+}
+
+func LogError(channel string, format string, a ...interface{}) {
+	logError(channel, time.Time{}, fmt.Sprintf(format, a...))
+	return
+}
+
+func LogWarn(channel string, format string, a ...interface{}) {
+	logWarn(channel, time.Time{}, fmt.Sprintf(format, a...))
+	return
+}
+
+func LogInfo(channel string, format string, a ...interface{}) {
+	logInfo(channel, time.Time{}, fmt.Sprintf(format, a...))
+	return
+}
+
+func LogDebug(channel string, format string, a ...interface{}) {
+	logDebug(channel, time.Time{}, fmt.Sprintf(format, a...))
+	return
+}
+
+//------------------------------------------------------------------------------
+
+func logAt(severity string, channel string, at time.Time, msg string) error {
 	switch settings.ValidateSeverity(severity) {
 	case "error":
-		LogError(channel, format, a...)
+		logError(channel, at, msg)
 
 	case "warn":
-		LogWarn(channel, format, a...)
+		logWarn(channel, at, msg)
 
 	case "info":
-		LogInfo(channel, format, a...)
+		logInfo(channel, at, msg)
 
 	case "debug":
-		LogDebug(channel, format, a...)
+		logDebug(channel, at, msg)
 
 	default:
 		return errors.New("Invalid severity")
@@ -64,54 +127,63 @@ func Log(severity string, channel string, format string, a ...interface{}) error
 	return nil
 }
 
-func LogError(channel string, format string, a ...interface{}) {
-	timestamp := getTimestamp()
-	msg := fmt.Sprintf(format, a...)
+func logError(channel string, at time.Time, msg string) {
+	timestamp := getTimestamp(at)
 
 	console.LogError(channel, timestamp, msg)
-	file.Error(channel, timestamp, msg)
-	slack.Error(channel, timestamp, msg)
-	email.Error(channel, timestamp, msg)
+	addRecentNotification("error", channel, timestamp, msg)
+	eventId := history.Add("error", channel, at, msg, "")
+	file.Error(eventId, channel, timestamp, msg)
+	slack.Error(eventId, channel, timestamp, msg)
+	email.Error(eventId, channel, timestamp, msg)
 	return
 }
 
-func LogWarn(channel string, format string, a ...interface{}) {
-	timestamp := getTimestamp()
-	msg := fmt.Sprintf(format, a...)
+func logWarn(channel string, at time.Time, msg string) {
+	timestamp := getTimestamp(at)
 
 	console.LogWarn(channel, timestamp, msg)
-	file.Warn(channel, timestamp, msg)
-	slack.Warn(channel, timestamp, msg)
-	email.Warn(channel, timestamp, msg)
+	addRecentNotification("warn", channel, timestamp, msg)
+	eventId := history.Add("warn", channel, at, msg, "")
+	file.Warn(eventId, channel, timestamp, msg)
+	slack.Warn(eventId, channel, timestamp, msg)
+	email.Warn(eventId, channel, timestamp, msg)
 	return
 }
 
-func LogInfo(channel string, format string, a ...interface{}) {
-	timestamp := getTimestamp()
-	msg := fmt.Sprintf(format, a...)
+func logInfo(channel string, at time.Time, msg string) {
+	timestamp := getTimestamp(at)
 
 	console.LogInfo(channel, timestamp, msg)
-	file.Info(channel, timestamp, msg)
-	slack.Info(channel, timestamp, msg)
-	email.Info(channel, timestamp, msg)
+	addRecentNotification("info", channel, timestamp, msg)
+	eventId := history.Add("info", channel, at, msg, "")
+	file.Info(eventId, channel, timestamp, msg)
+	slack.Info(eventId, channel, timestamp, msg)
+	email.Info(eventId, channel, timestamp, msg)
 	return
 }
 
-func LogDebug(channel string, format string, a ...interface{}) {
-	timestamp := getTimestamp()
-	msg := fmt.Sprintf(format, a...)
+func logDebug(channel string, at time.Time, msg string) {
+	timestamp := getTimestamp(at)
 
 	console.LogDebug(channel, timestamp, msg)
-	file.Debug(channel, timestamp, msg)
-	slack.Debug(channel, timestamp, msg)
-	email.Debug(channel, timestamp, msg)
+	addRecentNotification("debug", channel, timestamp, msg)
+	eventId := history.Add("debug", channel, at, msg, "")
+	file.Debug(eventId, channel, timestamp, msg)
+	slack.Debug(eventId, channel, timestamp, msg)
+	email.Debug(eventId, channel, timestamp, msg)
 	return
 }
 
-func getTimestamp() string {
-	now := time.Now()
+// getTimestamp formats the given time, or the current one if zero, in the timezone of the logs
+func getTimestamp(at time.Time) string {
+	if at.IsZero() {
+		at = time.Now()
+	}
 	if !settings.Config.Log.UseLocalTime {
-		now = now.UTC()
+		at = at.UTC()
+	} else {
+		at = at.Local()
 	}
-	return now.Format("2006-01-02 15:04:05")
+	return at.Format("2006-01-02 15:04:05")
 }
@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"sync"
+)
+
+//------------------------------------------------------------------------------
+
+// Notification is an entry of the recent notifications list
+type Notification struct {
+	Timestamp string `json:"timestamp"`
+	Severity  string `json:"severity"`
+	Channel   string `json:"channel"`
+	Message   string `json:"message"`
+}
+
+//------------------------------------------------------------------------------
+
+const (
+	maxRecentNotifications = 100
+)
+
+var recentMtx sync.Mutex
+var recentList = make([]Notification, 0, maxRecentNotifications)
+
+//------------------------------------------------------------------------------
+
+// GetRecentNotifications returns the latest notifications, newest first
+func GetRecentNotifications() []Notification {
+	recentMtx.Lock()
+	list := make([]Notification, len(recentList))
+	for idx := range recentList {
+		list[idx] = recentList[len(recentList) - 1 - idx]
+	}
+	recentMtx.Unlock()
+
+	return list
+}
+
+//------------------------------------------------------------------------------
+
+func addRecentNotification(severity string, channel string, timestamp string, msg string) {
+	recentMtx.Lock()
+	if len(recentList) >= maxRecentNotifications {
+		copy(recentList, recentList[1:])
+		recentList = recentList[:len(recentList) - 1]
+	}
+	recentList = append(recentList, Notification{
+		Timestamp: timestamp,
+		Severity:  severity,
+		Channel:   channel,
+		Message:   msg,
+	})
+	recentMtx.Unlock()
+
+	return
+}
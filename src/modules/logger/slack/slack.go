@@ -12,6 +12,8 @@ import (
 	"time"
 
 	"github.com/randlabs/server-watchdog/console"
+	"github.com/randlabs/server-watchdog/modules/logger/history"
+	"github.com/randlabs/server-watchdog/modules/logger/stats"
 	"github.com/randlabs/server-watchdog/settings"
 )
 
@@ -70,29 +72,30 @@ func Run(wg sync.WaitGroup) {
 	return
 }
 
-func Error(channel string, timestamp string, msg string) {
-	slackModule.sendSlackNotification(channel, "[ERROR]", timestamp, msg)
+func Error(eventId uint64, channel string, timestamp string, msg string) {
+	slackModule.sendSlackNotification(eventId, channel, "[ERROR]", timestamp, msg)
 	return
 }
 
-func Warn(channel string, timestamp string, msg string) {
-	slackModule.sendSlackNotification(channel, "[WARN]", timestamp, msg)
+func Warn(eventId uint64, channel string, timestamp string, msg string) {
+	slackModule.sendSlackNotification(eventId, channel, "[WARN]", timestamp, msg)
 	return
 }
 
-func Info(channel string, timestamp string, msg string) {
-	slackModule.sendSlackNotification(channel, "[INFO]", timestamp, msg)
+func Info(eventId uint64, channel string, timestamp string, msg string) {
+	slackModule.sendSlackNotification(eventId, channel, "[INFO]", timestamp, msg)
 	return
 }
 
-func Debug(channel string, timestamp string, msg string) {
-	slackModule.sendSlackNotification(channel, "[DEBUG", timestamp, msg)
+func Debug(eventId uint64, channel string, timestamp string, msg string) {
+	slackModule.sendSlackNotification(eventId, channel, "[DEBUG", timestamp, msg)
 	return
 }
 
 //------------------------------------------------------------------------------
 
-func (module *Module) sendSlackNotification(channel string, title string, timestamp string, msg string) {
+func (module *Module) sendSlackNotification(eventId uint64, channel string, title string, timestamp string,
+                                            msg string) {
 	module.wg.Add(1)
 
 	//retrieve channel info and check if enabled
@@ -187,6 +190,8 @@ TryAgain:
 		if err != nil {
 			console.Error("Unable to deliver notification to Slack channel. [%v]", err)
 		}
+		history.AddDelivery(eventId, "slack", err)
+		stats.AddDelivery("slack", channel, err)
 
 		module.wg.Done()
 	}(ch.Slack.Channel, timestamp, msg)
@@ -0,0 +1,80 @@
+package stats
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// DeliveryStats holds the notification delivery counters of an output in a channel
+type DeliveryStats struct {
+	Output      string
+	Channel     string
+	Sent        uint64
+	Failed      uint64
+	LastSuccess time.Time
+	LastFailure time.Time
+	LastError   string
+}
+
+type statsKey struct {
+	output  string
+	channel string
+}
+
+//------------------------------------------------------------------------------
+
+var mtx sync.Mutex
+var deliveries = make(map[statsKey]*DeliveryStats)
+
+//------------------------------------------------------------------------------
+
+// AddDelivery records the result of a notification delivery
+func AddDelivery(output string, channel string, err error) {
+	key := statsKey{
+		output:  output,
+		channel: channel,
+	}
+
+	mtx.Lock()
+	ds, ok := deliveries[key]
+	if !ok {
+		ds = &DeliveryStats{
+			Output:  output,
+			Channel: channel,
+		}
+		deliveries[key] = ds
+	}
+	if err == nil {
+		ds.Sent += 1
+		ds.LastSuccess = time.Now()
+	} else {
+		ds.Failed += 1
+		ds.LastFailure = time.Now()
+		ds.LastError = err.Error()
+	}
+	mtx.Unlock()
+
+	return
+}
+
+// GetDeliveryStats returns a copy of the delivery counters sorted by output and channel
+func GetDeliveryStats() []DeliveryStats {
+	mtx.Lock()
+	list := make([]DeliveryStats, 0, len(deliveries))
+	for _, ds := range deliveries {
+		list = append(list, *ds)
+	}
+	mtx.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Output != list[j].Output {
+			return list[i].Output < list[j].Output
+		}
+		return list[i].Channel < list[j].Channel
+	})
+
+	return list
+}
@@ -0,0 +1,174 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/randlabs/server-watchdog/modules/freediskspacechecker"
+	"github.com/randlabs/server-watchdog/modules/heartbeats"
+	"github.com/randlabs/server-watchdog/modules/logger/stats"
+	"github.com/randlabs/server-watchdog/modules/processwatcher"
+	"github.com/randlabs/server-watchdog/modules/tcpports"
+	"github.com/randlabs/server-watchdog/modules/webchecker"
+)
+
+//------------------------------------------------------------------------------
+
+type metricWriter struct {
+	w io.Writer
+}
+
+//------------------------------------------------------------------------------
+
+// ContentType is the Prometheus text exposition format content type
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var webStatuses = []string{"up", "stalled", "down"}
+
+//------------------------------------------------------------------------------
+
+// Write dumps the current state of all the modules in Prometheus text format
+func Write(w io.Writer) {
+	mw := metricWriter{
+		w: w,
+	}
+
+	webs := webchecker.GetStatus()
+
+	mw.header("watchdog_web_up", "gauge", "Whether the last check of the web succeeded.")
+	for _, web := range webs {
+		mw.sample("watchdog_web_up", boolValue(web.Status == "up"), "url", web.Config.Url,
+		          "channel", web.Config.Channel)
+	}
+
+	mw.header("watchdog_web_status", "gauge", "Current state of the web (up, stalled or down).")
+	for _, web := range webs {
+		for _, status := range webStatuses {
+			mw.sample("watchdog_web_status", boolValue(web.Status == status), "url", web.Config.Url, "status", status)
+		}
+	}
+
+	mw.header("watchdog_web_response_time_seconds", "gauge", "Response time of the last web check.")
+	for _, web := range webs {
+		mw.sample("watchdog_web_response_time_seconds", web.ResponseTime.Seconds(), "url", web.Config.Url)
+	}
+
+	//----
+
+	mw.header("watchdog_tcp_port_up", "gauge", "Whether the TCP port accepted connections in the last check.")
+	for _, group := range tcpports.GetStatus() {
+		for _, port := range group.Ports {
+			mw.sample("watchdog_tcp_port_up", boolValue(port.Up), "group", group.Config.Name,
+			          "address", group.Config.Address, "port", strconv.FormatUint(uint64(port.Port), 10))
+		}
+	}
+
+	//----
+
+	devices := freediskspacechecker.GetStatus()
+
+	mw.header("watchdog_disk_free_bytes", "gauge", "Free space of the device in bytes.")
+	for _, dev := range devices {
+		mw.sample("watchdog_disk_free_bytes", float64(dev.FreeSpace), "device", dev.Config.Device)
+	}
+
+	mw.header("watchdog_disk_minimum_free_bytes", "gauge", "Configured minimum free space of the device in bytes.")
+	for _, dev := range devices {
+		mw.sample("watchdog_disk_minimum_free_bytes", float64(dev.Config.MinimumFreeSpace),
+		          "device", dev.Config.Device)
+	}
+
+	//----
+
+	processes := processwatcher.GetStatus()
+
+	mw.header("watchdog_processes_watched", "gauge", "Number of processes being watched.")
+	mw.sample("watchdog_processes_watched", float64(len(processes)))
+
+	mw.header("watchdog_process_resident_memory_bytes", "gauge", "Resident memory size of the watched process.")
+	for _, p := range processes {
+		mw.sample("watchdog_process_resident_memory_bytes", float64(p.MemoryUsage), "pid", strconv.Itoa(p.Pid),
+		          "name", p.Name, "channel", p.Channel)
+	}
+
+	//----
+
+	beats := heartbeats.GetStatus()
+
+	mw.header("watchdog_heartbeat_overdue", "gauge", "Whether the heartbeat missed its deadline.")
+	for _, hb := range beats {
+		mw.sample("watchdog_heartbeat_overdue", boolValue(hb.Overdue), "name", hb.Config.Name,
+		          "channel", hb.Config.Channel)
+	}
+
+	mw.header("watchdog_heartbeat_last_ping_timestamp_seconds", "gauge", "Time of the last heartbeat ping.")
+	for _, hb := range beats {
+		if hb.LastPing != nil {
+			mw.sample("watchdog_heartbeat_last_ping_timestamp_seconds", float64(hb.LastPing.Unix()),
+			          "name", hb.Config.Name)
+		}
+	}
+
+	//----
+
+	deliveries := stats.GetDeliveryStats()
+
+	mw.header("watchdog_notifications_sent_total", "counter", "Notifications successfully delivered.")
+	for _, ds := range deliveries {
+		mw.sample("watchdog_notifications_sent_total", float64(ds.Sent), "output", ds.Output, "channel", ds.Channel)
+	}
+
+	mw.header("watchdog_notifications_failed_total", "counter", "Notifications that failed to be delivered.")
+	for _, ds := range deliveries {
+		mw.sample("watchdog_notifications_failed_total", float64(ds.Failed), "output", ds.Output, "channel", ds.Channel)
+	}
+
+	return
+}
+
+//------------------------------------------------------------------------------
+
+func (mw *metricWriter) header(name string, metricType string, help string) {
+	_, _ = fmt.Fprintf(mw.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+	return
+}
+
+func (mw *metricWriter) sample(name string, value float64, labels ...string) {
+	sb := strings.Builder{}
+
+	sb.WriteString(name)
+	if len(labels) > 0 {
+		sb.WriteByte('{')
+		for idx := 0; idx + 1 < len(labels); idx += 2 {
+			if idx > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(labels[idx])
+			sb.WriteString("=\"")
+			sb.WriteString(escapeLabelValue(labels[idx + 1]))
+			sb.WriteByte('"')
+		}
+		sb.WriteByte('}')
+	}
+	sb.WriteByte(' ')
+	sb.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	sb.WriteByte('\n')
+
+	_, _ = io.WriteString(mw.w, sb.String())
+	return
+}
+
+func escapeLabelValue(s string) string {
+	s = strings.Replace(s, "\\", "\\\\", -1)
+	s = strings.Replace(s, "\"", "\\\"", -1)
+	return strings.Replace(s, "\n", "\\n", -1)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
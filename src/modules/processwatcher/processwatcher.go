@@ -4,8 +4,10 @@ import (
 	"errors"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unicode/utf8"
 
@@ -15,12 +17,14 @@ import (
 	"github.com/randlabs/server-watchdog/console"
 	"github.com/randlabs/server-watchdog/modules/logger"
 	"github.com/randlabs/server-watchdog/settings"
+	"github.com/randlabs/server-watchdog/utils/timeutils"
 	gops_proc "github.com/shirou/gopsutil/process"
 )
 
 //------------------------------------------------------------------------------
 
 type Module struct {
+	lastCheckTime  int64 //first to keep it 64-bit aligned on 32-bit platforms because it is accessed atomically
 	shutdownSignal chan struct{}
 	processListMtx sync.Mutex
 	processList    []*ProcessItem
@@ -33,6 +37,18 @@ type ProcessItem struct {
 	Channel        string
 	Severity       string
 	MaxMemUsage    string
+	WatchedSince   time.Time
+}
+
+type ProcessStatus struct {
+	Pid          int        `json:"pid"`
+	Name         string     `json:"name,omitempty"`
+	Channel      string     `json:"channel"`
+	Severity     string     `json:"severity"`
+	MaxMemUsage  string     `json:"maxMem,omitempty"`
+	MemoryUsage  uint64     `json:"memoryUsage"`
+	WatchedSince time.Time  `json:"watchedSince"`
+	LastCheck    *time.Time `json:"lastCheck,omitempty"`
 }
 
 //------------------------------------------------------------------------------
@@ -181,6 +197,49 @@ func RemoveProcess(pid int, channel string) error {
 	return nil
 }
 
+// GetStatus returns the list of watched processes along with their current resident memory usage
+func GetStatus() []ProcessStatus {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return make([]ProcessStatus, 0)
+	}
+
+	localModule.processListMtx.Lock()
+	list := make([]ProcessStatus, len(localModule.processList))
+	for idx, p := range localModule.processList {
+		list[idx] = ProcessStatus{
+			Pid:          p.Pid,
+			Name:         p.Name,
+			Channel:      p.Channel,
+			Severity:     p.Severity,
+			MaxMemUsage:  p.MaxMemUsage,
+			WatchedSince: p.WatchedSince,
+		}
+	}
+	localModule.processListMtx.Unlock()
+
+	lastCheck := timeutils.FromUnixNano(atomic.LoadInt64(&localModule.lastCheckTime))
+	for idx := range list {
+		list[idx].LastCheck = lastCheck
+	}
+
+	//query memory usage outside the lock
+	for idx := range list {
+		proc, err := gops_proc.NewProcess(int32(list[idx].Pid))
+		if err == nil {
+			memInfo, err := proc.MemoryInfo()
+			if err == nil && memInfo != nil {
+				list[idx].MemoryUsage = memInfo.RSS
+			}
+		}
+	}
+
+	return list
+}
+
 //------------------------------------------------------------------------------
 
 func (m *Module) addProcessInternal(pid int, name string, maxMemUsage string, severity string, channel string) error {
@@ -207,6 +266,8 @@ func (m *Module) addProcessInternal(pid int, name string, maxMemUsage string, se
 				Name: name,
 				Channel: channel,
 				Severity: severity,
+				MaxMemUsage: maxMemUsage,
+				WatchedSince: time.Now().UTC(),
 			}
 
 			m.processList = append(m.processList, p)
@@ -233,9 +294,11 @@ func (m *Module) checkForDeadProcesses() {
 
 			//log it
 			if len(p.Name) == 0 {
-				_ = logger.Log(p.Severity, p.Channel, "The process #%v has ended.", p.Pid)
+				_ = logger.LogTarget("processes", strconv.Itoa(p.Pid), p.Severity, p.Channel,
+				                       "The process #%v has ended.", p.Pid)
 			} else {
-				_ = logger.Log(p.Severity, p.Channel, "The process \"%v\" (#%v) has ended.", p.Name, p.Pid)
+				_ = logger.LogTarget("processes", p.Name, p.Severity, p.Channel,
+				                       "The process \"%v\" (#%v) has ended.", p.Name, p.Pid)
 			}
 
 			//from https://github.com/golang/go/wiki/SliceTricks to avoid leaks
@@ -247,6 +310,7 @@ func (m *Module) checkForDeadProcesses() {
 
 	m.processListMtx.Unlock()
 
+	atomic.StoreInt64(&m.lastCheckTime, time.Now().UnixNano())
 	return
 }
 
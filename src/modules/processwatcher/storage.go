@@ -1,6 +1,8 @@
 package processwatcher
 
 import (
+	"strconv"
+
 	"github.com/randlabs/server-watchdog/modules/logger"
 	"github.com/randlabs/server-watchdog/utils/state"
 	"github.com/vmihailenco/msgpack/v4"
@@ -44,9 +46,11 @@ func (m *Module) loadState() error {
 
 				if err.Error() == errProcessNotFound {
 					if len(v.Name) == 0 {
-						_ = logger.Log(v.Severity, v.Channel, "The process #%v has died while the server watcher was down.", v.Pid)
+						_ = logger.LogTarget("processes", strconv.Itoa(v.Pid), v.Severity, v.Channel,
+						                       "The process #%v has died while the server watcher was down.", v.Pid)
 					} else {
-						_ = logger.Log(v.Severity, v.Channel, "The process \"%v\" (#%v) has died while the server watcher was down.", v.Name, v.Pid)
+						_ = logger.LogTarget("processes", v.Name, v.Severity, v.Channel,
+						                       "The process \"%v\" (#%v) has died while the server watcher was down.", v.Name, v.Pid)
 					}
 				}
 			}
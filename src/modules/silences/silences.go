@@ -0,0 +1,298 @@
+package silences
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	rp "github.com/randlabs/rundown-protection"
+	"github.com/randlabs/server-watchdog/console"
+	"github.com/randlabs/server-watchdog/settings"
+	"github.com/randlabs/server-watchdog/utils/guid"
+)
+
+//------------------------------------------------------------------------------
+
+type Module struct {
+	silencesMtx  sync.Mutex
+	silences     []Silence
+	r            rp.RundownProtection
+	saveStateMtx sync.Mutex //serializes the writes to the state file
+}
+
+type Silence struct {
+	Id        string                         `json:"id"`
+	Matchers  settings.SettingsJSON_Matchers `json:"matchers"`
+	StartsAt  time.Time                      `json:"startsAt"`
+	EndsAt    time.Time                      `json:"endsAt"`
+	Comment   string                         `json:"comment,omitempty"`
+	CreatedAt time.Time                      `json:"createdAt"`
+}
+
+type MaintenanceWindowStatus struct {
+	Name        string                         `json:"name"`
+	Schedule    string                         `json:"schedule"`
+	Duration    string                         `json:"duration"`
+	Timezone    string                         `json:"timezone"`
+	Action      string                         `json:"action"`
+	DowngradeTo string                         `json:"downgradeTo,omitempty"`
+	Matchers    settings.SettingsJSON_Matchers `json:"matchers"`
+	Active      bool                           `json:"active"`
+	NextStart   *time.Time                     `json:"nextStart,omitempty"`
+}
+
+//------------------------------------------------------------------------------
+
+var module *Module
+var lock sync.RWMutex
+
+var ErrNotFound = errors.New("Silence not found")
+var ErrShuttingDown = errors.New("Module is shutting down")
+
+//------------------------------------------------------------------------------
+
+func Start() error {
+	//initialize module
+	module = &Module{}
+	module.r.Initialize()
+
+	//load stored silences
+	err := module.loadState()
+	if err != nil {
+		console.Error("Unable to load silences state. [%v]", err)
+		return err
+	}
+
+	return nil
+}
+
+func Stop() {
+	lock.Lock()
+	localModule := module
+	module = nil
+	lock.Unlock()
+
+	if localModule != nil {
+		//wait until all workers are done
+		localModule.r.Wait()
+	}
+	return
+}
+
+// Add creates a new silence and returns its id. If no start time is given, the silence starts immediately.
+func Add(silence Silence) (string, error) {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return "", errors.New("Module is not active")
+	}
+
+	err := settings.ValidateMatchers(&silence.Matchers)
+	if err != nil {
+		return "", err
+	}
+	if len(silence.Matchers.Channel) == 0 && len(silence.Matchers.Module) == 0 &&
+	   len(silence.Matchers.Target) == 0 && len(silence.Matchers.Severity) == 0 {
+		return "", errors.New("At least one matcher must be specified")
+	}
+
+	now := time.Now().UTC()
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = now
+	}
+	if !silence.EndsAt.After(silence.StartsAt) || !silence.EndsAt.After(now) {
+		return "", errors.New("The silence must end after it starts and in the future")
+	}
+
+	id, err := guid.New()
+	if err != nil {
+		return "", err
+	}
+
+	silence.Id = id.ToString()
+	silence.CreatedAt = now
+
+	if localModule.r.Acquire() {
+		localModule.silencesMtx.Lock()
+		localModule.silences = append(localModule.silences, silence)
+		localModule.silencesMtx.Unlock()
+
+		localModule.runSaveState()
+
+		localModule.r.Release()
+	} else {
+		return "", ErrShuttingDown
+	}
+
+	return silence.Id, nil
+}
+
+// Expire removes a silence
+func Expire(id string) error {
+	var err error
+
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return errors.New("Module is not active")
+	}
+
+	if localModule.r.Acquire() {
+		found := false
+
+		localModule.silencesMtx.Lock()
+		for idx := range localModule.silences {
+			if strings.EqualFold(localModule.silences[idx].Id, id) {
+				localModule.silences = append(localModule.silences[:idx], localModule.silences[idx + 1:]...)
+				found = true
+				break
+			}
+		}
+		localModule.silencesMtx.Unlock()
+
+		if found {
+			localModule.runSaveState()
+		} else {
+			err = ErrNotFound
+		}
+
+		localModule.r.Release()
+	} else {
+		err = ErrShuttingDown
+	}
+
+	return err
+}
+
+// GetSilences returns the active and pending silences
+func GetSilences() []Silence {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return make([]Silence, 0)
+	}
+
+	localModule.pruneExpired()
+
+	localModule.silencesMtx.Lock()
+	list := make([]Silence, len(localModule.silences))
+	copy(list, localModule.silences)
+	localModule.silencesMtx.Unlock()
+
+	return list
+}
+
+// GetMaintenanceWindows returns the maintenance windows defined in the settings file
+func GetMaintenanceWindows() []MaintenanceWindowStatus {
+	now := time.Now()
+
+	list := make([]MaintenanceWindowStatus, len(settings.Config.Maintenance))
+	for idx := range settings.Config.Maintenance {
+		mw := &settings.Config.Maintenance[idx]
+
+		list[idx] = MaintenanceWindowStatus{
+			Name:        mw.Name,
+			Schedule:    mw.Schedule,
+			Duration:    mw.DurationX.String(),
+			Timezone:    mw.TimezoneX.String(),
+			Action:      mw.Action,
+			DowngradeTo: mw.DowngradeTo,
+			Matchers:    mw.Matchers,
+			Active:      mw.ScheduleX.IsActive(now.In(mw.TimezoneX), mw.DurationX),
+		}
+
+		next := mw.ScheduleX.Next(now.In(mw.TimezoneX))
+		if !next.IsZero() {
+			next = next.UTC()
+			list[idx].NextStart = &next
+		}
+	}
+
+	return list
+}
+
+// Check applies the active silences and maintenance windows to a notification. It returns the severity the
+// notification must be sent with, or an empty string if it must be suppressed, along with the reason.
+func Check(moduleName string, target string, channel string, severity string) (string, string) {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	now := time.Now()
+
+	if localModule != nil {
+		localModule.silencesMtx.Lock()
+		for idx := range localModule.silences {
+			s := &localModule.silences[idx]
+
+			if !now.Before(s.StartsAt) && now.Before(s.EndsAt) &&
+			   s.Matchers.Matches(moduleName, target, channel, severity) {
+				localModule.silencesMtx.Unlock()
+				return "", "silence " + s.Id
+			}
+		}
+		localModule.silencesMtx.Unlock()
+	}
+
+	newSeverity := severity
+	reason := ""
+	for idx := range settings.Config.Maintenance {
+		mw := &settings.Config.Maintenance[idx]
+
+		if mw.Matchers.Matches(moduleName, target, channel, severity) &&
+		   mw.ScheduleX.IsActive(now.In(mw.TimezoneX), mw.DurationX) {
+			if mw.Action == "suppress" {
+				return "", "maintenance window \"" + mw.Name + "\""
+			}
+			newSeverity = mw.DowngradeTo
+			reason = "maintenance window \"" + mw.Name + "\""
+		}
+	}
+
+	return newSeverity, reason
+}
+
+//------------------------------------------------------------------------------
+
+func (m *Module) pruneExpired() {
+	now := time.Now()
+	pruned := false
+
+	m.silencesMtx.Lock()
+	for idx := len(m.silences); idx > 0; idx-- {
+		if !now.Before(m.silences[idx - 1].EndsAt) {
+			m.silences = append(m.silences[:idx - 1], m.silences[idx:]...)
+			pruned = true
+		}
+	}
+	m.silencesMtx.Unlock()
+
+	if pruned {
+		m.runSaveState()
+	}
+	return
+}
+
+func (m *Module) runSaveState() {
+	if m.r.Acquire() {
+		go func(m *Module) {
+			m.saveStateMtx.Lock()
+			err := m.saveState()
+			m.saveStateMtx.Unlock()
+
+			if err != nil {
+				console.Error("Unable to save silences state. [%v]", err)
+			}
+
+			m.r.Release()
+		}(m)
+	}
+	return
+}
@@ -0,0 +1,40 @@
+package silences
+
+import (
+	"github.com/randlabs/server-watchdog/utils/state"
+	"github.com/vmihailenco/msgpack/v4"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	silencesStateFileName = "silences.state"
+)
+
+//------------------------------------------------------------------------------
+
+func (m *Module) loadState() error {
+	b, err := state.LoadStateBlob(silencesStateFileName)
+	if err == nil && b != nil {
+		var loadedItems []Silence
+
+		err = msgpack.Unmarshal(b, &loadedItems)
+		if err == nil {
+			m.silences = loadedItems
+		}
+	}
+
+	return err
+}
+
+func (m *Module) saveState() error {
+	m.silencesMtx.Lock()
+	b, err := msgpack.Marshal(m.silences)
+	m.silencesMtx.Unlock()
+
+	if err == nil {
+		err = state.SaveStateBlob(silencesStateFileName, b)
+	}
+
+	return err
+}
@@ -1,6 +1,11 @@
 package tcpports
 
 import (
+	"encoding/json"
+	"sync/atomic"
+
+	"github.com/randlabs/server-watchdog/console"
+	"github.com/randlabs/server-watchdog/settings"
 	"github.com/randlabs/server-watchdog/utils/state"
 	"github.com/vmihailenco/msgpack/v4"
 )
@@ -10,6 +15,9 @@ import (
 type TcpPortsCheckerStateItem struct {
 	HashCode uint64
 	Ports    []TcpPortsCheckerStateItem_Port
+	Failing  bool
+	Paused   bool
+	Config   []byte //json encoded settings of groups added at runtime
 }
 
 type TcpPortsCheckerStateItem_Port struct {
@@ -31,10 +39,20 @@ func (m *Module) loadState() error {
 		var loadedItems []TcpPortsCheckerStateItem
 
 		err = msgpack.Unmarshal(b, &loadedItems)
+		if err == nil && isLegacyState(loadedItems) {
+			//versions prior to the status API stored the same hash code for every group along with wrong port
+			//statuses so the state cannot be migrated
+			loadedItems = nil
+		}
 		if err == nil {
-			for idx := range m.tcpPortsList {
-				port := &m.tcpPortsList[idx]
+			//restore groups added at runtime
+			for _, v := range loadedItems {
+				if len(v.Config) > 0 {
+					m.restoreTcpPort(v)
+				}
+			}
 
+			for _, port := range m.tcpPortsList {
 				for _, v := range loadedItems {
 					if port.HashCode == v.HashCode {
 						port.LastCheckStatusLock.Lock()
@@ -50,6 +68,11 @@ func (m *Module) loadState() error {
 						}
 
 						port.LastCheckStatusLock.Unlock()
+
+						port.State.SetUp(!v.Failing)
+						if v.Paused {
+							atomic.StoreInt32(&port.Paused, 1)
+						}
 						break
 					}
 				}
@@ -60,12 +83,28 @@ func (m *Module) loadState() error {
 	return err
 }
 
+func isLegacyState(loadedItems []TcpPortsCheckerStateItem) bool {
+	hashCodes := make([]uint64, len(loadedItems))
+	for idx := range loadedItems {
+		hashCodes[idx] = loadedItems[idx].HashCode
+	}
+	return state.IsLegacyHashCode(hashCodes)
+}
+
 func (m *Module) saveState() error {
+	var err error
+
+	m.tcpPortsListMtx.Lock()
 	toSave := make([]TcpPortsCheckerStateItem, len(m.tcpPortsList))
 	for idx, v := range m.tcpPortsList {
 		toSave[idx] = TcpPortsCheckerStateItem{
 			HashCode : v.HashCode,
 			Ports    : make([]TcpPortsCheckerStateItem_Port, v.PortsX.GetCardinality()),
+			Failing  : !v.State.IsUp(),
+			Paused   : atomic.LoadInt32(&v.Paused) != 0,
+		}
+		if v.Config != nil && err == nil {
+			toSave[idx].Config, err = json.Marshal(v.Config)
 		}
 
 		pIdx := 0
@@ -79,16 +118,47 @@ func (m *Module) saveState() error {
 			if v.LastCheckStatus.Contains(vPort.Port) {
 				vPort.LastCheckStatus = true
 			} else {
-				vPort.LastCheckStatus = true
+				vPort.LastCheckStatus = false
 			}
+
+			pIdx++
 		}
 		v.LastCheckStatusLock.Unlock()
 	}
+	m.tcpPortsListMtx.Unlock()
 
-	b, err := msgpack.Marshal(toSave)
+	var b []byte
+	if err == nil {
+		b, err = msgpack.Marshal(toSave)
+	}
 	if err == nil {
 		err = state.SaveStateBlob(tcpPortsCheckerStateFileName, b)
 	}
 
 	return err
 }
+
+func (m *Module) restoreTcpPort(v TcpPortsCheckerStateItem) {
+	var port settings.SettingsJSON_TcpPorts
+
+	err := json.Unmarshal(v.Config, &port)
+	if err == nil {
+		err = settings.ValidateTcpPort(&port)
+	}
+	if err != nil {
+		console.Error("Unable to restore TCP port group added at runtime. [%v]", err)
+		return
+	}
+
+	item := newTcpPortItem(&port)
+	item.HashCode = v.HashCode
+	item.Config = &port
+
+	if m.isDuplicateTcpPort(item, -1) {
+		console.Error("Unable to restore TCP port group '%v' added at runtime. [%v]", port.Name, ErrAlreadyExists)
+		return
+	}
+
+	m.tcpPortsList = append(m.tcpPortsList, item)
+	return
+}
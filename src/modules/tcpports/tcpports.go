@@ -1,11 +1,15 @@
 package tcpports
 
 import (
+	"errors"
 	"fmt"
 	"github.com/RoaringBitmap/roaring"
+	"github.com/randlabs/server-watchdog/modules/alerts"
 	"github.com/randlabs/server-watchdog/modules/logger"
 	"hash/fnv"
 	"net"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -13,30 +17,73 @@ import (
 	rp "github.com/randlabs/rundown-protection"
 	"github.com/randlabs/server-watchdog/console"
 	"github.com/randlabs/server-watchdog/settings"
+	"github.com/randlabs/server-watchdog/utils/statetracker"
+	"github.com/randlabs/server-watchdog/utils/timeutils"
 )
 
 //------------------------------------------------------------------------------
 
 type Module struct {
-	shutdownSignal chan struct{}
-	tcpPortsList   []TcpPortItem
-	r              rp.RundownProtection
-	checkDone      chan struct{}
+	shutdownSignal  chan struct{}
+	tcpPortsListMtx sync.Mutex
+	tcpPortsList    []*TcpPortItem
+	r               rp.RundownProtection
+	checkDone       chan struct{}
+	listChanged     chan struct{}
+	saveStateMtx    sync.Mutex //serializes the writes to the state file
 }
 
 type TcpPortItem struct {
-	HashCode            uint64
-	Name                string
-	Address             string
-	PortsX              *roaring.Bitmap
-	Timeout             time.Duration
-	Channel             string
-	Severity            string
-	CheckPeriod         time.Duration
-	NextCheckPeriod     time.Duration
-	LastCheckStatusLock sync.Mutex
-	LastCheckStatus     *roaring.Bitmap
-	CheckInProgress     int32
+	//the fields accessed atomically go first to keep them 64-bit aligned on 32-bit platforms
+	LastCheckTime        int64
+	NextCheckTime        int64
+	LastNotificationTime int64
+	HashCode             uint64 //used as id so it is kept when the settings are updated
+	ConfigHash           uint64
+	Name                 string
+	Address              string
+	PortsX               *roaring.Bitmap
+	Timeout              time.Duration
+	Channel              string
+	Severity             string
+	CheckPeriod          time.Duration
+	NextCheckPeriod      time.Duration
+	LastCheckStatusLock  sync.Mutex
+	LastCheckStatus      *roaring.Bitmap
+	CheckInProgress      int32
+	ConsecutiveFailures  uint32
+	Paused               int32
+	State                *statetracker.Tracker
+	Config               *settings.SettingsJSON_TcpPorts //only set on groups added at runtime
+}
+
+type TcpPortStatus struct {
+	Id                  string               `json:"id"`
+	Source              string               `json:"source"`
+	Config              TcpPortStatus_Config `json:"config"`
+	Ports               []TcpPortStatus_Port `json:"ports"`
+	Failing             bool                 `json:"failing"`
+	Flapping            bool                 `json:"flapping"`
+	Paused              bool                 `json:"paused"`
+	LastCheck           *time.Time           `json:"lastCheck,omitempty"`
+	NextCheck           *time.Time           `json:"nextCheck,omitempty"`
+	ConsecutiveFailures uint32               `json:"consecutiveFailures"`
+	LastNotification    *time.Time           `json:"lastNotification,omitempty"`
+}
+
+type TcpPortStatus_Config struct {
+	Name        string `json:"name"`
+	Address     string `json:"address"`
+	Ports       string `json:"ports"`
+	CheckPeriod string `json:"checkPeriod"`
+	Timeout     string `json:"timeout"`
+	Channel     string `json:"channel"`
+	Severity    string `json:"severity"`
+}
+
+type TcpPortStatus_Port struct {
+	Port uint32 `json:"port"`
+	Up   bool   `json:"up"`
 }
 
 //------------------------------------------------------------------------------
@@ -44,6 +91,12 @@ type TcpPortItem struct {
 var module *Module
 var lock sync.RWMutex
 
+var ErrNotFound = errors.New("TCP port group not found")
+var ErrAlreadyExists = errors.New("A TCP port group with the same settings already exists")
+var ErrReadOnly = errors.New("TCP port groups defined in the settings file cannot be modified or deleted")
+var ErrShuttingDown = errors.New("Module is shutting down")
+var ErrNotActive = errors.New("Module is not active")
+
 //------------------------------------------------------------------------------
 
 func Start() error {
@@ -53,31 +106,13 @@ func Start() error {
 	module.r.Initialize()
 
 	//build tcp ports list from settings
-	module.tcpPortsList = make([]TcpPortItem, len(settings.Config.TcpPorts))
-	for idx, port := range settings.Config.TcpPorts {
-		h := fnv.New64a()
-		h.Sum([]byte(port.Name))
-		h.Sum([]byte(port.PortsX.String()))
-		h.Sum([]byte(port.Channel))
-		h.Sum([]byte(port.Severity))
-
-		module.tcpPortsList[idx] = TcpPortItem{
-			h.Sum64(),
-			port.Name,
-			port.Address,
-			port.PortsX,
-			port.TimeoutX,
-			port.Channel,
-			port.Severity,
-			port.CheckPeriodX,
-			0,
-			sync.Mutex{},
-			roaring.New(),
-			0,
-		}
+	module.tcpPortsList = make([]*TcpPortItem, len(settings.Config.TcpPorts))
+	for idx := range settings.Config.TcpPorts {
+		module.tcpPortsList[idx] = newTcpPortItem(&settings.Config.TcpPorts[idx])
 	}
 
 	module.checkDone = make(chan struct{})
+	module.listChanged = make(chan struct{}, 1)
 
 	//load stored state
 	err := module.loadState()
@@ -118,54 +153,52 @@ func Run(wg sync.WaitGroup) {
 
 		if localModule.r.Acquire() {
 			go func() {
-				if len(localModule.tcpPortsList) > 0 {
-					var timeToWait time.Duration
-
-					loop := true
-					for loop {
-						var start time.Time
-						var elapsed time.Duration
-
-						//find next web to check
-						timeToWait = -1
-						for i := len(localModule.tcpPortsList); i > 0; i-- {
-							if atomic.LoadInt32(&localModule.tcpPortsList[i - 1].CheckInProgress) == 0 {
-								if timeToWait < 0 || timeToWait > localModule.tcpPortsList[i - 1].NextCheckPeriod {
-									timeToWait = localModule.tcpPortsList[i - 1].NextCheckPeriod
-								}
-							}
-						}
+				var timeToWait time.Duration
 
-						start = time.Now()
-						if timeToWait >= 0 {
-							select {
-							case <-localModule.shutdownSignal:
-								loop = false
+				loop := true
+				for loop {
+					var start time.Time
+					var elapsed time.Duration
 
-							case <-time.After(timeToWait):
-								//check webs when the time to wait elapses
-								localModule.checkTcpPorts(timeToWait)
+					//find next tcp port group to check
+					timeToWait = localModule.getTimeToWait()
 
-							case <-localModule.checkDone:
-								//if a web check has finished, check again
-								elapsed = time.Since(start)
-								localModule.checkTcpPorts(elapsed)
-							}
-						} else {
-							select {
-							case <-localModule.shutdownSignal:
-								loop = false
-
-							case <-localModule.checkDone:
-								//if a web check has finished, check for others
-								elapsed = time.Since(start)
-								localModule.checkTcpPorts(elapsed)
-							}
+					start = time.Now()
+					if timeToWait >= 0 {
+						select {
+						case <-localModule.shutdownSignal:
+							loop = false
+
+						case <-time.After(timeToWait):
+							//check groups when the time to wait elapses
+							localModule.checkTcpPorts(timeToWait)
+
+						case <-localModule.checkDone:
+							//if a group check has finished, check again
+							elapsed = time.Since(start)
+							localModule.checkTcpPorts(elapsed)
+
+						case <-localModule.listChanged:
+							//if a group was added, modified or resumed, check again
+							elapsed = time.Since(start)
+							localModule.checkTcpPorts(elapsed)
+						}
+					} else {
+						select {
+						case <-localModule.shutdownSignal:
+							loop = false
+
+						case <-localModule.checkDone:
+							//if a group check has finished, check for others
+							elapsed = time.Since(start)
+							localModule.checkTcpPorts(elapsed)
+
+						case <-localModule.listChanged:
+							//if a group was added, modified or resumed, check again
+							elapsed = time.Since(start)
+							localModule.checkTcpPorts(elapsed)
 						}
 					}
-				} else {
-					//no webs to check, just wait for the shutdown signal
-					<-localModule.shutdownSignal
 				}
 
 				localModule.r.Release()
@@ -180,16 +213,344 @@ func Run(wg sync.WaitGroup) {
 	return
 }
 
+// GetStatus returns the current status of the monitored tcp port groups
+func GetStatus() []TcpPortStatus {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return make([]TcpPortStatus, 0)
+	}
+
+	localModule.tcpPortsListMtx.Lock()
+	defer localModule.tcpPortsListMtx.Unlock()
+
+	list := make([]TcpPortStatus, len(localModule.tcpPortsList))
+	for idx, port := range localModule.tcpPortsList {
+		list[idx] = TcpPortStatus{
+			Id:     strconv.FormatUint(port.HashCode, 16),
+			Source: sourceString(port.Config),
+			Config: TcpPortStatus_Config{
+				Name:        port.Name,
+				Address:     port.Address,
+				Ports:       portsString(port.PortsX),
+				CheckPeriod: port.CheckPeriod.String(),
+				Timeout:     port.Timeout.String(),
+				Channel:     port.Channel,
+				Severity:    port.Severity,
+			},
+			Ports:               make([]TcpPortStatus_Port, 0, port.PortsX.GetCardinality()),
+			Failing:             !port.State.IsUp(),
+			Flapping:            port.State.IsFlapping(),
+			Paused:              atomic.LoadInt32(&port.Paused) != 0,
+			LastCheck:           timeutils.FromUnixNano(atomic.LoadInt64(&port.LastCheckTime)),
+			NextCheck:           timeutils.FromUnixNano(atomic.LoadInt64(&port.NextCheckTime)),
+			ConsecutiveFailures: atomic.LoadUint32(&port.ConsecutiveFailures),
+			LastNotification:    timeutils.FromUnixNano(atomic.LoadInt64(&port.LastNotificationTime)),
+		}
+
+		port.LastCheckStatusLock.Lock()
+		it := port.PortsX.Iterator()
+		for it.HasNext() {
+			portNum := it.Next()
+
+			list[idx].Ports = append(list[idx].Ports, TcpPortStatus_Port{
+				Port: portNum,
+				Up:   port.LastCheckStatus.Contains(portNum),
+			})
+		}
+		port.LastCheckStatusLock.Unlock()
+	}
+
+	return list
+}
+
+// AddTcpPort starts monitoring a new TCP port group and returns its id. The group is stored and restored on restart.
+func AddTcpPort(port settings.SettingsJSON_TcpPorts) (string, error) {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return "", ErrNotActive
+	}
+
+	err := settings.ValidateTcpPort(&port)
+	if err != nil {
+		return "", err
+	}
+
+	id := ""
+	if localModule.r.Acquire() {
+		item := newTcpPortItem(&port)
+		item.Config = &port
+
+		localModule.tcpPortsListMtx.Lock()
+		if !localModule.isDuplicateTcpPort(item, -1) {
+			localModule.tcpPortsList = append(localModule.tcpPortsList, item)
+			id = strconv.FormatUint(item.HashCode, 16)
+		} else {
+			err = ErrAlreadyExists
+		}
+		localModule.tcpPortsListMtx.Unlock()
+
+		if err == nil {
+			localModule.runSaveState()
+			localModule.signalListChanged()
+		}
+
+		localModule.r.Release()
+	} else {
+		err = ErrShuttingDown
+	}
+
+	return id, err
+}
+
+// UpdateTcpPort replaces the settings of a TCP port group added at runtime. The id is kept.
+func UpdateTcpPort(id string, port settings.SettingsJSON_TcpPorts) error {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return ErrNotActive
+	}
+
+	err := settings.ValidateTcpPort(&port)
+	if err != nil {
+		return err
+	}
+
+	if localModule.r.Acquire() {
+		localModule.tcpPortsListMtx.Lock()
+		idx := localModule.findTcpPortById(id)
+		if idx >= 0 {
+			oldItem := localModule.tcpPortsList[idx]
+			if oldItem.Config != nil {
+				item := newTcpPortItem(&port)
+				item.HashCode = oldItem.HashCode
+				item.Paused = atomic.LoadInt32(&oldItem.Paused)
+				item.Config = &port
+
+				if !localModule.isDuplicateTcpPort(item, idx) {
+					localModule.tcpPortsList[idx] = item
+				} else {
+					err = ErrAlreadyExists
+				}
+			} else {
+				err = ErrReadOnly
+			}
+		} else {
+			err = ErrNotFound
+		}
+		localModule.tcpPortsListMtx.Unlock()
+
+		if err == nil {
+			localModule.runSaveState()
+			localModule.signalListChanged()
+		}
+
+		localModule.r.Release()
+	} else {
+		err = ErrShuttingDown
+	}
+
+	return err
+}
+
+// RemoveTcpPort stops monitoring a TCP port group added at runtime
+func RemoveTcpPort(id string) error {
+	var hashCode uint64
+	var err error
+
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return ErrNotActive
+	}
+
+	if localModule.r.Acquire() {
+		localModule.tcpPortsListMtx.Lock()
+		idx := localModule.findTcpPortById(id)
+		if idx >= 0 {
+			if localModule.tcpPortsList[idx].Config != nil {
+				listLen := len(localModule.tcpPortsList)
+
+				hashCode = localModule.tcpPortsList[idx].HashCode
+
+				copy(localModule.tcpPortsList[idx:], localModule.tcpPortsList[idx + 1:])
+				localModule.tcpPortsList[listLen - 1] = nil
+				localModule.tcpPortsList = localModule.tcpPortsList[:(listLen - 1)]
+			} else {
+				err = ErrReadOnly
+			}
+		} else {
+			err = ErrNotFound
+		}
+		localModule.tcpPortsListMtx.Unlock()
+
+		if err == nil {
+			alerts.Resolve(hashCode)
+			localModule.runSaveState()
+		}
+
+		localModule.r.Release()
+	} else {
+		err = ErrShuttingDown
+	}
+
+	return err
+}
+
+// PauseTcpPort suspends or resumes the checks of a TCP port group. Groups defined in the settings file can also be
+// paused.
+func PauseTcpPort(id string, pause bool) error {
+	var err error
+
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return ErrNotActive
+	}
+
+	if localModule.r.Acquire() {
+		changed := false
+
+		localModule.tcpPortsListMtx.Lock()
+		idx := localModule.findTcpPortById(id)
+		if idx >= 0 {
+			port := localModule.tcpPortsList[idx]
+
+			if pause {
+				changed = atomic.CompareAndSwapInt32(&port.Paused, 0, 1)
+				if changed {
+					atomic.StoreInt64(&port.NextCheckTime, 0)
+				}
+			} else {
+				changed = atomic.CompareAndSwapInt32(&port.Paused, 1, 0)
+				if changed {
+					//check it as soon as possible
+					port.NextCheckPeriod = 0
+				}
+			}
+		} else {
+			err = ErrNotFound
+		}
+		localModule.tcpPortsListMtx.Unlock()
+
+		if changed {
+			localModule.runSaveState()
+			localModule.signalListChanged()
+		}
+
+		localModule.r.Release()
+	} else {
+		err = ErrShuttingDown
+	}
+
+	return err
+}
+
 //------------------------------------------------------------------------------
 
+func newTcpPortItem(port *settings.SettingsJSON_TcpPorts) *TcpPortItem {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(port.Name))
+	_, _ = h.Write([]byte(port.PortsX.String()))
+	_, _ = h.Write([]byte(port.Channel))
+	_, _ = h.Write([]byte(port.Severity))
+
+	return &TcpPortItem{
+		HashCode:        h.Sum64(),
+		ConfigHash:      h.Sum64(),
+		Name:            port.Name,
+		Address:         port.Address,
+		PortsX:          port.PortsX,
+		Timeout:         port.TimeoutX,
+		Channel:         port.Channel,
+		Severity:        port.Severity,
+		CheckPeriod:     port.CheckPeriodX,
+		LastCheckStatus: roaring.New(),
+		State:           statetracker.New(port.FailureThreshold, port.SuccessThreshold, port.FlapThreshold,
+		                                  port.FlapWindowX),
+	}
+}
+
+// Must be called with the list lock held. Ids are kept on updates so an item can have the id of other settings.
+func (m *Module) isDuplicateTcpPort(item *TcpPortItem, skipIdx int) bool {
+	for idx, port := range m.tcpPortsList {
+		if idx != skipIdx && (port.HashCode == item.HashCode || port.ConfigHash == item.ConfigHash) {
+			return true
+		}
+	}
+	return false
+}
+
+// Must be called with the list lock held
+func (m *Module) findTcpPort(hashCode uint64) int {
+	for idx, port := range m.tcpPortsList {
+		if port.HashCode == hashCode {
+			return idx
+		}
+	}
+	return -1
+}
+
+// Must be called with the list lock held
+func (m *Module) findTcpPortById(id string) int {
+	hashCode, err := strconv.ParseUint(id, 16, 64)
+	if err != nil {
+		return -1
+	}
+	return m.findTcpPort(hashCode)
+}
+
+func (m *Module) signalListChanged() {
+	select {
+	case m.listChanged <- struct{}{}:
+	default:
+	}
+	return
+}
+
+func (m *Module) getTimeToWait() time.Duration {
+	timeToWait := time.Duration(-1)
+
+	m.tcpPortsListMtx.Lock()
+	for _, port := range m.tcpPortsList {
+		if atomic.LoadInt32(&port.CheckInProgress) == 0 && atomic.LoadInt32(&port.Paused) == 0 {
+			if timeToWait < 0 || timeToWait > port.NextCheckPeriod {
+				timeToWait = port.NextCheckPeriod
+			}
+		}
+	}
+	m.tcpPortsListMtx.Unlock()
+
+	return timeToWait
+}
+
 func (m *Module) checkTcpPorts(elapsedTime time.Duration) {
+	m.tcpPortsListMtx.Lock()
+	defer m.tcpPortsListMtx.Unlock()
+
 	for idx := len(m.tcpPortsList); idx > 0; idx-- {
-		port := &m.tcpPortsList[idx - 1]
+		port := m.tcpPortsList[idx - 1]
+
+		if atomic.LoadInt32(&port.Paused) != 0 {
+			continue
+		}
 
 		if atomic.CompareAndSwapInt32(&port.CheckInProgress, 0, 1) {
 			if elapsedTime >= port.NextCheckPeriod {
 				//reset timer
 				port.NextCheckPeriod = port.CheckPeriod
+				atomic.StoreInt64(&port.NextCheckTime, time.Now().Add(port.CheckPeriod).UnixNano())
 
 				//check this tcp port set
 				if m.r.Acquire() {
@@ -223,8 +584,8 @@ func (m *Module) checkTcpPorts(elapsedTime time.Duration) {
 							wg.Wait()
 						}
 
-						dropDetected := false
 						doSave := false
+						allUp := true
 
 						port.LastCheckStatusLock.Lock()
 
@@ -239,8 +600,8 @@ func (m *Module) checkTcpPorts(elapsedTime time.Duration) {
 									port.LastCheckStatus.Add(portNum)
 								}
 							} else {
+								allUp = false
 								if port.LastCheckStatus.Contains(portNum) {
-									dropDetected = true
 									doSave = true
 									port.LastCheckStatus.Remove(portNum)
 								}
@@ -251,21 +612,40 @@ func (m *Module) checkTcpPorts(elapsedTime time.Duration) {
 
 						port.LastCheckStatusLock.Unlock()
 
-						if doSave {
-							m.runSaveState()
+						atomic.StoreInt64(&port.LastCheckTime, time.Now().UnixNano())
+						if allUp {
+							atomic.StoreUint32(&port.ConsecutiveFailures, 0)
+						} else {
+							atomic.AddUint32(&port.ConsecutiveFailures, 1)
 						}
 
-						//notify only if status changed from true to false
-						if dropDetected {
-							if m.r.Acquire() {
-								go func(port *TcpPortItem) {
-									_ = logger.Log(port.Severity, port.Channel, "TCP Ports of group '%s' are down.", port.Name)
+						//the group is considered down if any of its ports is down. notify only if the confirmed
+						//status changed from up to down or on flapping changes
+						ev := port.State.Update(allUp)
+						switch ev {
+						case statetracker.EventDown:
+							m.runAlert(port, "TCP Ports of group '%s' are down.", port.Name)
+
+						case statetracker.EventUp:
+							alerts.Resolve(port.HashCode)
+
+						case statetracker.EventFlappingStarted:
+							m.runNotify(port, port.Severity, "TCP Ports of group '%s' are flapping.", port.Name)
 
-									m.r.Release()
-								}(port)
+						case statetracker.EventFlappingStopped:
+							if port.State.IsUp() {
+								alerts.Resolve(port.HashCode)
+								m.runNotify(port, "info", "TCP Ports of group '%s' stopped flapping and are up.",
+								            port.Name)
+							} else {
+								m.runAlert(port, "TCP Ports of group '%s' stopped flapping and are down.", port.Name)
 							}
 						}
 
+						if doSave || ev != statetracker.EventNone {
+							m.runSaveState()
+						}
+
 						atomic.StoreInt32(&port.CheckInProgress, 0)
 
 						select {
@@ -289,10 +669,47 @@ func (m *Module) checkTcpPorts(elapsedTime time.Duration) {
 	return
 }
 
+func sourceString(cfg *settings.SettingsJSON_TcpPorts) string {
+	if cfg != nil {
+		return "api"
+	}
+	return "settings"
+}
+
+func portsString(ports *roaring.Bitmap) string {
+	list := make([]string, 0, ports.GetCardinality())
+	it := ports.Iterator()
+	for it.HasNext() {
+		list = append(list, strconv.FormatUint(uint64(it.Next()), 10))
+	}
+	return strings.Join(list, ",")
+}
+
+func (m *Module) runAlert(port *TcpPortItem, format string, a ...interface{}) {
+	id := alerts.Open("tcpPorts", port.HashCode, port.Name, port.Channel, port.Severity, format, a...)
+	m.runNotify(port, port.Severity, format + " [alert %v]", append(a, id)...)
+	return
+}
+
+func (m *Module) runNotify(port *TcpPortItem, severity string, format string, a ...interface{}) {
+	if m.r.Acquire() {
+		go func(port *TcpPortItem) {
+			atomic.StoreInt64(&port.LastNotificationTime, time.Now().UnixNano())
+
+			_ = logger.LogTarget("tcpPorts", port.Name, severity, port.Channel, format, a...)
+
+			m.r.Release()
+		}(port)
+	}
+	return
+}
+
 func (m *Module) runSaveState() {
 	if m.r.Acquire() {
 		go func(m *Module) {
+			m.saveStateMtx.Lock()
 			err := m.saveState()
+			m.saveStateMtx.Unlock()
 
 			if err != nil {
 				console.Error("Unable to save tcp ports checker state. [%v]", err)
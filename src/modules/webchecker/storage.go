@@ -1,8 +1,11 @@
 package webchecker
 
 import (
+	"encoding/json"
 	"sync/atomic"
 
+	"github.com/randlabs/server-watchdog/console"
+	"github.com/randlabs/server-watchdog/settings"
 	"github.com/randlabs/server-watchdog/utils/state"
 	"github.com/vmihailenco/msgpack/v4"
 )
@@ -12,6 +15,8 @@ import (
 type WebCheckerStateItem struct {
 	HashCode         uint64
 	LastCheckStatus  bool
+	Paused           bool
+	Config           []byte //json encoded settings of webs added at runtime
 }
 
 //------------------------------------------------------------------------------
@@ -29,9 +34,16 @@ func (m *Module) loadState() error {
 
 		err = msgpack.Unmarshal(b, &loadedItems)
 		if err == nil {
-			for idx := range m.websList {
-				web := &m.websList[idx]
+			m.migrateLegacyState(loadedItems)
 
+			//restore webs added at runtime
+			for _, v := range loadedItems {
+				if len(v.Config) > 0 {
+					m.restoreWeb(v)
+				}
+			}
+
+			for _, web := range m.websList {
 				for _, v := range loadedItems {
 					if web.HashCode == v.HashCode {
 						if v.LastCheckStatus {
@@ -39,6 +51,10 @@ func (m *Module) loadState() error {
 						} else {
 							atomic.StoreInt32(&web.LastCheckStatus, 0)
 						}
+						web.State.SetUp(v.LastCheckStatus)
+						if v.Paused {
+							atomic.StoreInt32(&web.Paused, 1)
+						}
 						break
 					}
 				}
@@ -49,24 +65,75 @@ func (m *Module) loadState() error {
 	return err
 }
 
+// migrateLegacyState sets the current hash codes to a state saved by a version prior to the status API. Those
+// versions stored the same hash code for every web, in the order they are defined in the settings file, so they are
+// matched by position.
+func (m *Module) migrateLegacyState(loadedItems []WebCheckerStateItem) {
+	hashCodes := make([]uint64, len(loadedItems))
+	for idx := range loadedItems {
+		hashCodes[idx] = loadedItems[idx].HashCode
+	}
+	if !state.IsLegacyHashCode(hashCodes) {
+		return
+	}
+
+	for idx := range loadedItems {
+		if idx < len(m.websList) {
+			loadedItems[idx].HashCode = m.websList[idx].HashCode
+		}
+	}
+	return
+}
+
 func (m *Module) saveState() error {
+	var err error
+
+	m.websListMtx.Lock()
 	toSave := make([]WebCheckerStateItem, len(m.websList))
 	for idx, v := range m.websList {
-		status := false
-		if atomic.LoadInt32(&v.LastCheckStatus) != 0 {
-			status = true
-		}
-
 		toSave[idx] = WebCheckerStateItem{
 			HashCode        : v.HashCode,
-			LastCheckStatus : status,
+			LastCheckStatus : v.State.IsUp(),
+			Paused          : atomic.LoadInt32(&v.Paused) != 0,
+		}
+		if v.Config != nil && err == nil {
+			toSave[idx].Config, err = json.Marshal(v.Config)
 		}
 	}
+	m.websListMtx.Unlock()
 
-	b, err := msgpack.Marshal(toSave)
+	var b []byte
+	if err == nil {
+		b, err = msgpack.Marshal(toSave)
+	}
 	if err == nil {
 		err = state.SaveStateBlob(webCheckerStateFileName, b)
 	}
 
 	return err
 }
+
+func (m *Module) restoreWeb(v WebCheckerStateItem) {
+	var web settings.SettingsJSON_Webs
+
+	err := json.Unmarshal(v.Config, &web)
+	if err == nil {
+		err = settings.ValidateWeb(&web)
+	}
+	if err != nil {
+		console.Error("Unable to restore web added at runtime. [%v]", err)
+		return
+	}
+
+	item := newWebItem(&web)
+	item.HashCode = v.HashCode
+	item.Config = &web
+
+	if m.isDuplicateWeb(item, -1) {
+		console.Error("Unable to restore web '%v' added at runtime. [%v]", web.Url, ErrAlreadyExists)
+		return
+	}
+
+	m.websList = append(m.websList, item)
+	return
+}
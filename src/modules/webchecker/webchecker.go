@@ -1,11 +1,14 @@
 package webchecker
 
 import (
+	"errors"
+	"github.com/randlabs/server-watchdog/modules/alerts"
 	"github.com/randlabs/server-watchdog/modules/logger"
 	"hash/fnv"
 	"io/ioutil"
 	"net/http"
 	"regexp"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -13,29 +16,69 @@ import (
 	rp "github.com/randlabs/rundown-protection"
 	"github.com/randlabs/server-watchdog/console"
 	"github.com/randlabs/server-watchdog/settings"
+	"github.com/randlabs/server-watchdog/utils/statetracker"
+	"github.com/randlabs/server-watchdog/utils/timeutils"
 )
 
 //------------------------------------------------------------------------------
 
 type Module struct {
 	shutdownSignal chan struct{}
-	websList       []WebItem
+	websListMtx    sync.Mutex
+	websList       []*WebItem
 	r              rp.RundownProtection
 	checkDone      chan struct{}
+	listChanged    chan struct{}
+	saveStateMtx   sync.Mutex //serializes the writes to the state file
 }
 
 type WebItem struct {
-	HashCode        uint64
-	Url             string
-	Headers         map[string]string
-	Content         []WebItem_Content
-	Timeout         time.Duration
-	Channel         string
-	Severity        string
-	CheckPeriod     time.Duration
-	NextCheckPeriod time.Duration
-	LastCheckStatus int32
-	CheckInProgress int32
+	//the fields accessed atomically go first to keep them 64-bit aligned on 32-bit platforms
+	LastResponseTime     int64
+	LastCheckTime        int64
+	NextCheckTime        int64
+	LastNotificationTime int64
+	HashCode             uint64 //used as id so it is kept when the settings are updated
+	ConfigHash           uint64
+	Url                  string
+	Headers              map[string]string
+	Content              []WebItem_Content
+	Timeout              time.Duration
+	Channel              string
+	Severity             string
+	CheckPeriod          time.Duration
+	NextCheckPeriod      time.Duration
+	LastCheckStatus      int32
+	CheckInProgress      int32
+	ConsecutiveFailures  uint32
+	Paused               int32
+	State                *statetracker.Tracker
+	Config               *settings.SettingsJSON_Webs //only set on webs added at runtime
+}
+
+type WebStatus struct {
+	Id                  string           `json:"id"`
+	Source              string           `json:"source"`
+	Config              WebStatus_Config `json:"config"`
+	Status              string           `json:"status"`
+	Failing             bool             `json:"failing"`
+	Flapping            bool             `json:"flapping"`
+	Paused              bool             `json:"paused"`
+	ResponseTime        time.Duration    `json:"-"`
+	ResponseTimeMs      int64            `json:"responseTimeMs"`
+	LastCheck           *time.Time       `json:"lastCheck,omitempty"`
+	NextCheck           *time.Time       `json:"nextCheck,omitempty"`
+	ConsecutiveFailures uint32           `json:"consecutiveFailures"`
+	LastNotification    *time.Time       `json:"lastNotification,omitempty"`
+}
+
+type WebStatus_Config struct {
+	Url         string   `json:"url"`
+	Content     []string `json:"content,omitempty"`
+	CheckPeriod string   `json:"checkPeriod"`
+	Timeout     string   `json:"timeout"`
+	Channel     string   `json:"channel"`
+	Severity    string   `json:"severity"`
 }
 
 type WebItem_Content struct {
@@ -49,6 +92,12 @@ type WebItem_Content struct {
 var module *Module
 var lock sync.RWMutex
 
+var ErrNotFound = errors.New("Web not found")
+var ErrAlreadyExists = errors.New("A web with the same settings already exists")
+var ErrReadOnly = errors.New("Webs defined in the settings file cannot be modified or deleted")
+var ErrShuttingDown = errors.New("Module is shutting down")
+var ErrNotActive = errors.New("Module is not active")
+
 //------------------------------------------------------------------------------
 
 func Start() error {
@@ -58,46 +107,13 @@ func Start() error {
 	module.r.Initialize()
 
 	//build webs list from settings
-	module.websList = make([]WebItem, len(settings.Config.Webs))
-	for idx, web := range settings.Config.Webs {
-		h := fnv.New64a()
-		h.Sum([]byte(web.Url))
-		h.Sum([]byte(web.Channel))
-		h.Sum([]byte(web.Severity))
-
-		wc := make([]WebItem_Content, len(web.Content))
-
-		for idx, c := range web.Content {
-			wc[idx].SearchRegex = c.SearchRegex
-			wc[idx].CheckChanges = c.CheckChanges
-			wc[idx].LastContent = make([]string, len(c.CheckChanges))
-
-			h.Sum([]byte(c.SearchRegex.String()))
-		}
-
-		wh := map[string]string{}
-		if web.Headers != nil {
-			for key, value := range *web.Headers {
-				wh[key] = value
-			}
-		}
-
-		module.websList[idx] = WebItem{
-			h.Sum64(),
-			web.Url,
-			wh,
-			wc,
-			web.TimeoutX,
-			web.Channel,
-			web.Severity,
-			web.CheckPeriodX,
-			0,
-			1,
-			0,
-		}
+	module.websList = make([]*WebItem, len(settings.Config.Webs))
+	for idx := range settings.Config.Webs {
+		module.websList[idx] = newWebItem(&settings.Config.Webs[idx])
 	}
 
 	module.checkDone = make(chan struct{})
+	module.listChanged = make(chan struct{}, 1)
 
 	//load stored state
 	err := module.loadState()
@@ -138,54 +154,52 @@ func Run(wg sync.WaitGroup) {
 
 		if localModule.r.Acquire() {
 			go func() {
-				if len(localModule.websList) > 0 {
-					var timeToWait time.Duration
-
-					loop := true
-					for loop {
-						var start time.Time
-						var elapsed time.Duration
-
-						//find next web to check
-						timeToWait = -1
-						for i := len(localModule.websList); i > 0; i-- {
-							if atomic.LoadInt32(&localModule.websList[i - 1].CheckInProgress) == 0 {
-								if timeToWait < 0 || timeToWait > localModule.websList[i - 1].NextCheckPeriod {
-									timeToWait = localModule.websList[i - 1].NextCheckPeriod
-								}
-							}
-						}
+				var timeToWait time.Duration
 
-						start = time.Now()
-						if timeToWait >= 0 {
-							select {
-							case <-localModule.shutdownSignal:
-								loop = false
+				loop := true
+				for loop {
+					var start time.Time
+					var elapsed time.Duration
 
-							case <-time.After(timeToWait):
-								//check webs when the time to wait elapses
-								localModule.checkWebs(timeToWait)
+					//find next web to check
+					timeToWait = localModule.getTimeToWait()
 
-							case <-localModule.checkDone:
-								//if a web check has finished, check again
-								elapsed = time.Since(start)
-								localModule.checkWebs(elapsed)
-							}
-						} else {
-							select {
-							case <-localModule.shutdownSignal:
-								loop = false
-
-							case <-localModule.checkDone:
-								//if a web check has finished, check for others
-								elapsed = time.Since(start)
-								localModule.checkWebs(elapsed)
-							}
+					start = time.Now()
+					if timeToWait >= 0 {
+						select {
+						case <-localModule.shutdownSignal:
+							loop = false
+
+						case <-time.After(timeToWait):
+							//check webs when the time to wait elapses
+							localModule.checkWebs(timeToWait)
+
+						case <-localModule.checkDone:
+							//if a web check has finished, check again
+							elapsed = time.Since(start)
+							localModule.checkWebs(elapsed)
+
+						case <-localModule.listChanged:
+							//if a web was added, modified or resumed, check again
+							elapsed = time.Since(start)
+							localModule.checkWebs(elapsed)
+						}
+					} else {
+						select {
+						case <-localModule.shutdownSignal:
+							loop = false
+
+						case <-localModule.checkDone:
+							//if a web check has finished, check for others
+							elapsed = time.Since(start)
+							localModule.checkWebs(elapsed)
+
+						case <-localModule.listChanged:
+							//if a web was added, modified or resumed, check again
+							elapsed = time.Since(start)
+							localModule.checkWebs(elapsed)
 						}
 					}
-				} else {
-					//no webs to check, just wait for the shutdown signal
-					<-localModule.shutdownSignal
 				}
 
 				localModule.r.Release()
@@ -200,16 +214,351 @@ func Run(wg sync.WaitGroup) {
 	return
 }
 
+// GetStatus returns the current status of the monitored webs
+func GetStatus() []WebStatus {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return make([]WebStatus, 0)
+	}
+
+	localModule.websListMtx.Lock()
+	defer localModule.websListMtx.Unlock()
+
+	list := make([]WebStatus, len(localModule.websList))
+	for idx, web := range localModule.websList {
+		list[idx] = WebStatus{
+			Id:     strconv.FormatUint(web.HashCode, 16),
+			Source: sourceString(web.Config),
+			Config: WebStatus_Config{
+				Url:         web.Url,
+				Content:     make([]string, len(web.Content)),
+				CheckPeriod: web.CheckPeriod.String(),
+				Timeout:     web.Timeout.String(),
+				Channel:     web.Channel,
+				Severity:    web.Severity,
+			},
+			Status:              statusString(atomic.LoadInt32(&web.LastCheckStatus)),
+			Failing:             !web.State.IsUp(),
+			Flapping:            web.State.IsFlapping(),
+			Paused:              atomic.LoadInt32(&web.Paused) != 0,
+			ResponseTime:        time.Duration(atomic.LoadInt64(&web.LastResponseTime)),
+			LastCheck:           timeutils.FromUnixNano(atomic.LoadInt64(&web.LastCheckTime)),
+			NextCheck:           timeutils.FromUnixNano(atomic.LoadInt64(&web.NextCheckTime)),
+			ConsecutiveFailures: atomic.LoadUint32(&web.ConsecutiveFailures),
+			LastNotification:    timeutils.FromUnixNano(atomic.LoadInt64(&web.LastNotificationTime)),
+		}
+		list[idx].ResponseTimeMs = int64(list[idx].ResponseTime / time.Millisecond)
+		for contentIdx := range web.Content {
+			list[idx].Config.Content[contentIdx] = web.Content[contentIdx].SearchRegex.String()
+		}
+	}
+
+	return list
+}
+
+// AddWeb starts monitoring a new web and returns its id. The web is stored and restored on restart.
+func AddWeb(web settings.SettingsJSON_Webs) (string, error) {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return "", ErrNotActive
+	}
+
+	err := settings.ValidateWeb(&web)
+	if err != nil {
+		return "", err
+	}
+
+	id := ""
+	if localModule.r.Acquire() {
+		item := newWebItem(&web)
+		item.Config = &web
+
+		localModule.websListMtx.Lock()
+		if !localModule.isDuplicateWeb(item, -1) {
+			localModule.websList = append(localModule.websList, item)
+			id = strconv.FormatUint(item.HashCode, 16)
+		} else {
+			err = ErrAlreadyExists
+		}
+		localModule.websListMtx.Unlock()
+
+		if err == nil {
+			localModule.runSaveState()
+			localModule.signalListChanged()
+		}
+
+		localModule.r.Release()
+	} else {
+		err = ErrShuttingDown
+	}
+
+	return id, err
+}
+
+// UpdateWeb replaces the settings of a web added at runtime. The id is kept.
+func UpdateWeb(id string, web settings.SettingsJSON_Webs) error {
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return ErrNotActive
+	}
+
+	err := settings.ValidateWeb(&web)
+	if err != nil {
+		return err
+	}
+
+	if localModule.r.Acquire() {
+		localModule.websListMtx.Lock()
+		idx := localModule.findWebById(id)
+		if idx >= 0 {
+			oldItem := localModule.websList[idx]
+			if oldItem.Config != nil {
+				item := newWebItem(&web)
+				item.HashCode = oldItem.HashCode
+				item.Paused = atomic.LoadInt32(&oldItem.Paused)
+				item.Config = &web
+
+				if !localModule.isDuplicateWeb(item, idx) {
+					localModule.websList[idx] = item
+				} else {
+					err = ErrAlreadyExists
+				}
+			} else {
+				err = ErrReadOnly
+			}
+		} else {
+			err = ErrNotFound
+		}
+		localModule.websListMtx.Unlock()
+
+		if err == nil {
+			localModule.runSaveState()
+			localModule.signalListChanged()
+		}
+
+		localModule.r.Release()
+	} else {
+		err = ErrShuttingDown
+	}
+
+	return err
+}
+
+// RemoveWeb stops monitoring a web added at runtime
+func RemoveWeb(id string) error {
+	var hashCode uint64
+	var err error
+
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return ErrNotActive
+	}
+
+	if localModule.r.Acquire() {
+		localModule.websListMtx.Lock()
+		idx := localModule.findWebById(id)
+		if idx >= 0 {
+			if localModule.websList[idx].Config != nil {
+				listLen := len(localModule.websList)
+
+				hashCode = localModule.websList[idx].HashCode
+
+				copy(localModule.websList[idx:], localModule.websList[idx + 1:])
+				localModule.websList[listLen - 1] = nil
+				localModule.websList = localModule.websList[:(listLen - 1)]
+			} else {
+				err = ErrReadOnly
+			}
+		} else {
+			err = ErrNotFound
+		}
+		localModule.websListMtx.Unlock()
+
+		if err == nil {
+			alerts.Resolve(hashCode)
+			localModule.runSaveState()
+		}
+
+		localModule.r.Release()
+	} else {
+		err = ErrShuttingDown
+	}
+
+	return err
+}
+
+// PauseWeb suspends or resumes the checks of a web. Webs defined in the settings file can also be paused.
+func PauseWeb(id string, pause bool) error {
+	var err error
+
+	lock.RLock()
+	localModule := module
+	lock.RUnlock()
+
+	if localModule == nil {
+		return ErrNotActive
+	}
+
+	if localModule.r.Acquire() {
+		changed := false
+
+		localModule.websListMtx.Lock()
+		idx := localModule.findWebById(id)
+		if idx >= 0 {
+			web := localModule.websList[idx]
+
+			if pause {
+				changed = atomic.CompareAndSwapInt32(&web.Paused, 0, 1)
+				if changed {
+					atomic.StoreInt64(&web.NextCheckTime, 0)
+				}
+			} else {
+				changed = atomic.CompareAndSwapInt32(&web.Paused, 1, 0)
+				if changed {
+					//check it as soon as possible
+					web.NextCheckPeriod = 0
+				}
+			}
+		} else {
+			err = ErrNotFound
+		}
+		localModule.websListMtx.Unlock()
+
+		if changed {
+			localModule.runSaveState()
+			localModule.signalListChanged()
+		}
+
+		localModule.r.Release()
+	} else {
+		err = ErrShuttingDown
+	}
+
+	return err
+}
+
 //------------------------------------------------------------------------------
 
+func newWebItem(web *settings.SettingsJSON_Webs) *WebItem {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(web.Url))
+	_, _ = h.Write([]byte(web.Channel))
+	_, _ = h.Write([]byte(web.Severity))
+
+	wc := make([]WebItem_Content, len(web.Content))
+
+	for idx, c := range web.Content {
+		wc[idx].SearchRegex = c.SearchRegex
+		wc[idx].CheckChanges = c.CheckChanges
+		wc[idx].LastContent = make([]string, len(c.CheckChanges))
+
+		_, _ = h.Write([]byte(c.SearchRegex.String()))
+	}
+
+	wh := map[string]string{}
+	if web.Headers != nil {
+		for key, value := range *web.Headers {
+			wh[key] = value
+		}
+	}
+
+	return &WebItem{
+		HashCode:        h.Sum64(),
+		ConfigHash:      h.Sum64(),
+		Url:             web.Url,
+		Headers:         wh,
+		Content:         wc,
+		Timeout:         web.TimeoutX,
+		Channel:         web.Channel,
+		Severity:        web.Severity,
+		CheckPeriod:     web.CheckPeriodX,
+		LastCheckStatus: 1,
+		State:           statetracker.New(web.FailureThreshold, web.SuccessThreshold, web.FlapThreshold,
+		                                  web.FlapWindowX),
+	}
+}
+
+// Must be called with the list lock held. Ids are kept on updates so an item can have the id of other settings.
+func (m *Module) isDuplicateWeb(item *WebItem, skipIdx int) bool {
+	for idx, web := range m.websList {
+		if idx != skipIdx && (web.HashCode == item.HashCode || web.ConfigHash == item.ConfigHash) {
+			return true
+		}
+	}
+	return false
+}
+
+// Must be called with the list lock held
+func (m *Module) findWeb(hashCode uint64) int {
+	for idx, web := range m.websList {
+		if web.HashCode == hashCode {
+			return idx
+		}
+	}
+	return -1
+}
+
+// Must be called with the list lock held
+func (m *Module) findWebById(id string) int {
+	hashCode, err := strconv.ParseUint(id, 16, 64)
+	if err != nil {
+		return -1
+	}
+	return m.findWeb(hashCode)
+}
+
+func (m *Module) signalListChanged() {
+	select {
+	case m.listChanged <- struct{}{}:
+	default:
+	}
+	return
+}
+
+func (m *Module) getTimeToWait() time.Duration {
+	timeToWait := time.Duration(-1)
+
+	m.websListMtx.Lock()
+	for _, web := range m.websList {
+		if atomic.LoadInt32(&web.CheckInProgress) == 0 && atomic.LoadInt32(&web.Paused) == 0 {
+			if timeToWait < 0 || timeToWait > web.NextCheckPeriod {
+				timeToWait = web.NextCheckPeriod
+			}
+		}
+	}
+	m.websListMtx.Unlock()
+
+	return timeToWait
+}
+
 func (m *Module) checkWebs(elapsedTime time.Duration) {
+	m.websListMtx.Lock()
+	defer m.websListMtx.Unlock()
+
 	for idx := len(m.websList); idx > 0; idx-- {
-		web := &m.websList[idx - 1]
+		web := m.websList[idx - 1]
+
+		if atomic.LoadInt32(&web.Paused) != 0 {
+			continue
+		}
 
 		if atomic.CompareAndSwapInt32(&web.CheckInProgress, 0, 1) {
 			if elapsedTime >= web.NextCheckPeriod {
 				//reset timer
 				web.NextCheckPeriod = web.CheckPeriod
+				atomic.StoreInt64(&web.NextCheckTime, time.Now().Add(web.CheckPeriod).UnixNano())
 
 				//check this web
 				if m.r.Acquire() {
@@ -222,6 +571,8 @@ func (m *Module) checkWebs(elapsedTime time.Duration) {
 							Timeout: web.Timeout,
 						}
 
+						start := time.Now()
+
 						req, _ := http.NewRequest("GET", web.Url, nil)
 						for hdrKey, hdrValue := range web.Headers {
 							req.Header.Set(hdrKey, hdrValue)
@@ -282,25 +633,42 @@ func (m *Module) checkWebs(elapsedTime time.Duration) {
 							_ = resp.Body.Close()
 						}
 
-						oldStatus := atomic.SwapInt32(&web.LastCheckStatus, newStatus)
-						if oldStatus != newStatus {
-							m.runSaveState()
+						atomic.StoreInt64(&web.LastResponseTime, int64(time.Since(start)))
+						atomic.StoreInt64(&web.LastCheckTime, time.Now().UnixNano())
+						if newStatus == 1 {
+							atomic.StoreUint32(&web.ConsecutiveFailures, 0)
+						} else {
+							atomic.AddUint32(&web.ConsecutiveFailures, 1)
 						}
 
-						//notify only if status changed from true to false
-						if oldStatus == 1 && newStatus <= 0 {
-							if m.r.Acquire() {
-								go func(web *WebItem, newStatus int32) {
-									switch newStatus {
-									case 0:
-										_ = logger.Log(web.Severity, web.Channel, "Site '%s' is down.", web.Url)
-									case -1:
-										_ = logger.Log(web.Severity, web.Channel, "Site '%s' is stalled.", web.Url)
-									}
+						atomic.StoreInt32(&web.LastCheckStatus, newStatus)
 
-									m.r.Release()
-								}(web, newStatus)
+						//notify only if the confirmed status changed from up to down or on flapping changes
+						ev := web.State.Update(newStatus == 1)
+						switch ev {
+						case statetracker.EventDown:
+							if newStatus == 0 {
+								m.runAlert(web, "Site '%s' is down.", web.Url)
+							} else {
+								m.runAlert(web, "Site '%s' is stalled.", web.Url)
 							}
+
+						case statetracker.EventUp:
+							alerts.Resolve(web.HashCode)
+
+						case statetracker.EventFlappingStarted:
+							m.runNotify(web, web.Severity, "Site '%s' is flapping.", web.Url)
+
+						case statetracker.EventFlappingStopped:
+							if web.State.IsUp() {
+								alerts.Resolve(web.HashCode)
+								m.runNotify(web, "info", "Site '%s' stopped flapping and is up.", web.Url)
+							} else {
+								m.runAlert(web, "Site '%s' stopped flapping and is down.", web.Url)
+							}
+						}
+						if ev != statetracker.EventNone {
+							m.runSaveState()
 						}
 
 						atomic.StoreInt32(&web.CheckInProgress, 0)
@@ -326,10 +694,48 @@ func (m *Module) checkWebs(elapsedTime time.Duration) {
 	return
 }
 
+func sourceString(cfg *settings.SettingsJSON_Webs) string {
+	if cfg != nil {
+		return "api"
+	}
+	return "settings"
+}
+
+func statusString(status int32) string {
+	switch status {
+	case 1:
+		return "up"
+	case -1:
+		return "stalled"
+	}
+	return "down"
+}
+
+func (m *Module) runAlert(web *WebItem, format string, a ...interface{}) {
+	id := alerts.Open("webs", web.HashCode, web.Url, web.Channel, web.Severity, format, a...)
+	m.runNotify(web, web.Severity, format + " [alert %v]", append(a, id)...)
+	return
+}
+
+func (m *Module) runNotify(web *WebItem, severity string, format string, a ...interface{}) {
+	if m.r.Acquire() {
+		go func(web *WebItem) {
+			atomic.StoreInt64(&web.LastNotificationTime, time.Now().UnixNano())
+
+			_ = logger.LogTarget("webs", web.Url, severity, web.Channel, format, a...)
+
+			m.r.Release()
+		}(web)
+	}
+	return
+}
+
 func (m *Module) runSaveState() {
 	if m.r.Acquire() {
 		go func(m *Module) {
+			m.saveStateMtx.Lock()
 			err := m.saveState()
+			m.saveStateMtx.Unlock()
 
 			if err != nil {
 				console.Error("Unable to save web checker state. [%v]", err)
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"net"
+	"sync"
+)
+
+//------------------------------------------------------------------------------
+
+// perIPConnsListener limits the number of open connections from the same IP address. It is used below the TLS
+// listener because the limiter of fasthttp wraps the connections and hides their TLS state from the handlers.
+type perIPConnsListener struct {
+	// inner listener
+	ln net.Listener
+
+	// maximum number of open connections of each ip address
+	maxConnsPerIP int
+
+	// the number of open connections of each ip address
+	mtx   sync.Mutex
+	conns map[string]int
+}
+
+type perIPConn struct {
+	net.Conn
+	ln   *perIPConnsListener
+	ip   string
+	once sync.Once
+}
+
+//------------------------------------------------------------------------------
+
+// newPerIPConnsListener wraps the given listener into a listener that closes the connections exceeding the limit
+func newPerIPConnsListener(ln net.Listener, maxConnsPerIP int) net.Listener {
+	return &perIPConnsListener{
+		ln:            ln,
+		maxConnsPerIP: maxConnsPerIP,
+		conns:         make(map[string]int),
+	}
+}
+
+// Accept waits for a connection from an address that did not reach the limit
+func (ln *perIPConnsListener) Accept() (net.Conn, error) {
+	for {
+		c, err := ln.ln.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		ip := connIP(c)
+
+		ln.mtx.Lock()
+		count := ln.conns[ip]
+		if count < ln.maxConnsPerIP {
+			ln.conns[ip] = count + 1
+		}
+		ln.mtx.Unlock()
+
+		if count >= ln.maxConnsPerIP {
+			_ = c.Close()
+			continue
+		}
+
+		return &perIPConn{
+			Conn: c,
+			ln:   ln,
+			ip:   ip,
+		}, nil
+	}
+}
+
+// Addr returns the listen address
+func (ln *perIPConnsListener) Addr() net.Addr {
+	return ln.ln.Addr()
+}
+
+// Close closes the inner listener
+func (ln *perIPConnsListener) Close() error {
+	return ln.ln.Close()
+}
+
+//------------------------------------------------------------------------------
+
+func (c *perIPConn) Close() error {
+	err := c.Conn.Close()
+
+	//a connection can be closed more than once
+	c.once.Do(func() {
+		c.ln.mtx.Lock()
+		if c.ln.conns[c.ip] <= 1 {
+			delete(c.ln.conns, c.ip)
+		} else {
+			c.ln.conns[c.ip] -= 1
+		}
+		c.ln.mtx.Unlock()
+	})
+
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+func connIP(c net.Conn) string {
+	if addr, ok := c.RemoteAddr().(*net.TCPAddr); ok {
+		return addr.IP.String()
+	}
+	return c.RemoteAddr().String()
+}
@@ -1,9 +1,12 @@
 package server
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"errors"
+	"math"
 	"net"
+	"os"
 	"runtime"
 	"strconv"
 	"sync/atomic"
@@ -27,6 +30,14 @@ type Cookie = fasthttp.Cookie
 
 //------------------------------------------------------------------------------
 
+// ListenAddress defines an address where the server accepts connections. Network can be "tcp", "tcp4", "tcp6" or
+// "unix". For unix sockets, the address is the socket file path.
+type ListenAddress struct {
+	Network    string
+	Address    string
+	SocketMode os.FileMode
+}
+
 type Server struct {
 	Router *fasthttprouter.Router
 	shutdownSignal chan bool
@@ -36,21 +47,24 @@ type Server struct {
 
 //------------------------------------------------------------------------------
 
+const maxConnsPerIP = 20000
+
+//------------------------------------------------------------------------------
+
 var strContentType = []byte("Content-Type")
 var strApplicationJSON = []byte("application/json")
 
 //------------------------------------------------------------------------------
 
-func Create(port uint, compress bool) (*Server, error) {
+// Create starts a server listening on the given addresses. If a TLS configuration is provided, the server accepts
+// HTTPS connections only on TCP addresses.
+func Create(addresses []ListenAddress, compress bool, tlsConfig *tls.Config) (*Server, error) {
 	var server *Server
-	var fastserver fasthttp.Server
-	var gracefulListener net.Listener
-	var listener net.Listener
+	var gracefulListeners []net.Listener
 	var listenErr chan error
-	var err error
 
-	if port < 1 || port > 65535 {
-		return nil, errors.New("Invalid server port")
+	if len(addresses) == 0 {
+		return nil, errors.New("No listen addresses")
 	}
 
 	server = &Server{}
@@ -64,35 +78,48 @@ func Create(port uint, compress bool) (*Server, error) {
 		h = fasthttp.CompressHandler(h)
 	}
 
-	fastserver = fasthttp.Server{
-		Handler:              h,
-		ReadTimeout:          10 * time.Second,
-		WriteTimeout:         10 * time.Second,
-		MaxConnsPerIP:        20000,
-		MaxRequestsPerConn:   5,
-		DisableKeepalive:     true,
-	}
+	// create listeners
+	gracefulListeners = make([]net.Listener, 0, len(addresses))
+	for _, addr := range addresses {
+		listener, err := listen(addr)
+		if err != nil {
+			for _, ln := range gracefulListeners {
+				_ = ln.Close()
+			}
+			return nil, err
+		}
 
-	// create listener
-	if runtime.GOOS == "windows" {
-		listener, err = net.Listen("tcp4", "0.0.0.0:" + strconv.Itoa(int(port)))
-	} else {
-		listener, err = reuseport.Listen("tcp4", "0.0.0.0:" + strconv.Itoa(int(port)))
+		// create a graceful shutdown listener
+		gracefulListeners = append(gracefulListeners, NewGracefulListener(listener, 5 * time.Second))
 	}
-	if err != nil {
-		return nil, err
-	}
-
-	// create a graceful shutdown listener
-	gracefulListener = NewGracefulListener(listener, 5 * time.Second)
 
 	// error handling
-	listenErr = make(chan error, 1)
+	listenErr = make(chan error, len(gracefulListeners))
+
+	for idx, gracefulListener := range gracefulListeners {
+		fastserver := &fasthttp.Server{
+			Handler:              h,
+			ReadTimeout:          10 * time.Second,
+			WriteTimeout:         10 * time.Second,
+			MaxConnsPerIP:        maxConnsPerIP,
+			MaxRequestsPerConn:   5,
+			DisableKeepalive:     true,
+		}
 
-	/// Run server
-	go func() {
-		listenErr <- fastserver.Serve(gracefulListener)
-	}()
+		// wrap in a tls listener if needed. the graceful listener keeps tracking the underlying connections.
+		serveListener := gracefulListener
+		if tlsConfig != nil && addresses[idx].Network != "unix" {
+			// the per-ip limiter of fasthttp wraps the connections and hides the tls state from the handlers so the
+			// connections are limited below the tls listener instead
+			serveListener = tls.NewListener(newPerIPConnsListener(gracefulListener, maxConnsPerIP), tlsConfig)
+			fastserver.MaxConnsPerIP = 0
+		}
+
+		/// Run server
+		go func() {
+			listenErr <- fastserver.Serve(serveListener)
+		}()
+	}
 
 	go func() {
 		for {
@@ -110,10 +137,12 @@ func Create(port uint, compress bool) (*Server, error) {
 				// FIXME: This causes a data race
 				//server.SetKeepAlivesEnabled(false)
 
-				// Attempt the graceful shutdown by closing the listener
+				// Attempt the graceful shutdown by closing the listeners
 				// and completing all inflight requests.
 
-				_ = gracefulListener.Close()
+				for _, ln := range gracefulListeners {
+					_ = ln.Close()
+				}
 
 				server.shutdownErr <- nil
 			}
@@ -161,11 +190,38 @@ func SendAccessDenied(ctx *RequestCtx, msg string) {
 	return
 }
 
+func SendTooManyRequests(ctx *RequestCtx, retryAfter time.Duration) {
+	sendError(ctx, fasthttp.StatusTooManyRequests, "")
+	SetRetryAfter(ctx, retryAfter)
+	return
+}
+
+// SetRetryAfter tells the client when to send the request again. Must be called after the response body is set.
+func SetRetryAfter(ctx *RequestCtx, retryAfter time.Duration) {
+	ctx.Response.Header.Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	return
+}
+
+func SendConflict(ctx *RequestCtx, msg string) {
+	sendError(ctx, fasthttp.StatusConflict, msg)
+	return
+}
+
+func SendNotFound(ctx *RequestCtx, msg string) {
+	sendError(ctx, fasthttp.StatusNotFound, msg)
+	return
+}
+
 func SendInternalServerError(ctx *RequestCtx, msg string) {
 	sendError(ctx, fasthttp.StatusInternalServerError, msg)
 	return
 }
 
+func SendServiceUnavailable(ctx *RequestCtx, msg string) {
+	sendError(ctx, fasthttp.StatusServiceUnavailable, msg)
+	return
+}
+
 func sendError(ctx *RequestCtx, statusCode int, msg string) {
 	if len(msg) == 0 {
 		msg = fasthttp.StatusMessage(statusCode)
@@ -173,3 +229,31 @@ func sendError(ctx *RequestCtx, statusCode int, msg string) {
 	ctx.Error(msg, statusCode)
 	return
 }
+
+func listen(addr ListenAddress) (net.Listener, error) {
+	if addr.Network == "unix" {
+		// remove the socket file left by a previous instance that was not stopped cleanly
+		if fi, err := os.Lstat(addr.Address); err == nil && fi.Mode() & os.ModeSocket != 0 {
+			_ = os.Remove(addr.Address)
+		}
+
+		listener, err := net.Listen("unix", addr.Address)
+		if err != nil {
+			return nil, err
+		}
+		if addr.SocketMode != 0 {
+			err = os.Chmod(addr.Address, addr.SocketMode)
+			if err != nil {
+				_ = listener.Close()
+				return nil, err
+			}
+		}
+		return listener, nil
+	}
+
+	// reuseport only supports ipv4 and ipv6 specific listeners
+	if runtime.GOOS == "windows" || (addr.Network != "tcp4" && addr.Network != "tcp6") {
+		return net.Listen(addr.Network, addr.Address)
+	}
+	return reuseport.Listen(addr.Network, addr.Address)
+}
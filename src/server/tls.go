@@ -0,0 +1,153 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	certificateCheckInterval = 10 * time.Second
+)
+
+//------------------------------------------------------------------------------
+
+// TLSOptions defines the TLS settings of the server
+type TLSOptions struct {
+	CertFile          string
+	KeyFile           string
+	MinVersion        uint16
+	ClientCAFile      string
+	RequireClientCert bool
+}
+
+// certificateLoader keeps the server certificate and loads it again when the certificate or key files change
+type certificateLoader struct {
+	mtx         sync.Mutex
+	certFile    string
+	keyFile     string
+	cert        *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+	lastCheck   time.Time
+}
+
+//------------------------------------------------------------------------------
+
+// NewTLSConfig creates the TLS configuration of the server. The certificate is reloaded automatically when its files
+// are modified, so it can be renewed without restarting the server.
+func NewTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	loader := &certificateLoader{
+		certFile: opts.CertFile,
+		keyFile:  opts.KeyFile,
+	}
+	err := loader.load()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &tls.Config{
+		MinVersion:     opts.MinVersion,
+		GetCertificate: loader.getCertificate,
+	}
+
+	if len(opts.ClientCAFile) > 0 {
+		pem, err := ioutil.ReadFile(opts.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.ClientCAs = x509.NewCertPool()
+		if !cfg.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("No valid certificates found in the client certificate authority file")
+		}
+
+		//when not required, clients can still authenticate with an API key
+		if opts.RequireClientCert {
+			cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		} else {
+			cfg.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+
+	return cfg, nil
+}
+
+// GetClientCertificateCN returns the common name of the verified client certificate used in the request, if any
+func GetClientCertificateCN(ctx *RequestCtx) (string, bool) {
+	if !ctx.IsTLS() {
+		return "", false
+	}
+
+	state := ctx.TLSConnectionState()
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.PeerCertificates) == 0 {
+		return "", false
+	}
+	return state.PeerCertificates[0].Subject.CommonName, true
+}
+
+//------------------------------------------------------------------------------
+
+func (l *certificateLoader) getCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	//check for changes from time to time. if the new files cannot be loaded, for e.g., because only one of them was
+	//replaced yet, keep using the current certificate
+	if time.Since(l.lastCheck) >= certificateCheckInterval {
+		l.lastCheck = time.Now()
+
+		if l.filesChanged() {
+			_ = l.loadLocked()
+		}
+	}
+
+	return l.cert, nil
+}
+
+func (l *certificateLoader) load() error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.lastCheck = time.Now()
+	return l.loadLocked()
+}
+
+func (l *certificateLoader) loadLocked() error {
+	certInfo, err := os.Stat(l.certFile)
+	if err != nil {
+		return err
+	}
+	keyInfo, err := os.Stat(l.keyFile)
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if err != nil {
+		return err
+	}
+
+	l.cert = &cert
+	l.certModTime = certInfo.ModTime()
+	l.keyModTime = keyInfo.ModTime()
+	return nil
+}
+
+func (l *certificateLoader) filesChanged() bool {
+	certInfo, err := os.Stat(l.certFile)
+	if err != nil {
+		return false
+	}
+	keyInfo, err := os.Stat(l.keyFile)
+	if err != nil {
+		return false
+	}
+	return !certInfo.ModTime().Equal(l.certModTime) || !keyInfo.ModTime().Equal(l.keyModTime)
+}
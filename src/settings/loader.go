@@ -0,0 +1,278 @@
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+//------------------------------------------------------------------------------
+
+// Sections that an included file is allowed to define
+var includableSections = map[string]struct{}{
+	"channels":      {},
+	"processes":     {},
+	"webs":          {},
+	"tcpPorts":      {},
+	"freeDiskSpace": {},
+	"heartbeats":    {},
+	"jobs":          {},
+}
+
+//------------------------------------------------------------------------------
+
+// IsSupportedSettingsFile returns true if the file extension belongs to a known configuration format
+func IsSupportedSettingsFile(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		fallthrough
+	case ".yaml":
+		fallthrough
+	case ".yml":
+		fallthrough
+	case ".toml":
+		return true
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
+
+func loadSettingsFile(filename string, cfg *SettingsJSON, v *validator) error {
+	var raw map[string]interface{}
+	var b []byte
+	var err error
+
+	raw, err = decodeSettingsFile(filename)
+	if err != nil {
+		return err
+	}
+
+	v.checkUnknownKeys(filename, "", raw, reflect.TypeOf(cfg))
+
+	//convert the generic representation into json so the same struct tags are used for all formats
+	b, err = json.Marshal(raw)
+	if err == nil {
+		err = json.Unmarshal(b, cfg)
+	}
+	if err != nil {
+		return errors.New(fmt.Sprintf("Invalid settings file \"%v\". [%v]", filename, err))
+	}
+
+	v.addSources(filename, "processes", len(cfg.Processes))
+	v.addSources(filename, "webs", len(cfg.Webs))
+	v.addSources(filename, "tcpPorts", len(cfg.TcpPorts))
+	v.addSources(filename, "freeDiskSpace", len(cfg.FreeDiskSpace))
+	v.addSources(filename, "heartbeats", len(cfg.Heartbeats))
+	v.addSources(filename, "jobs", len(cfg.Jobs))
+
+	return nil
+}
+
+func decodeSettingsFile(filename string) (map[string]interface{}, error) {
+	var raw map[string]interface{}
+	var b []byte
+	var err error
+
+	b, err = ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Cannot load settings. [%v]", err))
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml":
+		fallthrough
+	case ".yml":
+		var v interface{}
+
+		err = yaml.Unmarshal(b, &v)
+		if err == nil {
+			var ok bool
+
+			raw, ok = normalizeYamlValue(v).(map[string]interface{})
+			if !ok && v != nil {
+				err = errors.New("root element must be a map")
+			}
+		}
+
+	case ".toml":
+		_, err = toml.Decode(string(b), &raw)
+
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(b))
+		decoder.UseNumber()
+		err = decoder.Decode(&raw)
+	}
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Invalid settings file \"%v\". [%v]", filename, err))
+	}
+	if raw == nil {
+		raw = make(map[string]interface{})
+	}
+
+	return raw, nil
+}
+
+func processIncludes(cfg *SettingsJSON, v *validator) error {
+	var files []string
+	var err error
+
+	seen := make(map[string]struct{})
+	for _, pattern := range cfg.Include {
+		var matches []string
+
+		if len(pattern) == 0 {
+			return errors.New("An empty include path was specified.")
+		}
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(BaseFolder, pattern)
+		}
+		pattern = filepath.Clean(pattern)
+
+		matches, err = expandIncludePath(pattern)
+		if err != nil {
+			return errors.New(fmt.Sprintf("Unable to process include \"%v\". [%v]", pattern, err))
+		}
+		for _, match := range matches {
+			match, err = filepath.Abs(match)
+			if err != nil {
+				return errors.New(fmt.Sprintf("Unable to process include \"%v\". [%v]", pattern, err))
+			}
+			match = filepath.Clean(match)
+
+			//skip files already matched by a previous include entry
+			if _, ok := seen[match]; ok {
+				continue
+			}
+			seen[match] = struct{}{}
+
+			files = append(files, match)
+		}
+	}
+
+	for _, filename := range files {
+		var raw map[string]interface{}
+		var included SettingsJSON
+		var b []byte
+
+		raw, err = decodeSettingsFile(filename)
+		if err != nil {
+			return err
+		}
+
+		for key := range raw {
+			if _, ok := includableSections[key]; !ok {
+				return errors.New(fmt.Sprintf("Section \"%v\" is not allowed in included file \"%v\".", key, filename))
+			}
+		}
+
+		v.checkUnknownKeys(filename, "", raw, reflect.TypeOf(&included))
+
+		b, err = json.Marshal(raw)
+		if err == nil {
+			err = json.Unmarshal(b, &included)
+		}
+		if err != nil {
+			return errors.New(fmt.Sprintf("Invalid settings file \"%v\". [%v]", filename, err))
+		}
+
+		//merge
+		for chName, ch := range included.Channels {
+			if _, ok := cfg.Channels[chName]; ok {
+				return errors.New(fmt.Sprintf("Channel \"%v\" in included file \"%v\" is already defined.",
+				                              chName, filename))
+			}
+			if cfg.Channels == nil {
+				cfg.Channels = make(map[string]SettingsJSON_Channel)
+			}
+			cfg.Channels[chName] = ch
+			v.channels[chName] = filename
+		}
+		v.addSources(filename, "processes", len(included.Processes))
+		v.addSources(filename, "webs", len(included.Webs))
+		v.addSources(filename, "tcpPorts", len(included.TcpPorts))
+		v.addSources(filename, "freeDiskSpace", len(included.FreeDiskSpace))
+		v.addSources(filename, "heartbeats", len(included.Heartbeats))
+		v.addSources(filename, "jobs", len(included.Jobs))
+		cfg.Processes = append(cfg.Processes, included.Processes...)
+		cfg.Webs = append(cfg.Webs, included.Webs...)
+		cfg.TcpPorts = append(cfg.TcpPorts, included.TcpPorts...)
+		cfg.FreeDiskSpace = append(cfg.FreeDiskSpace, included.FreeDiskSpace...)
+		cfg.Heartbeats = append(cfg.Heartbeats, included.Heartbeats...)
+		cfg.Jobs = append(cfg.Jobs, included.Jobs...)
+	}
+
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// Expands an include entry into the list of files. Directories load all the supported files inside them and
+// glob patterns load every match. Results are sorted to keep the merge order predictable.
+func expandIncludePath(pattern string) ([]string, error) {
+	var files []string
+
+	fi, err := os.Stat(pattern)
+	if err == nil {
+		if !fi.IsDir() {
+			return []string{pattern}, nil
+		}
+
+		entries, err := ioutil.ReadDir(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if (!entry.IsDir()) && IsSupportedSettingsFile(entry.Name()) {
+				files = append(files, filepath.Join(pattern, entry.Name()))
+			}
+		}
+	} else {
+		if !strings.ContainsAny(pattern, "*?[") {
+			return nil, err
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			fi, err = os.Stat(match)
+			if err == nil && (!fi.IsDir()) && IsSupportedSettingsFile(match) {
+				files = append(files, match)
+			}
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// YAML decoder returns maps with interface keys that cannot be converted to json
+func normalizeYamlValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			m[fmt.Sprint(k)] = normalizeYamlValue(item)
+		}
+		return m
+
+	case []interface{}:
+		for idx := range value {
+			value[idx] = normalizeYamlValue(value[idx])
+		}
+		return value
+	}
+	return v
+}
@@ -1,31 +1,50 @@
 package settings
 
 import (
+	"os"
 	"regexp"
 	"time"
 
 	"github.com/RoaringBitmap/roaring"
+	"github.com/randlabs/server-watchdog/utils/cron"
 )
 
 //------------------------------------------------------------------------------
 
 type SettingsJSON struct {
 	Name string `json:"name,omitempty"`
+	Include []string `json:"include,omitempty"`
 	Server struct {
-		Port   uint   `json:"port"`
-		ApiKey string `json:"apiKey"`
-	} `json:"server"`
+		Port        uint                          `json:"port,omitempty" schema:"port"`
+		Listen      []string                      `json:"listen,omitempty"`
+		ListenX     []SettingsJSON_ListenAddress  `json:"-"`
+		SocketMode  string                        `json:"socketMode,omitempty"`
+		SocketModeX os.FileMode                   `json:"-"`
+		ApiKey    string                  `json:"apiKey,omitempty"`
+		ApiKeys   []SettingsJSON_ApiKey   `json:"apiKeys,omitempty"`
+		Tls       *SettingsJSON_Tls       `json:"tls,omitempty"`
+		RateLimit *SettingsJSON_RateLimit `json:"rateLimit,omitempty"`
+		Notify    SettingsJSON_Notify     `json:"notify,omitempty"`
+		Grpc      *SettingsJSON_Grpc      `json:"grpc,omitempty"`
+		Dashboard struct {
+			Enabled bool `json:"enable"`
+		} `json:"dashboard,omitempty"`
+	} `json:"server" schema:"required"`
 	Log struct {
 		Folder       string `json:"folder"`
-		MaxAge       string `json:"maxAge,omitempty"`
-		MaxAgeX      time.Duration
-		UseLocalTime bool `json:useLocalTime,omitempty"`
+		MaxAge       string `json:"maxAge,omitempty" schema:"timespan"`
+		MaxAgeX      time.Duration `json:"-"`
+		UseLocalTime bool `json:"useLocalTime,omitempty"`
 	} `json:"log"`
-	Channels map[string]SettingsJSON_Channel   `json:"channels"`
+	Channels map[string]SettingsJSON_Channel   `json:"channels" schema:"required"`
 	Processes []SettingsJSON_Processes         `json:"processes,omitempty"`
 	Webs []SettingsJSON_Webs                   `json:"webs,omitempty"`
 	TcpPorts []SettingsJSON_TcpPorts           `json:"tcpPorts,omitempty"`
 	FreeDiskSpace []SettingsJSON_FreeDiskSpace `json:"freeDiskSpace,omitempty"`
+	Heartbeats []SettingsJSON_Heartbeats       `json:"heartbeats,omitempty"`
+	Jobs []SettingsJSON_Jobs                   `json:"jobs,omitempty"`
+	Maintenance []SettingsJSON_Maintenance     `json:"maintenance,omitempty"`
+	Escalation []SettingsJSON_Escalation       `json:"escalation,omitempty"`
 }
 
 type SettingsJSON_Channel struct {
@@ -46,66 +65,187 @@ type SettingsJSON_Channel_Slack struct {
 type SettingsJSON_Channel_EMail struct {
 	Enabled     bool                          `json:"enable"`
 	Subject     string                        `json:"subject"`
-	Sender      string                        `json:"sender"`
-	Receivers   []string                      `json:"receivers"`
+	Sender      string                        `json:"sender" schema:"email"`
+	Receivers   []string                      `json:"receivers" schema:"email"`
 	Server      SettingsJSON_EMail_SmtpServer `json:"smtpServer"`
 }
 
+type SettingsJSON_ApiKey struct {
+	Name     string   `json:"name" schema:"required"`
+	KeyHash  string   `json:"keyHash" schema:"required"`
+	Scopes   []string `json:"scopes" schema:"required,enum:read|notify|process|ping|ack|admin"`
+	Channels []string `json:"channels,omitempty"`
+}
+
+type SettingsJSON_Grpc struct {
+	Listen  string                     `json:"listen" schema:"required"`
+	ListenX SettingsJSON_ListenAddress `json:"-"`
+}
+
+type SettingsJSON_Notify struct {
+	DedupWindow        string        `json:"dedupWindow,omitempty" schema:"timespan"`
+	DedupWindowX       time.Duration `json:"-"`
+	IdempotencyWindow  string        `json:"idempotencyWindow,omitempty" schema:"timespan"`
+	IdempotencyWindowX time.Duration `json:"-"`
+}
+
+type SettingsJSON_RateLimit struct {
+	PerKey   *SettingsJSON_RateLimit_Bucket `json:"perKey,omitempty"`
+	PerIp    *SettingsJSON_RateLimit_Bucket `json:"perIp,omitempty"`
+	Channel  string                         `json:"channel,omitempty"`
+	Severity string                         `json:"severity,omitempty" schema:"severity"`
+}
+
+type SettingsJSON_RateLimit_Bucket struct {
+	Rate  string  `json:"rate" schema:"required"`
+	RateX float64 `json:"-"`
+	Burst uint    `json:"burst,omitempty"`
+}
+
+type SettingsJSON_ListenAddress struct {
+	Network string
+	Address string
+}
+
+type SettingsJSON_Tls struct {
+	CertFile     string                        `json:"certFile" schema:"required"`
+	KeyFile      string                        `json:"keyFile" schema:"required"`
+	MinVersion   string                        `json:"minVersion,omitempty" schema:"enum:1.0|1.1|1.2|1.3"`
+	MinVersionX  uint16                        `json:"-"`
+	ClientAuth   *SettingsJSON_Tls_ClientAuth  `json:"clientAuth,omitempty"`
+}
+
+type SettingsJSON_Tls_ClientAuth struct {
+	CAFile     string   `json:"caFile" schema:"required"`
+	Required   bool     `json:"required,omitempty"`
+	AllowedCNs []string `json:"allowedCNs" schema:"required"`
+	Scopes     []string `json:"scopes,omitempty" schema:"enum:read|notify|process|ping|ack|admin"`
+	Channels   []string `json:"channels,omitempty"`
+}
+
 type SettingsJSON_EMail_SmtpServer struct {
-	Host     string `json:"host"`
-	Port     uint   `json:"port,omitempty"`
-	UserName string `json:"username"`
+	Host     string `json:"host" schema:"required"`
+	Port     uint   `json:"port,omitempty" schema:"port"`
+	UserName string `json:"username" schema:"required"`
 	Password string `json:"password"`
 	UseSSL   bool   `json:"useSSL,omitempty"`
 }
 
 type SettingsJSON_Processes struct {
 	FriendlyName      string `json:"name,omitempty"`
-	ExecutableName    string `json:"executableName"`
+	ExecutableName    string `json:"executableName" schema:"required"`
 	CommandLineParams string `json:"args,omitempty"`
 	IncludeChilds     bool   `json:"includeChilds,omitempty"`
-	MaxMemUsage       string `json:"maxMem,omitempty"`
-	Channel           string `json:"channel"`
-	Severity          string `json:"severity,omitempty"`
+	MaxMemUsage       string `json:"maxMem,omitempty" schema:"memory"`
+	Channel           string `json:"channel" schema:"required"`
+	Severity          string `json:"severity,omitempty" schema:"severity"`
 }
 
 type SettingsJSON_Webs struct {
-	Url          string                      `json:"url"`
+	Url          string                      `json:"url" schema:"required,uri"`
 	Headers      *map[string]string          `json:"headers,omitempty"`
 	Content      []SettingsJSON_Webs_Content `json:"content,omitempty"`
-	CheckPeriod  string                      `json:"checkPeriod,omitempty"`
-	CheckPeriodX time.Duration
-	Timeout       string                     `json:"timeout,omitempty"`
-	TimeoutX      time.Duration
-	Channel      string                      `json:"channel"`
-	Severity     string                      `json:"severity,omitempty"`
+	CheckPeriod  string                      `json:"checkPeriod,omitempty" schema:"timespan"`
+	CheckPeriodX time.Duration `json:"-"`
+	Timeout       string                     `json:"timeout,omitempty" schema:"timespan"`
+	TimeoutX      time.Duration `json:"-"`
+	Channel      string                      `json:"channel" schema:"required"`
+	Severity     string                      `json:"severity,omitempty" schema:"severity"`
+	SettingsJSON_Thresholds
 }
 
 type SettingsJSON_Webs_Content struct {
-	Search       string `json:"search"`
+	Search       string `json:"search" schema:"required"`
 	CheckChanges []uint `json:"checkChanges,omitempty"`
-	SearchRegex  *regexp.Regexp
+	SearchRegex  *regexp.Regexp `json:"-"`
 }
 
 type SettingsJSON_TcpPorts struct {
-	Name          string `json:"name"`
-	Address       string `json:"address"`
-	Ports         string `json:"ports"`
-	PortsX        *roaring.Bitmap
-	CheckPeriod   string `json:"checkPeriod,omitempty"`
-	CheckPeriodX  time.Duration
-	Timeout       string `json:"timeout,omitempty"`
-	TimeoutX      time.Duration
-	Channel       string `json:"channel"`
-	Severity      string `json:"severity,omitempty"`
+	Name          string `json:"name" schema:"required"`
+	Address       string `json:"address" schema:"required"`
+	Ports         string `json:"ports" schema:"required,ports"`
+	PortsX        *roaring.Bitmap `json:"-"`
+	CheckPeriod   string `json:"checkPeriod,omitempty" schema:"timespan"`
+	CheckPeriodX  time.Duration `json:"-"`
+	Timeout       string `json:"timeout,omitempty" schema:"timespan"`
+	TimeoutX      time.Duration `json:"-"`
+	Channel       string `json:"channel" schema:"required"`
+	Severity      string `json:"severity,omitempty" schema:"severity"`
+	SettingsJSON_Thresholds
 }
 
 type SettingsJSON_FreeDiskSpace struct {
-	Device        string `json:"device"`
-	CheckPeriod   string `json:"checkPeriod,omitempty"`
-	CheckPeriodX  time.Duration
-	MinimumSpace  string `json:"minimumSpace"`
-	MinimumSpaceX uint64
-	Channel       string `json:"channel"`
-	Severity      string `json:"severity,omitempty"`
+	Device        string `json:"device" schema:"required"`
+	CheckPeriod   string `json:"checkPeriod,omitempty" schema:"timespan"`
+	CheckPeriodX  time.Duration `json:"-"`
+	MinimumSpace  string `json:"minimumSpace" schema:"required,memory"`
+	MinimumSpaceX uint64 `json:"-"`
+	Channel       string `json:"channel" schema:"required"`
+	Severity      string `json:"severity,omitempty" schema:"severity"`
+	SettingsJSON_Thresholds
+}
+
+type SettingsJSON_Heartbeats struct {
+	Name          string `json:"name" schema:"required"`
+	Interval      string `json:"interval" schema:"required,timespan"`
+	IntervalX     time.Duration `json:"-"`
+	Grace         string `json:"grace,omitempty" schema:"timespan"`
+	GraceX        time.Duration `json:"-"`
+	Channel       string `json:"channel" schema:"required"`
+	Severity      string `json:"severity,omitempty" schema:"severity"`
+}
+
+type SettingsJSON_Jobs struct {
+	Name          string `json:"name" schema:"required"`
+	Schedule      string `json:"schedule,omitempty"`
+	ScheduleX     *cron.Schedule `json:"-"`
+	Timezone      string `json:"timezone,omitempty"`
+	TimezoneX     *time.Location `json:"-"`
+	StartGrace    string `json:"startGrace,omitempty" schema:"timespan"`
+	StartGraceX   time.Duration `json:"-"`
+	MaxDuration   string `json:"maxDuration,omitempty" schema:"timespan"`
+	MaxDurationX  time.Duration `json:"-"`
+	Channel       string `json:"channel" schema:"required"`
+	Severity      string `json:"severity,omitempty" schema:"severity"`
+}
+
+type SettingsJSON_Thresholds struct {
+	FailureThreshold uint          `json:"failureThreshold,omitempty"`
+	SuccessThreshold uint          `json:"successThreshold,omitempty"`
+	FlapThreshold    uint          `json:"flapThreshold,omitempty"`
+	FlapWindow       string        `json:"flapWindow,omitempty" schema:"timespan"`
+	FlapWindowX      time.Duration `json:"-"`
+}
+
+type SettingsJSON_Maintenance struct {
+	Name        string                `json:"name" schema:"required"`
+	Schedule    string                `json:"schedule" schema:"required"`
+	ScheduleX   *cron.Schedule        `json:"-"`
+	Duration    string                `json:"duration" schema:"required,timespan"`
+	DurationX   time.Duration         `json:"-"`
+	Timezone    string                `json:"timezone,omitempty"`
+	TimezoneX   *time.Location        `json:"-"`
+	Action      string                `json:"action,omitempty" schema:"enum:suppress|downgrade"`
+	DowngradeTo string                `json:"downgradeTo,omitempty" schema:"severity"`
+	Matchers    SettingsJSON_Matchers `json:"matchers,omitempty"`
+}
+
+type SettingsJSON_Escalation struct {
+	Name     string                             `json:"name" schema:"required"`
+	Matchers SettingsJSON_Matchers              `json:"matchers,omitempty"`
+	Steps    []SettingsJSON_Escalation_Step     `json:"steps" schema:"required"`
+}
+
+type SettingsJSON_Escalation_Step struct {
+	After    string        `json:"after" schema:"required,timespan"`
+	AfterX   time.Duration `json:"-"`
+	Channel  string        `json:"channel,omitempty"`
+	Severity string        `json:"severity,omitempty" schema:"severity"`
+}
+
+type SettingsJSON_Matchers struct {
+	Channel  string `json:"channel,omitempty"`
+	Module   string `json:"module,omitempty" schema:"enum:webs|tcpPorts|freeDiskSpace|processes|heartbeats|jobs|notify|rateLimit"`
+	Target   string `json:"target,omitempty"`
+	Severity string `json:"severity,omitempty" schema:"severity"`
 }
@@ -0,0 +1,169 @@
+package settings
+
+import (
+	"reflect"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+// Severity values accepted by ValidateSeverity. An empty string means the default severity.
+var severityNames = []string{
+	"", "error", "warn", "warning", "info", "information", "debug",
+}
+
+// Units accepted by ValidateTimeSpan
+var timeSpanUnits = []string{
+	"ms", "s", "sec", "secs", "m", "min", "mins", "h", "hour", "hours", "d", "day", "days", "w", "week", "weeks",
+}
+
+// Units accepted by ValidateMemoryAmount
+var memoryAmountUnits = []string{
+	"b", "bytes", "k", "kb", "kilobytes", "m", "mb", "megabytes", "g", "gb", "gigabytes",
+}
+
+//------------------------------------------------------------------------------
+
+// GenerateSchema builds a JSON Schema (draft-07) document describing the settings file
+func GenerateSchema() map[string]interface{} {
+	schema := typeSchema(reflect.TypeOf(SettingsJSON{}), nil)
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "Server Watchdog settings"
+	return schema
+}
+
+//------------------------------------------------------------------------------
+
+func typeSchema(t reflect.Type, hints []string) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	schema := make(map[string]interface{})
+
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		required := make([]string, 0)
+
+		for idx := 0; idx < t.NumField(); idx++ {
+			field := t.Field(idx)
+
+			//fields of embedded structs are promoted
+			if isEmbeddedStruct(field) {
+				embedded := typeSchema(field.Type, nil)
+				for name, prop := range embedded["properties"].(map[string]interface{}) {
+					properties[name] = prop
+				}
+				if embeddedRequired, ok := embedded["required"].([]string); ok {
+					required = append(required, embeddedRequired...)
+				}
+				continue
+			}
+
+			name, ok := jsonFieldName(field)
+			if !ok {
+				continue
+			}
+
+			fieldHints := strings.Split(field.Tag.Get("schema"), ",")
+			properties[name] = typeSchema(field.Type, fieldHints)
+			if hasHint(fieldHints, "required") {
+				required = append(required, name)
+			}
+		}
+
+		schema["type"] = "object"
+		schema["properties"] = properties
+		schema["additionalProperties"] = false
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+
+	case reflect.Map:
+		schema["type"] = "object"
+		schema["additionalProperties"] = typeSchema(t.Elem(), nil)
+
+	case reflect.Slice:
+		schema["type"] = "array"
+		schema["items"] = typeSchema(t.Elem(), hints)
+
+	case reflect.Bool:
+		schema["type"] = "boolean"
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		schema["type"] = "integer"
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema["type"] = "integer"
+		schema["minimum"] = 0
+		if hasHint(hints, "port") {
+			schema["minimum"] = 1
+			schema["maximum"] = 65535
+		}
+
+	case reflect.Float32, reflect.Float64:
+		schema["type"] = "number"
+
+	case reflect.String:
+		schema["type"] = "string"
+
+		switch {
+		case len(enumHint(hints)) > 0:
+			schema["enum"] = enumHint(hints)
+		case hasHint(hints, "severity"):
+			schema["enum"] = severityNames
+		case hasHint(hints, "timespan"):
+			schema["pattern"] = "^\\s*(\\d+\\s*(" + unitsPattern(timeSpanUnits) + ")\\s*)+$"
+		case hasHint(hints, "memory"):
+			schema["pattern"] = "^\\s*(\\d+(\\.\\d+)?)\\s*(" + unitsPattern(memoryAmountUnits) + ")\\s*$"
+		case hasHint(hints, "ports"):
+			schema["pattern"] = "^\\s*\\d+(\\s*-\\s*\\d+)?(\\s*,\\s*\\d+(\\s*-\\s*\\d+)?)*\\s*$"
+		case hasHint(hints, "email"):
+			schema["format"] = "email"
+		case hasHint(hints, "uri"):
+			schema["format"] = "uri"
+		}
+	}
+
+	return schema
+}
+
+func hasHint(hints []string, hint string) bool {
+	for _, h := range hints {
+		if h == hint {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the values of an "enum:a|b|c" hint
+func enumHint(hints []string) []string {
+	for _, h := range hints {
+		if strings.HasPrefix(h, "enum:") {
+			return strings.Split(h[5:], "|")
+		}
+	}
+	return nil
+}
+
+// Builds a case insensitive alternation. JSON Schema regexes do not support inline flags.
+func unitsPattern(units []string) string {
+	alternatives := make([]string, len(units))
+
+	for idx, unit := range units {
+		sb := strings.Builder{}
+		for _, ch := range unit {
+			lower := strings.ToLower(string(ch))
+			upper := strings.ToUpper(string(ch))
+			if lower != upper {
+				sb.WriteString("[" + lower + upper + "]")
+			} else {
+				sb.WriteRune(ch)
+			}
+		}
+		alternatives[idx] = sb.String()
+	}
+	return strings.Join(alternatives, "|")
+}
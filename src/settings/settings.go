@@ -1,22 +1,15 @@
 package settings
 
 import (
-	"encoding/json"
-	"errors"
-	"fmt"
 	"github.com/RoaringBitmap/roaring"
-	"os"
 	"path/filepath"
-	"regexp"
-	"runtime"
 	"strconv"
 	"strings"
 	"time"
 
-	valid "github.com/asaskevich/govalidator"
+	"github.com/minio/minio/pkg/wildcard"
 	"github.com/randlabs/server-watchdog/utils/process"
 	"github.com/randlabs/server-watchdog/utils/stringparser"
-	"github.com/ricochet2200/go-disk-usage/du"
 )
 
 //------------------------------------------------------------------------------
@@ -24,333 +17,74 @@ import (
 var Config SettingsJSON
 var BaseFolder string
 
+var configFilename string
+
+// MatcherModules are the module names that silences, maintenance windows and escalation policies can match
+var MatcherModules = []string{
+	"webs", "tcpPorts", "freeDiskSpace", "processes", "heartbeats", "jobs", "notify", "rateLimit",
+}
+
+// ApiKeyScopes are the groups of endpoints an API key can be granted access to
+var ApiKeyScopes = []string{
+	"read", "notify", "process", "ping", "ack", "admin",
+}
+
 //------------------------------------------------------------------------------
 
 // Load ...
 func Load() error {
-	var file *os.File
-	var parser *json.Decoder
-	var settingsFilename string
-	var ok bool
 	var err error
 
-	settingsFilename, err = GetSettingsFilename()
+	settingsFilename := GetSettingsFilename()
+	v := newValidator(settingsFilename)
+	err = load(settingsFilename, &Config, v)
 	if err != nil {
 		return err
 	}
+	return v.firstError()
+}
 
-	file, err = os.Open(settingsFilename)
-	if err != nil {
-		return errors.New(fmt.Sprintf("Cannot load settings. [%v]", err))
-	}
-	defer func() {
-		_ = file.Close()
-	}()
-
-	BaseFolder = filepath.Dir(settingsFilename)
-	if !strings.HasSuffix(BaseFolder, string(filepath.Separator)) {
-		BaseFolder += string(filepath.Separator)
-	}
+// Check loads the settings file and validates all the sections without stopping on the first error. Besides the
+// errors, it returns warnings about unknown keys.
+func Check() ([]ValidationIssue, []ValidationIssue, error) {
+	var cfg SettingsJSON
+	var err error
 
-	parser = json.NewDecoder(file)
-	err = parser.Decode(&Config)
+	settingsFilename := GetSettingsFilename()
+	v := newValidator(settingsFilename)
+	err = load(settingsFilename, &cfg, v)
 	if err != nil {
-		return errors.New(fmt.Sprintf("Invalid settings file. [%v]", err))
-	}
-
-	//validate settings
-	if len(Config.Name) == 0 {
-		Config.Name = "SERVER-WATCHDOG"
-	} else if len(Config.Name) > 256 {
-		return errors.New("Name is too long. Max 256 chars.")
-	}
-
-	//----
-
-	if Config.Server.Port < 1 || Config.Server.Port > 65535 {
-		return errors.New(fmt.Sprintf("Invalid server port."))
-	}
-	if len(Config.Server.ApiKey) == 0 {
-		return errors.New(fmt.Sprintf("Invalid server API key."))
-	}
-
-	//----
-
-	if len(Config.Log.MaxAge) > 0 {
-		Config.Log.MaxAgeX, ok = ValidateTimeSpan(Config.Log.MaxAge)
-		if !ok {
-			return errors.New("Invalid log files max age value.")
-		}
-		if Config.Log.MaxAgeX < 10 * time.Minute {
-			return errors.New("Log files max age value cannot be lower than 10 minutes.")
-		}
-	} else {
-		Config.Log.MaxAgeX = 7 * 24 * time.Hour
-	}
-
-	//----
-
-	hasChannels := false
-	for chName := range Config.Channels {
-		hasChannels = true
-
-		hasOutput := false
-
-		if len(chName) == 0 {
-			return errors.New(fmt.Sprintf("A channel without name was specified."))
-		} else if len(chName) == 32 {
-			return errors.New(fmt.Sprintf("Channel name too long. Max 32 chars."))
-		}
-
-		ch := Config.Channels[chName]
-
-		if ch.File != nil && ch.File.Enabled {
-			hasOutput = true
-		}
-
-		if ch.Slack != nil && ch.Slack.Enabled {
-			hasOutput = true
-			if len(ch.Slack.Channel) == 0 {
-				return errors.New(fmt.Sprintf("No slack hook specified for channel \"%v\".", chName))
-			}
-		}
-
-		if ch.EMail != nil && ch.EMail.Enabled {
-			hasOutput = true
-
-			if len(ch.EMail.Subject) > 256 {
-				return errors.New(fmt.Sprintf("Email subject for channel \"%v\" is too long. Max 256 chars.", chName))
-			}
-
-			if !valid.IsEmail(ch.EMail.Sender) {
-				return errors.New(fmt.Sprintf("Missing or invalid sender email address for channel \"%v\".", chName))
-			}
-
-			if ch.EMail.Receivers == nil || len(ch.EMail.Receivers) == 0 {
-				return errors.New(fmt.Sprintf("No receiver email addresses for channel \"%v\" was specified.", chName))
-			}
-
-			for i := len(ch.EMail.Receivers); i > 0; i-- {
-				if !valid.IsEmail(ch.EMail.Receivers[i - 1]) {
-					return errors.New(fmt.Sprintf("Invalid receiver email address specified for channel \"%v\".", chName))
-				}
-			}
-
-			if len(ch.EMail.Server.UserName) == 0 {
-				return errors.New(fmt.Sprintf("Missing email server's username for channel \"%v\".", chName))
-			}
-			if len(ch.EMail.Server.Host) == 0 {
-				return errors.New(fmt.Sprintf("Missing email server's host for channel \"%v\".", chName))
-			}
-
-			if ch.EMail.Server.Port == 0 {
-				ch.EMail.Server.Port = 25
-			} else if ch.EMail.Server.Port < 1 || ch.EMail.Server.Port > 65535 {
-				return errors.New(fmt.Sprintf("Invalid email server's port for channel \"%v\".", chName))
-			}
-		}
-
-		if !hasOutput {
-			return errors.New(fmt.Sprintf("No output stream was specified for channel \"%v\".", chName))
-		}
-	}
-	if !hasChannels {
-		return errors.New(fmt.Sprintf("No channels were specified."))
-	}
-
-	//----
-
-	for idx := range Config.Processes {
-		proc := &Config.Processes[idx]
-
-		if len(proc.ExecutableName) == 0 {
-			return errors.New(fmt.Sprintf("Missing or invalid process' executable name."))
-		}
-
-		_, ok = Config.Channels[proc.Channel]
-		if !ok {
-			return errors.New(fmt.Sprintf("Channel not found for process \"%v\".", proc.ExecutableName))
-		}
-
-		proc.Severity = ValidateSeverity(proc.Severity)
-		if len(proc.Severity) == 0 {
-			return errors.New(fmt.Sprintf("Invalid severity for process \"%v\".", proc.ExecutableName))
-		}
-	}
-
-	//----
-
-	for idx := range Config.Webs {
-		web := &Config.Webs[idx]
-
-		if !valid.IsURL(web.Url) {
-			return errors.New(fmt.Sprintf("Missing or invalid url specified."))
-		}
-
-		if len(web.CheckPeriod) > 0 {
-			web.CheckPeriodX, ok = ValidateTimeSpan(web.CheckPeriod)
-			if !ok {
-				return errors.New(fmt.Sprintf("Invalid web check period value for web \"%v\".", web.Url))
-			}
-			if web.CheckPeriodX < 10 * time.Second {
-				return errors.New(fmt.Sprintf("Web check period value for \"%v\" cannot be lower than 10 seconds.", web.Url))
-			}
-		} else {
-			web.CheckPeriodX = 10 * time.Second
-		}
-
-		for contentIdx := range web.Content {
-			wc := &web.Content[contentIdx]
-
-			if len(wc.Search) == 0 {
-				return errors.New(fmt.Sprintf("Missing content search regex for web \"%v\".", web.Url))
-			}
-			wc.SearchRegex, err = regexp.Compile(wc.Search)
-			if err != nil {
-				return errors.New(fmt.Sprintf("Invalid content search regex for web \"%v\".", web.Url))
-			}
-
-			nSubExpr := uint(wc.SearchRegex.NumSubexp())
-			for idx := range wc.CheckChanges {
-				if wc.CheckChanges[idx] < 1 || wc.CheckChanges[idx] > nSubExpr {
-					return errors.New(fmt.Sprintf("Invalid content search regex for web \"%v\".", web.Url))
-				}
-			}
-		}
-
-		if len(web.Timeout) > 0 {
-			web.TimeoutX, ok = ValidateTimeSpan(web.Timeout)
-			if !ok {
-				return errors.New(fmt.Sprintf("Invalid web check timeout value for web \"%v\".", web.Url))
-			}
-			if web.TimeoutX < 10 * time.Second {
-				return errors.New(fmt.Sprintf("Web check timeout value for \"%v\" cannot be lower than 10 seconds.", web.Url))
-			}
-		} else {
-			web.TimeoutX = 10 * time.Second
-		}
-
-		_, ok = Config.Channels[web.Channel]
-		if !ok {
-			return errors.New(fmt.Sprintf("Channel not found for web \"%v\".", web.Url))
-		}
-
-		web.Severity = ValidateSeverity(web.Severity)
-		if len(web.Severity) == 0 {
-			return errors.New(fmt.Sprintf("Invalid severity for web \"%v\".", web.Url))
-		}
+		return nil, nil, err
 	}
 
-	//----
-
-	for idx := range Config.TcpPorts {
-		port := &Config.TcpPorts[idx]
-
-		if len(port.Name) == 0 {
-			return errors.New(fmt.Sprintf("Missing or invalid TCP port description name."))
-		}
-
-		if !valid.IsHost(port.Address) {
-			return errors.New(fmt.Sprintf("Missing or invalid address in TCP port group \"%v\".", port.Name))
-		}
-
-		port.PortsX, ok = parsePortsList(port.Ports)
-		if !ok {
-			return errors.New(fmt.Sprintf("Missing or invalid port value/range in TCP port group \"%v\".", port.Name))
-		}
-
-		if len(port.CheckPeriod) > 0 {
-			port.CheckPeriodX, ok = ValidateTimeSpan(port.CheckPeriod)
-			if !ok {
-				return errors.New(fmt.Sprintf("Invalid check period value for TCP port group \"%v\".", port.Name))
-			}
-			if port.CheckPeriodX < 10 * time.Second {
-				return errors.New(fmt.Sprintf("Check period value for TCP port group \"%v\" cannot be lower than 10 seconds.", port.Name))
-			}
-		} else {
-			port.CheckPeriodX = 10 * time.Second
-		}
-
-		if len(port.Timeout) > 0 {
-			port.TimeoutX, ok = ValidateTimeSpan(port.Timeout)
-			if !ok {
-				return errors.New(fmt.Sprintf("Invalid check timeout value for TCP port group \"%v\".", port.Name))
-			}
-			if port.TimeoutX < 10 * time.Second {
-				return errors.New(fmt.Sprintf("Check timeout value for TCP port group \"%v\" cannot be lower than 10 seconds.", port.Name))
-			}
-		} else {
-			port.TimeoutX = 10 * time.Second
-		}
+	return v.Errors, v.Warnings, nil
+}
 
-		_, ok = Config.Channels[port.Channel]
-		if !ok {
-			return errors.New(fmt.Sprintf("Channel not found for TCP Port \"%v\".", port.Name))
-		}
+// LoadApiKeys reads the settings file again and returns the global API key and the named ones. The rest of the
+// settings are not applied.
+func LoadApiKeys() (string, []SettingsJSON_ApiKey, error) {
+	var cfg SettingsJSON
+	var err error
 
-		port.Severity = ValidateSeverity(port.Severity)
-		if len(port.Severity) == 0 {
-			return errors.New(fmt.Sprintf("Invalid severity for TCP Port \"%v\".", port.Name))
-		}
+	settingsFilename := GetSettingsFilename()
+	v := newValidator(settingsFilename)
+	err = load(settingsFilename, &cfg, v)
+	if err == nil {
+		err = v.firstError()
 	}
-
-	//----
-
-	for idx := range Config.FreeDiskSpace {
-		fds := &Config.FreeDiskSpace[idx]
-
-		if len(fds.Device) == 0 {
-			return errors.New(fmt.Sprintf("Missing or invalid url specified."))
-		}
-
-		fds.Device = filepath.Clean(fds.Device)
-		if runtime.GOOS == "windows" {
-			if !strings.HasSuffix(fds.Device, string(filepath.Separator)) {
-				fds.Device += string(filepath.Separator)
-			}
-		}
-
-		diskUsage := du.NewDiskUsage(fds.Device)
-		if diskUsage.Size() == 0 {
-			return errors.New(fmt.Sprintf("Invalid or missing free disk space check device \"%v\".", fds.Device))
-		}
-		diskSize := diskUsage.Size()
-
-		fds.MinimumSpaceX, ok = ValidateMemoryAmount(fds.MinimumSpace, &diskSize)
-		if !ok {
-			return errors.New(fmt.Sprintf("Invalid free disk space check minimum value for device \"%v\".",
-											fds.Device))
-		}
-
-		if len(fds.CheckPeriod) > 0 {
-			fds.CheckPeriodX, ok = ValidateTimeSpan(fds.CheckPeriod)
-			if !ok {
-				return errors.New(fmt.Sprintf("Invalid free disk space check period value for device \"%v\".",
-												fds.Device))
-			}
-		} else {
-			fds.CheckPeriodX = -1
-		}
-
-		_, ok = Config.Channels[fds.Channel]
-		if !ok {
-			return errors.New(fmt.Sprintf("Channel not found for device \"%v\".", fds.Device))
-		}
-
-		fds.Severity = ValidateSeverity(fds.Severity)
-		if len(fds.Severity) == 0 {
-			return errors.New(fmt.Sprintf("Invalid severity for device \"%v\".", fds.Device))
-		}
+	if err != nil {
+		return "", nil, err
 	}
+	return cfg.Server.ApiKey, cfg.Server.ApiKeys, nil
+}
 
-	return nil
+// SetSettingsFilename sets the settings file to load. Relative paths are resolved from the application folder.
+func SetSettingsFilename(filename string) {
+	configFilename = filename
 }
 
-func GetSettingsFilename() (string, error) {
-	filename, err := process.GetCmdLineParam("settings")
-	if err != nil {
-		return "", err
-	}
+func GetSettingsFilename() string {
+	filename := configFilename
 	if len(filename) == 0 {
 		filename = "./settings.json"
 	}
@@ -359,7 +93,7 @@ func GetSettingsFilename() (string, error) {
 		filename = filepath.Join(process.AppPath, filename)
 	}
 	filename = filepath.Clean(filename)
-	return filename, nil
+	return filename
 }
 
 func ValidateSeverity(severity string) string {
@@ -387,6 +121,68 @@ func ValidateChannel(channel string) bool {
 	return ok
 }
 
+// ValidateWeb checks a web definition added at runtime using the same rules applied to the settings file
+func ValidateWeb(web *SettingsJSON_Webs) error {
+	v := newValidator("")
+	v.validateWeb(web, Config.Channels, "", "web")
+	return v.firstError()
+}
+
+// ValidateTcpPort checks a TCP port group definition added at runtime using the same rules applied to the settings
+// file
+func ValidateTcpPort(port *SettingsJSON_TcpPorts) error {
+	v := newValidator("")
+	v.validateTcpPort(port, Config.Channels, "", "tcpPort")
+	return v.firstError()
+}
+
+// ValidateFreeDiskSpace checks a free disk space definition added at runtime using the same rules applied to the
+// settings file
+func ValidateFreeDiskSpace(fds *SettingsJSON_FreeDiskSpace) error {
+	v := newValidator("")
+	v.validateFreeDiskSpace(fds, Config.Channels, "", "freeDiskSpace")
+	return v.firstError()
+}
+
+// ValidateJob checks a job reported at runtime that is not defined in the settings file
+func ValidateJob(job *SettingsJSON_Jobs) error {
+	v := newValidator("")
+	v.validateJob(job, Config.Channels, "", "job")
+	return v.firstError()
+}
+
+// ValidateApiKey checks an API key created at runtime. The key hash is not checked.
+func ValidateApiKey(key *SettingsJSON_ApiKey) error {
+	v := newValidator("")
+	v.validateApiKey(key, Config.Channels, false, "", "apiKey")
+	return v.firstError()
+}
+
+// ValidateMatchers checks the notification matchers of a silence created at runtime
+func ValidateMatchers(m *SettingsJSON_Matchers) error {
+	v := newValidator("")
+	v.validateMatchers(m, Config.Channels, "", "matchers")
+	return v.firstError()
+}
+
+// Matches returns true if a notification about a target of the given module satisfies all the matchers. Empty
+// matchers match anything.
+func (m *SettingsJSON_Matchers) Matches(module string, target string, channel string, severity string) bool {
+	if len(m.Channel) > 0 && m.Channel != channel {
+		return false
+	}
+	if len(m.Module) > 0 && !strings.EqualFold(m.Module, module) {
+		return false
+	}
+	if len(m.Target) > 0 && !wildcard.Match(m.Target, target) {
+		return false
+	}
+	if len(m.Severity) > 0 && m.Severity != severity {
+		return false
+	}
+	return true
+}
+
 func ValidateMaxMemoryUsage(channel string) bool {
 	_, ok := Config.Channels[channel]
 	return ok
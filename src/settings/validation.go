@@ -0,0 +1,1251 @@
+package settings
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	valid "github.com/asaskevich/govalidator"
+	"github.com/randlabs/server-watchdog/utils/cron"
+	"github.com/ricochet2200/go-disk-usage/du"
+)
+
+//------------------------------------------------------------------------------
+
+// ValidationIssue describes a problem found in a settings file
+type ValidationIssue struct {
+	File    string
+	Path    string
+	Message string
+}
+
+type validator struct {
+	Errors   []ValidationIssue
+	Warnings []ValidationIssue
+	mainFile string
+	sources  map[string][]itemSource
+	channels map[string]string
+}
+
+type itemSource struct {
+	file  string
+	index int
+}
+
+//------------------------------------------------------------------------------
+
+func (issue ValidationIssue) String() string {
+	s := ""
+	if len(issue.File) > 0 {
+		s = issue.File + ": "
+	}
+	if len(issue.Path) > 0 {
+		s += issue.Path + ": "
+	}
+	return s + issue.Message
+}
+
+//------------------------------------------------------------------------------
+
+func newValidator(mainFile string) *validator {
+	return &validator{
+		mainFile: mainFile,
+		sources:  make(map[string][]itemSource),
+		channels: make(map[string]string),
+	}
+}
+
+func (v *validator) addError(file string, path string, format string, a ...interface{}) {
+	v.Errors = append(v.Errors, ValidationIssue{
+		File:    v.displayName(file),
+		Path:    path,
+		Message: fmt.Sprintf(format, a...),
+	})
+	return
+}
+
+func (v *validator) addWarning(file string, path string, format string, a ...interface{}) {
+	v.Warnings = append(v.Warnings, ValidationIssue{
+		File:    v.displayName(file),
+		Path:    path,
+		Message: fmt.Sprintf(format, a...),
+	})
+	return
+}
+
+func (v *validator) firstError() error {
+	if len(v.Errors) > 0 {
+		return errors.New(v.Errors[0].String())
+	}
+	return nil
+}
+
+// Only included files are reported by name, main file issues just show the path
+func (v *validator) displayName(file string) string {
+	if len(file) == 0 || file == v.mainFile {
+		return ""
+	}
+	if rel, err := filepath.Rel(BaseFolder, file); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return file
+}
+
+// Records the file where the items of a section were defined
+func (v *validator) addSources(file string, section string, count int) {
+	for idx := 0; idx < count; idx++ {
+		v.sources[section] = append(v.sources[section], itemSource{
+			file:  file,
+			index: idx,
+		})
+	}
+	return
+}
+
+// Returns the file and path of a merged section item
+func (v *validator) itemLocation(section string, idx int) (string, string) {
+	if idx < len(v.sources[section]) {
+		src := v.sources[section][idx]
+		return src.file, section + "[" + strconv.Itoa(src.index) + "]"
+	}
+	return v.mainFile, section + "[" + strconv.Itoa(idx) + "]"
+}
+
+func (v *validator) channelLocation(chName string) (string, string) {
+	file, ok := v.channels[chName]
+	if !ok {
+		file = v.mainFile
+	}
+	return file, "channels." + chName
+}
+
+//------------------------------------------------------------------------------
+
+func load(filename string, cfg *SettingsJSON, v *validator) error {
+	BaseFolder = filepath.Dir(filename)
+	if !strings.HasSuffix(BaseFolder, string(filepath.Separator)) {
+		BaseFolder += string(filepath.Separator)
+	}
+
+	err := loadSettingsFile(filename, cfg, v)
+	if err == nil {
+		err = processIncludes(cfg, v)
+	}
+	if err != nil {
+		return err
+	}
+
+	validate(cfg, v)
+	return nil
+}
+
+func validate(cfg *SettingsJSON, v *validator) {
+	var ok bool
+
+	if len(cfg.Name) == 0 {
+		cfg.Name = "SERVER-WATCHDOG"
+	} else if len(cfg.Name) > 256 {
+		v.addError(v.mainFile, "name", "Name is too long. Max 256 chars.")
+	}
+
+	//----
+
+	v.validateListen(cfg)
+	if len(cfg.Server.ApiKey) == 0 && len(cfg.Server.ApiKeys) == 0 &&
+	   (cfg.Server.Tls == nil || cfg.Server.Tls.ClientAuth == nil) {
+		v.addError(v.mainFile, "server.apiKey", "Invalid server API key.")
+	}
+	if cfg.Server.Tls != nil {
+		v.validateTls(cfg.Server.Tls, cfg.Channels, v.mainFile, "server.tls")
+	}
+	if cfg.Server.RateLimit != nil {
+		v.validateRateLimit(cfg.Server.RateLimit, cfg.Channels, v.mainFile, "server.rateLimit")
+	}
+	v.validateNotify(&cfg.Server.Notify, v.mainFile, "server.notify")
+	if cfg.Server.Grpc != nil {
+		v.validateGrpc(cfg.Server.Grpc, v.mainFile, "server.grpc")
+	}
+
+	//----
+
+	if len(cfg.Log.MaxAge) > 0 {
+		cfg.Log.MaxAgeX, ok = ValidateTimeSpan(cfg.Log.MaxAge)
+		if !ok {
+			v.addError(v.mainFile, "log.maxAge", "Invalid log files max age value.")
+		} else if cfg.Log.MaxAgeX < 10 * time.Minute {
+			v.addError(v.mainFile, "log.maxAge", "Log files max age value cannot be lower than 10 minutes.")
+		}
+	} else {
+		cfg.Log.MaxAgeX = 7 * 24 * time.Hour
+	}
+
+	//----
+
+	if len(cfg.Channels) == 0 {
+		v.addError(v.mainFile, "channels", "No channels were specified.")
+	}
+	chNames := make([]string, 0, len(cfg.Channels))
+	for chName := range cfg.Channels {
+		chNames = append(chNames, chName)
+	}
+	sort.Strings(chNames)
+	for _, chName := range chNames {
+		ch := cfg.Channels[chName]
+
+		file, path := v.channelLocation(chName)
+		v.validateChannel(chName, &ch, file, path)
+
+		cfg.Channels[chName] = ch
+	}
+
+	//----
+
+	for idx := range cfg.Processes {
+		file, path := v.itemLocation("processes", idx)
+		v.validateProcess(&cfg.Processes[idx], cfg.Channels, file, path)
+	}
+
+	for idx := range cfg.Webs {
+		file, path := v.itemLocation("webs", idx)
+		v.validateWeb(&cfg.Webs[idx], cfg.Channels, file, path)
+	}
+
+	for idx := range cfg.TcpPorts {
+		file, path := v.itemLocation("tcpPorts", idx)
+		v.validateTcpPort(&cfg.TcpPorts[idx], cfg.Channels, file, path)
+	}
+
+	for idx := range cfg.FreeDiskSpace {
+		file, path := v.itemLocation("freeDiskSpace", idx)
+		v.validateFreeDiskSpace(&cfg.FreeDiskSpace[idx], cfg.Channels, file, path)
+	}
+
+	for idx := range cfg.Heartbeats {
+		file, path := v.itemLocation("heartbeats", idx)
+		v.validateHeartbeat(&cfg.Heartbeats[idx], cfg.Channels, file, path)
+
+		for prevIdx := 0; prevIdx < idx; prevIdx++ {
+			if cfg.Heartbeats[prevIdx].Name == cfg.Heartbeats[idx].Name {
+				v.addError(file, path + ".name", "Heartbeat \"%v\" is already defined.", cfg.Heartbeats[idx].Name)
+				break
+			}
+		}
+	}
+
+	for idx := range cfg.Jobs {
+		file, path := v.itemLocation("jobs", idx)
+		v.validateJob(&cfg.Jobs[idx], cfg.Channels, file, path)
+
+		for prevIdx := 0; prevIdx < idx; prevIdx++ {
+			if cfg.Jobs[prevIdx].Name == cfg.Jobs[idx].Name {
+				v.addError(file, path + ".name", "Job \"%v\" is already defined.", cfg.Jobs[idx].Name)
+				break
+			}
+		}
+	}
+
+	for idx := range cfg.Server.ApiKeys {
+		path := "server.apiKeys[" + strconv.Itoa(idx) + "]"
+		v.validateApiKey(&cfg.Server.ApiKeys[idx], cfg.Channels, true, v.mainFile, path)
+
+		for prevIdx := 0; prevIdx < idx; prevIdx++ {
+			if cfg.Server.ApiKeys[prevIdx].Name == cfg.Server.ApiKeys[idx].Name {
+				v.addError(v.mainFile, path + ".name", "API key \"%v\" is already defined.", cfg.Server.ApiKeys[idx].Name)
+				break
+			}
+		}
+	}
+
+	for idx := range cfg.Maintenance {
+		v.validateMaintenance(&cfg.Maintenance[idx], cfg.Channels, v.mainFile,
+		                      "maintenance[" + strconv.Itoa(idx) + "]")
+	}
+
+	for idx := range cfg.Escalation {
+		v.validateEscalation(&cfg.Escalation[idx], cfg.Channels, v.mainFile,
+		                     "escalation[" + strconv.Itoa(idx) + "]")
+	}
+
+	return
+}
+
+func (v *validator) validateChannel(chName string, ch *SettingsJSON_Channel, file string, path string) {
+	hasOutput := false
+
+	if len(chName) == 0 {
+		v.addError(file, path, "A channel without name was specified.")
+	} else if len(chName) > 32 {
+		v.addError(file, path, "Channel name too long. Max 32 chars.")
+	}
+
+	if ch.File != nil && ch.File.Enabled {
+		hasOutput = true
+	}
+
+	if ch.Slack != nil && ch.Slack.Enabled {
+		hasOutput = true
+		if len(ch.Slack.Channel) == 0 {
+			v.addError(file, path + ".slack.channel", "No slack hook specified for channel \"%v\".", chName)
+		}
+	}
+
+	if ch.EMail != nil && ch.EMail.Enabled {
+		hasOutput = true
+
+		if len(ch.EMail.Subject) > 256 {
+			v.addError(file, path + ".email.subject", "Email subject for channel \"%v\" is too long. Max 256 chars.",
+			           chName)
+		}
+
+		if !valid.IsEmail(ch.EMail.Sender) {
+			v.addError(file, path + ".email.sender", "Missing or invalid sender email address for channel \"%v\".",
+			           chName)
+		}
+
+		if len(ch.EMail.Receivers) == 0 {
+			v.addError(file, path + ".email.receivers", "No receiver email addresses for channel \"%v\" was specified.",
+			           chName)
+		}
+		for i := range ch.EMail.Receivers {
+			if !valid.IsEmail(ch.EMail.Receivers[i]) {
+				v.addError(file, path + ".email.receivers[" + strconv.Itoa(i) + "]",
+				           "Invalid receiver email address specified for channel \"%v\".", chName)
+			}
+		}
+
+		if len(ch.EMail.Server.UserName) == 0 {
+			v.addError(file, path + ".email.smtpServer.username", "Missing email server's username for channel \"%v\".",
+			           chName)
+		}
+		if len(ch.EMail.Server.Host) == 0 {
+			v.addError(file, path + ".email.smtpServer.host", "Missing email server's host for channel \"%v\".",
+			           chName)
+		}
+
+		if ch.EMail.Server.Port == 0 {
+			ch.EMail.Server.Port = 25
+		} else if ch.EMail.Server.Port < 1 || ch.EMail.Server.Port > 65535 {
+			v.addError(file, path + ".email.smtpServer.port", "Invalid email server's port for channel \"%v\".", chName)
+		}
+	}
+
+	if !hasOutput {
+		v.addError(file, path, "No output stream was specified for channel \"%v\".", chName)
+	}
+	return
+}
+
+func (v *validator) validateProcess(proc *SettingsJSON_Processes, channels map[string]SettingsJSON_Channel,
+                                    file string, path string) {
+	if len(proc.ExecutableName) == 0 {
+		v.addError(file, path + ".executableName", "Missing or invalid process' executable name.")
+	}
+
+	if _, ok := channels[proc.Channel]; !ok {
+		v.addError(file, path + ".channel", "Channel not found for process \"%v\".", proc.ExecutableName)
+	}
+
+	proc.Severity = ValidateSeverity(proc.Severity)
+	if len(proc.Severity) == 0 {
+		v.addError(file, path + ".severity", "Invalid severity for process \"%v\".", proc.ExecutableName)
+	}
+	return
+}
+
+func (v *validator) validateWeb(web *SettingsJSON_Webs, channels map[string]SettingsJSON_Channel,
+                                file string, path string) {
+	var ok bool
+	var err error
+
+	if !valid.IsURL(web.Url) {
+		v.addError(file, path + ".url", "Missing or invalid url specified.")
+	}
+
+	if len(web.CheckPeriod) > 0 {
+		web.CheckPeriodX, ok = ValidateTimeSpan(web.CheckPeriod)
+		if !ok {
+			v.addError(file, path + ".checkPeriod", "Invalid web check period value for web \"%v\".", web.Url)
+		} else if web.CheckPeriodX < 10 * time.Second {
+			v.addError(file, path + ".checkPeriod", "Web check period value for \"%v\" cannot be lower than 10 seconds.",
+			           web.Url)
+		}
+	} else {
+		web.CheckPeriodX = 10 * time.Second
+	}
+
+	for contentIdx := range web.Content {
+		wc := &web.Content[contentIdx]
+		contentPath := path + ".content[" + strconv.Itoa(contentIdx) + "]"
+
+		if len(wc.Search) == 0 {
+			v.addError(file, contentPath + ".search", "Missing content search regex for web \"%v\".", web.Url)
+			continue
+		}
+		wc.SearchRegex, err = regexp.Compile(wc.Search)
+		if err != nil {
+			v.addError(file, contentPath + ".search", "Invalid content search regex for web \"%v\".", web.Url)
+			continue
+		}
+
+		nSubExpr := uint(wc.SearchRegex.NumSubexp())
+		for idx := range wc.CheckChanges {
+			if wc.CheckChanges[idx] < 1 || wc.CheckChanges[idx] > nSubExpr {
+				v.addError(file, contentPath + ".checkChanges[" + strconv.Itoa(idx) + "]",
+				           "Invalid content search regex for web \"%v\".", web.Url)
+			}
+		}
+	}
+
+	if len(web.Timeout) > 0 {
+		web.TimeoutX, ok = ValidateTimeSpan(web.Timeout)
+		if !ok {
+			v.addError(file, path + ".timeout", "Invalid web check timeout value for web \"%v\".", web.Url)
+		} else if web.TimeoutX < 10 * time.Second {
+			v.addError(file, path + ".timeout", "Web check timeout value for \"%v\" cannot be lower than 10 seconds.",
+			           web.Url)
+		}
+	} else {
+		web.TimeoutX = 10 * time.Second
+	}
+
+	if _, ok = channels[web.Channel]; !ok {
+		v.addError(file, path + ".channel", "Channel not found for web \"%v\".", web.Url)
+	}
+
+	web.Severity = ValidateSeverity(web.Severity)
+	if len(web.Severity) == 0 {
+		v.addError(file, path + ".severity", "Invalid severity for web \"%v\".", web.Url)
+	}
+
+	v.validateThresholds(&web.SettingsJSON_Thresholds, file, path)
+	return
+}
+
+func (v *validator) validateTcpPort(port *SettingsJSON_TcpPorts, channels map[string]SettingsJSON_Channel,
+                                    file string, path string) {
+	var ok bool
+
+	if len(port.Name) == 0 {
+		v.addError(file, path + ".name", "Missing or invalid TCP port description name.")
+	}
+
+	if !valid.IsHost(port.Address) {
+		v.addError(file, path + ".address", "Missing or invalid address in TCP port group \"%v\".", port.Name)
+	}
+
+	port.PortsX, ok = parsePortsList(port.Ports)
+	if !ok {
+		v.addError(file, path + ".ports", "Missing or invalid port value/range in TCP port group \"%v\".", port.Name)
+	}
+
+	if len(port.CheckPeriod) > 0 {
+		port.CheckPeriodX, ok = ValidateTimeSpan(port.CheckPeriod)
+		if !ok {
+			v.addError(file, path + ".checkPeriod", "Invalid check period value for TCP port group \"%v\".", port.Name)
+		} else if port.CheckPeriodX < 10 * time.Second {
+			v.addError(file, path + ".checkPeriod",
+			           "Check period value for TCP port group \"%v\" cannot be lower than 10 seconds.", port.Name)
+		}
+	} else {
+		port.CheckPeriodX = 10 * time.Second
+	}
+
+	if len(port.Timeout) > 0 {
+		port.TimeoutX, ok = ValidateTimeSpan(port.Timeout)
+		if !ok {
+			v.addError(file, path + ".timeout", "Invalid check timeout value for TCP port group \"%v\".", port.Name)
+		} else if port.TimeoutX < 10 * time.Second {
+			v.addError(file, path + ".timeout",
+			           "Check timeout value for TCP port group \"%v\" cannot be lower than 10 seconds.", port.Name)
+		}
+	} else {
+		port.TimeoutX = 10 * time.Second
+	}
+
+	if _, ok = channels[port.Channel]; !ok {
+		v.addError(file, path + ".channel", "Channel not found for TCP Port \"%v\".", port.Name)
+	}
+
+	port.Severity = ValidateSeverity(port.Severity)
+	if len(port.Severity) == 0 {
+		v.addError(file, path + ".severity", "Invalid severity for TCP Port \"%v\".", port.Name)
+	}
+
+	v.validateThresholds(&port.SettingsJSON_Thresholds, file, path)
+	return
+}
+
+func (v *validator) validateFreeDiskSpace(fds *SettingsJSON_FreeDiskSpace, channels map[string]SettingsJSON_Channel,
+                                          file string, path string) {
+	var ok bool
+
+	if len(fds.Device) == 0 {
+		v.addError(file, path + ".device", "Missing or invalid device specified.")
+	} else {
+		fds.Device = filepath.Clean(fds.Device)
+		if runtime.GOOS == "windows" {
+			if !strings.HasSuffix(fds.Device, string(filepath.Separator)) {
+				fds.Device += string(filepath.Separator)
+			}
+		}
+
+		diskUsage := du.NewDiskUsage(fds.Device)
+		diskSize := diskUsage.Size()
+		if diskSize == 0 {
+			v.addError(file, path + ".device", "Invalid or missing free disk space check device \"%v\".", fds.Device)
+		} else {
+			fds.MinimumSpaceX, ok = ValidateMemoryAmount(fds.MinimumSpace, &diskSize)
+			if !ok {
+				v.addError(file, path + ".minimumSpace",
+				           "Invalid free disk space check minimum value for device \"%v\".", fds.Device)
+			}
+		}
+	}
+
+	if len(fds.CheckPeriod) > 0 {
+		fds.CheckPeriodX, ok = ValidateTimeSpan(fds.CheckPeriod)
+		if !ok {
+			v.addError(file, path + ".checkPeriod", "Invalid free disk space check period value for device \"%v\".",
+			           fds.Device)
+		}
+	} else {
+		fds.CheckPeriodX = -1
+	}
+
+	if _, ok = channels[fds.Channel]; !ok {
+		v.addError(file, path + ".channel", "Channel not found for device \"%v\".", fds.Device)
+	}
+
+	fds.Severity = ValidateSeverity(fds.Severity)
+	if len(fds.Severity) == 0 {
+		v.addError(file, path + ".severity", "Invalid severity for device \"%v\".", fds.Device)
+	}
+
+	v.validateThresholds(&fds.SettingsJSON_Thresholds, file, path)
+	return
+}
+
+func (v *validator) validateHeartbeat(hb *SettingsJSON_Heartbeats, channels map[string]SettingsJSON_Channel,
+                                      file string, path string) {
+	var ok bool
+
+	if !isValidUrlName(hb.Name) {
+		v.addError(file, path + ".name", "Missing or invalid heartbeat name. Only letters, digits, '-', '_' and '.' " +
+		           "are allowed.")
+	}
+
+	hb.IntervalX, ok = ValidateTimeSpan(hb.Interval)
+	if !ok {
+		v.addError(file, path + ".interval", "Missing or invalid interval for heartbeat \"%v\".", hb.Name)
+	} else if hb.IntervalX < 10 * time.Second {
+		v.addError(file, path + ".interval", "Interval for heartbeat \"%v\" cannot be lower than 10 seconds.", hb.Name)
+	}
+
+	if len(hb.Grace) > 0 {
+		hb.GraceX, ok = ValidateTimeSpan(hb.Grace)
+		if !ok {
+			v.addError(file, path + ".grace", "Invalid grace period for heartbeat \"%v\".", hb.Name)
+		}
+	} else {
+		hb.GraceX = time.Minute
+	}
+
+	if _, ok = channels[hb.Channel]; !ok {
+		v.addError(file, path + ".channel", "Channel not found for heartbeat \"%v\".", hb.Name)
+	}
+
+	hb.Severity = ValidateSeverity(hb.Severity)
+	if len(hb.Severity) == 0 {
+		v.addError(file, path + ".severity", "Invalid severity for heartbeat \"%v\".", hb.Name)
+	}
+	return
+}
+
+func (v *validator) validateJob(job *SettingsJSON_Jobs, channels map[string]SettingsJSON_Channel,
+                                file string, path string) {
+	var ok bool
+	var err error
+
+	if !isValidUrlName(job.Name) {
+		v.addError(file, path + ".name", "Missing or invalid job name. Only letters, digits, '-', '_' and '.' " +
+		           "are allowed.")
+	}
+
+	if len(job.Schedule) > 0 {
+		job.ScheduleX, err = cron.Parse(job.Schedule)
+		if err != nil {
+			v.addError(file, path + ".schedule", "Invalid schedule for job \"%v\". [%v]", job.Name, err)
+		}
+	}
+
+	if len(job.Timezone) > 0 {
+		job.TimezoneX, err = time.LoadLocation(job.Timezone)
+		if err != nil {
+			v.addError(file, path + ".timezone", "Invalid timezone for job \"%v\".", job.Name)
+		}
+	} else {
+		job.TimezoneX = time.Local
+	}
+
+	if len(job.StartGrace) > 0 {
+		job.StartGraceX, ok = ValidateTimeSpan(job.StartGrace)
+		if !ok {
+			v.addError(file, path + ".startGrace", "Invalid start grace period for job \"%v\".", job.Name)
+		}
+	} else {
+		job.StartGraceX = 5 * time.Minute
+	}
+
+	if len(job.MaxDuration) > 0 {
+		job.MaxDurationX, ok = ValidateTimeSpan(job.MaxDuration)
+		if !ok || job.MaxDurationX < time.Second {
+			v.addError(file, path + ".maxDuration", "Invalid maximum duration for job \"%v\".", job.Name)
+		}
+	}
+
+	if _, ok = channels[job.Channel]; !ok {
+		v.addError(file, path + ".channel", "Channel not found for job \"%v\".", job.Name)
+	}
+
+	job.Severity = ValidateSeverity(job.Severity)
+	if len(job.Severity) == 0 {
+		v.addError(file, path + ".severity", "Invalid severity for job \"%v\".", job.Name)
+	}
+	return
+}
+
+func (v *validator) validateApiKey(key *SettingsJSON_ApiKey, channels map[string]SettingsJSON_Channel, checkHash bool,
+                                   file string, path string) {
+	if !isValidUrlName(key.Name) {
+		v.addError(file, path + ".name", "Missing or invalid API key name. Only letters, digits, '-', '_' and '.' " +
+		           "are allowed.")
+	}
+
+	if checkHash {
+		//keys are stored as the hex encoded SHA-256 hash
+		key.KeyHash = strings.ToLower(strings.TrimSpace(key.KeyHash))
+		if len(key.KeyHash) != 64 || strings.Trim(key.KeyHash, "0123456789abcdef") != "" {
+			v.addError(file, path + ".keyHash", "Invalid hash for API key \"%v\". It must be a SHA-256 hex string.",
+			           key.Name)
+		}
+	}
+
+	if len(key.Scopes) == 0 {
+		v.addError(file, path + ".scopes", "No scopes were specified for API key \"%v\".", key.Name)
+	}
+	for idx, scope := range key.Scopes {
+		if !isValidApiKeyScope(scope) {
+			v.addError(file, path + ".scopes[" + strconv.Itoa(idx) + "]", "Invalid scope \"%v\" for API key \"%v\".",
+			           scope, key.Name)
+		}
+	}
+
+	for idx, chName := range key.Channels {
+		if _, ok := channels[chName]; !ok {
+			v.addError(file, path + ".channels[" + strconv.Itoa(idx) + "]", "Channel \"%v\" not found for API key " +
+			           "\"%v\".", chName, key.Name)
+		}
+	}
+	return
+}
+
+func (v *validator) validateListen(cfg *SettingsJSON) {
+	portRequired := len(cfg.Server.Listen) == 0
+	hasSocket := false
+
+	cfg.Server.ListenX = make([]SettingsJSON_ListenAddress, 0, len(cfg.Server.Listen))
+	for idx, addr := range cfg.Server.Listen {
+		path := "server.listen[" + strconv.Itoa(idx) + "]"
+
+		addr = strings.TrimSpace(addr)
+		if strings.HasPrefix(addr, "unix:") {
+			if len(addr) == 5 {
+				v.addError(v.mainFile, path, "Missing unix socket path.")
+				continue
+			}
+			if runtime.GOOS == "windows" {
+				v.addError(v.mainFile, path, "Unix sockets are not supported on this platform.")
+				continue
+			}
+			cfg.Server.ListenX = append(cfg.Server.ListenX, SettingsJSON_ListenAddress{
+				Network: "unix",
+				Address: resolvePath(addr[5:]),
+			})
+			hasSocket = true
+			continue
+		}
+
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			//no port specified so use the default one
+			host = strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+			port = ""
+			portRequired = true
+		} else if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			v.addError(v.mainFile, path, "Invalid port in listen address \"%v\".", addr)
+			continue
+		}
+
+		//an empty host listens on all interfaces, both IPv4 and IPv6
+		network := "tcp"
+		if ip := net.ParseIP(host); ip != nil {
+			if ip.To4() != nil {
+				network = "tcp4"
+			} else {
+				network = "tcp6"
+			}
+		} else if len(host) > 0 && !valid.IsDNSName(host) {
+			v.addError(v.mainFile, path, "Invalid listen address \"%v\".", addr)
+			continue
+		}
+
+		cfg.Server.ListenX = append(cfg.Server.ListenX, SettingsJSON_ListenAddress{
+			Network: network,
+			Address: net.JoinHostPort(host, port),
+		})
+	}
+
+	if portRequired {
+		if cfg.Server.Port < 1 || cfg.Server.Port > 65535 {
+			v.addError(v.mainFile, "server.port", "Invalid server port.")
+		} else {
+			port := strconv.FormatUint(uint64(cfg.Server.Port), 10)
+
+			if len(cfg.Server.Listen) == 0 {
+				cfg.Server.ListenX = append(cfg.Server.ListenX, SettingsJSON_ListenAddress{
+					Network: "tcp4",
+					Address: "0.0.0.0:" + port,
+				})
+			}
+			for idx := range cfg.Server.ListenX {
+				if strings.HasSuffix(cfg.Server.ListenX[idx].Address, ":") {
+					cfg.Server.ListenX[idx].Address += port
+				}
+			}
+		}
+	} else if cfg.Server.Port > 65535 {
+		v.addError(v.mainFile, "server.port", "Invalid server port.")
+	}
+
+	if len(cfg.Server.SocketMode) > 0 {
+		mode, err := strconv.ParseUint(cfg.Server.SocketMode, 8, 32)
+		if err != nil || mode > 0777 {
+			v.addError(v.mainFile, "server.socketMode", "Invalid socket permissions. Use an octal value like 0660.")
+		}
+		cfg.Server.SocketModeX = os.FileMode(mode)
+		if !hasSocket {
+			v.addWarning(v.mainFile, "server.socketMode", "Socket permissions are only used with unix sockets.")
+		}
+	} else {
+		cfg.Server.SocketModeX = 0660
+	}
+	return
+}
+
+func (v *validator) validateGrpc(grpc *SettingsJSON_Grpc, file string, path string) {
+	host, port, err := net.SplitHostPort(strings.TrimSpace(grpc.Listen))
+	if err != nil {
+		v.addError(file, path + ".listen", "Invalid gRPC listen address. Use a value like :3005 or 127.0.0.1:3005.")
+		return
+	}
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		v.addError(file, path + ".listen", "Invalid port in gRPC listen address.")
+		return
+	}
+
+	//an empty host listens on all interfaces, both IPv4 and IPv6
+	network := "tcp"
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.To4() != nil {
+			network = "tcp4"
+		} else {
+			network = "tcp6"
+		}
+	} else if len(host) > 0 && !valid.IsDNSName(host) {
+		v.addError(file, path + ".listen", "Invalid gRPC listen address.")
+		return
+	}
+
+	grpc.ListenX = SettingsJSON_ListenAddress{
+		Network: network,
+		Address: net.JoinHostPort(host, port),
+	}
+	return
+}
+
+func (v *validator) validateNotify(notify *SettingsJSON_Notify, file string, path string) {
+	var ok bool
+
+	if len(notify.DedupWindow) > 0 {
+		notify.DedupWindowX, ok = ValidateTimeSpan(notify.DedupWindow)
+		if !ok || notify.DedupWindowX < time.Second {
+			v.addError(file, path + ".dedupWindow", "Invalid deduplication window.")
+		}
+	} else {
+		notify.DedupWindowX = 5 * time.Minute
+	}
+
+	if len(notify.IdempotencyWindow) > 0 {
+		notify.IdempotencyWindowX, ok = ValidateTimeSpan(notify.IdempotencyWindow)
+		if !ok || notify.IdempotencyWindowX < time.Second {
+			v.addError(file, path + ".idempotencyWindow", "Invalid idempotency window.")
+		}
+	} else {
+		notify.IdempotencyWindowX = 24 * time.Hour
+	}
+	return
+}
+
+func (v *validator) validateRateLimit(rl *SettingsJSON_RateLimit, channels map[string]SettingsJSON_Channel,
+                                     file string, path string) {
+	var ok bool
+
+	if rl.PerKey == nil && rl.PerIp == nil {
+		v.addWarning(file, path, "No rate limits were specified.")
+	}
+
+	if rl.PerKey != nil {
+		rl.PerKey.RateX, ok = parseRate(rl.PerKey.Rate)
+		if !ok {
+			v.addError(file, path + ".perKey.rate", "Invalid rate. Use a value like 10/s, 100/m or 1000/h.")
+		}
+		if rl.PerKey.Burst == 0 {
+			rl.PerKey.Burst = 1
+		}
+	}
+	if rl.PerIp != nil {
+		rl.PerIp.RateX, ok = parseRate(rl.PerIp.Rate)
+		if !ok {
+			v.addError(file, path + ".perIp.rate", "Invalid rate. Use a value like 10/s, 100/m or 1000/h.")
+		}
+		if rl.PerIp.Burst == 0 {
+			rl.PerIp.Burst = 1
+		}
+	}
+
+	if len(rl.Channel) > 0 {
+		if _, ok = channels[rl.Channel]; !ok {
+			v.addError(file, path + ".channel", "Channel not found for rate limit notifications.")
+		}
+	}
+
+	rl.Severity = ValidateSeverity(rl.Severity)
+	if len(rl.Severity) == 0 {
+		v.addError(file, path + ".severity", "Invalid severity for rate limit notifications.")
+	}
+	return
+}
+
+func (v *validator) validateTls(t *SettingsJSON_Tls, channels map[string]SettingsJSON_Channel, file string,
+                                path string) {
+	if len(t.CertFile) == 0 {
+		v.addError(file, path + ".certFile", "Missing certificate file.")
+	} else {
+		t.CertFile = resolvePath(t.CertFile)
+	}
+	if len(t.KeyFile) == 0 {
+		v.addError(file, path + ".keyFile", "Missing private key file.")
+	} else {
+		t.KeyFile = resolvePath(t.KeyFile)
+	}
+
+	switch t.MinVersion {
+	case "1.0":
+		t.MinVersionX = tls.VersionTLS10
+	case "1.1":
+		t.MinVersionX = tls.VersionTLS11
+	case "", "1.2":
+		t.MinVersionX = tls.VersionTLS12
+	case "1.3":
+		t.MinVersionX = tls.VersionTLS13
+	default:
+		v.addError(file, path + ".minVersion", "Invalid minimum TLS version.")
+	}
+
+	if t.ClientAuth != nil {
+		ca := t.ClientAuth
+
+		if len(ca.CAFile) == 0 {
+			v.addError(file, path + ".clientAuth.caFile", "Missing client certificate authority file.")
+		} else {
+			ca.CAFile = resolvePath(ca.CAFile)
+		}
+
+		if len(ca.AllowedCNs) == 0 {
+			v.addError(file, path + ".clientAuth.allowedCNs", "No allowed common names were specified.")
+		}
+		for idx, cn := range ca.AllowedCNs {
+			if len(strings.TrimSpace(cn)) == 0 {
+				v.addError(file, path + ".clientAuth.allowedCNs[" + strconv.Itoa(idx) + "]", "Invalid common name.")
+			}
+		}
+
+		//client certificates have full access unless scopes are specified
+		if len(ca.Scopes) == 0 {
+			ca.Scopes = []string{ "admin" }
+		}
+		for idx, scope := range ca.Scopes {
+			if !isValidApiKeyScope(scope) {
+				v.addError(file, path + ".clientAuth.scopes[" + strconv.Itoa(idx) + "]", "Invalid scope \"%v\".", scope)
+			}
+		}
+
+		for idx, chName := range ca.Channels {
+			if _, ok := channels[chName]; !ok {
+				v.addError(file, path + ".clientAuth.channels[" + strconv.Itoa(idx) + "]", "Channel \"%v\" not found.",
+				           chName)
+			}
+		}
+	}
+	return
+}
+
+func (v *validator) validateThresholds(th *SettingsJSON_Thresholds, file string, path string) {
+	var ok bool
+
+	if th.FailureThreshold == 0 {
+		th.FailureThreshold = 1
+	} else if th.FailureThreshold > 100 {
+		v.addError(file, path + ".failureThreshold", "Failure threshold cannot be greater than 100.")
+	}
+
+	if th.SuccessThreshold == 0 {
+		th.SuccessThreshold = 1
+	} else if th.SuccessThreshold > 100 {
+		v.addError(file, path + ".successThreshold", "Success threshold cannot be greater than 100.")
+	}
+
+	if th.FlapThreshold == 1 || th.FlapThreshold > 100 {
+		v.addError(file, path + ".flapThreshold", "Flap threshold must be between 2 and 100.")
+	}
+
+	if len(th.FlapWindow) > 0 {
+		th.FlapWindowX, ok = ValidateTimeSpan(th.FlapWindow)
+		if !ok {
+			v.addError(file, path + ".flapWindow", "Invalid flap detection window.")
+		} else if th.FlapWindowX < time.Minute {
+			v.addError(file, path + ".flapWindow", "Flap detection window cannot be lower than 1 minute.")
+		}
+	} else {
+		th.FlapWindowX = 10 * time.Minute
+	}
+	return
+}
+
+func (v *validator) validateMaintenance(mw *SettingsJSON_Maintenance, channels map[string]SettingsJSON_Channel,
+                                        file string, path string) {
+	var ok bool
+	var err error
+
+	if len(mw.Name) == 0 {
+		v.addError(file, path + ".name", "Missing or invalid maintenance window name.")
+	}
+
+	mw.ScheduleX, err = cron.Parse(mw.Schedule)
+	if err != nil {
+		v.addError(file, path + ".schedule", "Invalid schedule for maintenance window \"%v\". [%v]", mw.Name, err)
+	}
+
+	mw.DurationX, ok = ValidateTimeSpan(mw.Duration)
+	if !ok || mw.DurationX < time.Minute {
+		v.addError(file, path + ".duration", "Invalid duration for maintenance window \"%v\".", mw.Name)
+	}
+
+	if len(mw.Timezone) > 0 {
+		mw.TimezoneX, err = time.LoadLocation(mw.Timezone)
+		if err != nil {
+			v.addError(file, path + ".timezone", "Invalid timezone for maintenance window \"%v\".", mw.Name)
+		}
+	} else {
+		mw.TimezoneX = time.Local
+	}
+
+	switch mw.Action {
+	case "":
+		mw.Action = "suppress"
+	case "suppress":
+	case "downgrade":
+		if len(mw.DowngradeTo) == 0 {
+			mw.DowngradeTo = "info"
+		}
+	default:
+		v.addError(file, path + ".action", "Invalid action for maintenance window \"%v\".", mw.Name)
+	}
+
+	if len(mw.DowngradeTo) > 0 {
+		mw.DowngradeTo = ValidateSeverity(mw.DowngradeTo)
+		if len(mw.DowngradeTo) == 0 {
+			v.addError(file, path + ".downgradeTo", "Invalid severity for maintenance window \"%v\".", mw.Name)
+		}
+	}
+
+	v.validateMatchers(&mw.Matchers, channels, file, path + ".matchers")
+	return
+}
+
+func (v *validator) validateEscalation(esc *SettingsJSON_Escalation, channels map[string]SettingsJSON_Channel,
+                                       file string, path string) {
+	var ok bool
+
+	if len(esc.Name) == 0 {
+		v.addError(file, path + ".name", "Missing or invalid escalation policy name.")
+	}
+
+	if len(esc.Steps) == 0 {
+		v.addError(file, path + ".steps", "No steps were specified for escalation policy \"%v\".", esc.Name)
+	}
+	for idx := range esc.Steps {
+		step := &esc.Steps[idx]
+		stepPath := path + ".steps[" + strconv.Itoa(idx) + "]"
+
+		step.AfterX, ok = ValidateTimeSpan(step.After)
+		if !ok || step.AfterX < time.Minute {
+			v.addError(file, stepPath + ".after", "Invalid delay for escalation policy \"%v\". Minimum is 1 minute.",
+			           esc.Name)
+		} else if idx > 0 && step.AfterX <= esc.Steps[idx - 1].AfterX {
+			v.addError(file, stepPath + ".after", "Steps of escalation policy \"%v\" must be sorted by delay.",
+			           esc.Name)
+		}
+
+		if len(step.Channel) > 0 {
+			if _, ok = channels[step.Channel]; !ok {
+				v.addError(file, stepPath + ".channel", "Channel \"%v\" not found.", step.Channel)
+			}
+		}
+
+		if len(step.Severity) > 0 {
+			severity := ValidateSeverity(step.Severity)
+			if len(severity) == 0 {
+				v.addError(file, stepPath + ".severity", "Invalid severity \"%v\".", step.Severity)
+			}
+			step.Severity = severity
+		}
+	}
+
+	v.validateMatchers(&esc.Matchers, channels, file, path + ".matchers")
+	return
+}
+
+func (v *validator) validateMatchers(m *SettingsJSON_Matchers, channels map[string]SettingsJSON_Channel,
+                                     file string, path string) {
+	if len(m.Channel) > 0 {
+		if _, ok := channels[m.Channel]; !ok {
+			v.addError(file, path + ".channel", "Channel \"%v\" not found.", m.Channel)
+		}
+	}
+
+	if len(m.Module) > 0 {
+		found := false
+		for _, name := range MatcherModules {
+			if strings.EqualFold(m.Module, name) {
+				m.Module = name
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.addError(file, path + ".module", "Invalid module \"%v\".", m.Module)
+		}
+	}
+
+	if len(m.Severity) > 0 {
+		severity := ValidateSeverity(m.Severity)
+		if len(severity) == 0 {
+			v.addError(file, path + ".severity", "Invalid severity \"%v\".", m.Severity)
+		}
+		m.Severity = severity
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// Walks a decoded settings tree and reports the keys that do not map to any field of the target type
+func (v *validator) checkUnknownKeys(file string, path string, value interface{}, t reflect.Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		for _, key := range sortedKeys(m) {
+			item := m[key]
+
+			field, found := findJSONField(t, key)
+			if !found {
+				v.addWarning(file, joinPath(path, key), "Unknown key.")
+				continue
+			}
+			v.checkUnknownKeys(file, joinPath(path, key), item, field.Type)
+		}
+
+	case reflect.Map:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		for _, key := range sortedKeys(m) {
+			v.checkUnknownKeys(file, joinPath(path, key), m[key], t.Elem())
+		}
+
+	case reflect.Slice:
+		items, ok := value.([]interface{})
+		if !ok {
+			//toml decodes arrays of tables into typed slices
+			if tables, ok := value.([]map[string]interface{}); ok {
+				for idx, item := range tables {
+					v.checkUnknownKeys(file, path + "[" + strconv.Itoa(idx) + "]", item, t.Elem())
+				}
+			}
+			return
+		}
+
+		for idx, item := range items {
+			v.checkUnknownKeys(file, path + "[" + strconv.Itoa(idx) + "]", item, t.Elem())
+		}
+	}
+	return
+}
+
+// Returns the json name of a struct field and false if the field is not serialized
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	if len(field.PkgPath) > 0 {
+		return "", false //unexported
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name := strings.Split(tag, ",")[0]
+	if len(name) == 0 {
+		name = field.Name
+	}
+	return name, true
+}
+
+// Mimics encoding/json key matching which is case insensitive
+func findJSONField(t reflect.Type, key string) (reflect.StructField, bool) {
+	var candidate reflect.StructField
+
+	found := false
+	for idx := 0; idx < t.NumField(); idx++ {
+		field := t.Field(idx)
+
+		//fields of embedded structs are promoted
+		if isEmbeddedStruct(field) {
+			if embeddedField, ok := findJSONField(field.Type, key); ok {
+				if name, _ := jsonFieldName(embeddedField); name == key {
+					return embeddedField, true
+				}
+				if !found {
+					candidate = embeddedField
+					found = true
+				}
+			}
+			continue
+		}
+
+		name, ok := jsonFieldName(field)
+		if ok {
+			if name == key {
+				return field, true
+			}
+			if !found && strings.EqualFold(name, key) {
+				candidate = field
+				found = true
+			}
+		}
+	}
+	return candidate, found
+}
+
+// Names used as a path segment of an endpoint url are limited to a safe set of characters
+func isValidUrlName(name string) bool {
+	if len(name) == 0 || len(name) > 128 {
+		return false
+	}
+	for _, ch := range name {
+		if !((ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') ||
+		     ch == '-' || ch == '_' || ch == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+func isEmbeddedStruct(field reflect.StructField) bool {
+	return field.Anonymous && field.Type.Kind() == reflect.Struct && len(field.Tag.Get("json")) == 0
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinPath(path string, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+	return path + "." + key
+}
+
+func isValidApiKeyScope(scope string) bool {
+	for _, s := range ApiKeyScopes {
+		if scope == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Relative paths are resolved from the location of the main settings file
+func resolvePath(path string) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(BaseFolder, path)
+	}
+	return filepath.Clean(path)
+}
+
+// parseRate converts a rate like 10/s, 100/m or 1000/h to requests per second
+func parseRate(rate string) (float64, bool) {
+	parts := strings.Split(strings.TrimSpace(rate), "/")
+	if len(parts) != 2 {
+		return 0, false
+	}
+
+	count, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || count <= 0 {
+		return 0, false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(parts[1])) {
+	case "s", "sec", "second":
+		return count, true
+	case "m", "min", "minute":
+		return count / 60, true
+	case "h", "hour":
+		return count / 3600, true
+	}
+	return 0, false
+}
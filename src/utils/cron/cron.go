@@ -0,0 +1,213 @@
+package cron
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// Schedule is a parsed standard five field cron expression: minute, hour, day of month, month and day of week
+type Schedule struct {
+	minute     uint64
+	hour       uint64
+	dayOfMonth uint64
+	month      uint64
+	dayOfWeek  uint64
+	anyDom     bool
+	anyDow     bool
+}
+
+type fieldBounds struct {
+	name string
+	min  int
+	max  int
+}
+
+//------------------------------------------------------------------------------
+
+var bounds = []fieldBounds{
+	{ "minute", 0, 59 },
+	{ "hour", 0, 23 },
+	{ "day of month", 1, 31 },
+	{ "month", 1, 12 },
+	{ "day of week", 0, 7 },
+}
+
+var shortcuts = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+//------------------------------------------------------------------------------
+
+// Parse parses a cron expression. Each field accepts '*', values, ranges (a-b), steps (*/n or a-b/n) and comma
+// separated lists of them. Day of week accepts both 0 and 7 as Sunday.
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if s, ok := shortcuts[strings.ToLower(expr)]; ok {
+		expr = s
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, errors.New("a cron expression must have five fields")
+	}
+
+	values := make([]uint64, 5)
+	for idx, field := range fields {
+		v, err := parseField(field, bounds[idx])
+		if err != nil {
+			return nil, err
+		}
+		values[idx] = v
+	}
+
+	//sunday can be specified as 0 or 7
+	if values[4] & (1 << 7) != 0 {
+		values[4] |= 1
+	}
+
+	return &Schedule{
+		minute:     values[0],
+		hour:       values[1],
+		dayOfMonth: values[2],
+		month:      values[3],
+		dayOfWeek:  values[4],
+		anyDom:     fields[2] == "*",
+		anyDow:     fields[4] == "*",
+	}, nil
+}
+
+// Matches returns true if the schedule fires at the minute of the given time
+func (s *Schedule) Matches(t time.Time) bool {
+	return s.minute & (1 << uint(t.Minute())) != 0 && s.hour & (1 << uint(t.Hour())) != 0 &&
+	       s.month & (1 << uint(t.Month())) != 0 && s.matchesDay(t)
+}
+
+// IsActive returns true if a window that starts at each schedule activation and lasts the given duration contains
+// the given time
+func (s *Schedule) IsActive(t time.Time, duration time.Duration) bool {
+	if duration <= 0 {
+		return false
+	}
+
+	//the first activation after the window start must not be later than the given time
+	next := s.Next(t.Add(-duration))
+	return !next.IsZero() && !next.After(t)
+}
+
+// Next returns the first activation after the given time or the zero time if there is none within a year
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(1, 0, 1)
+
+	//skip whole months, days and hours that cannot match before looking for the minute
+	for t.Before(limit) {
+		loc := t.Location()
+
+		if s.month & (1 << uint(t.Month())) == 0 {
+			t = advance(t, time.Date(t.Year(), t.Month() + 1, 1, 0, 0, 0, 0, loc))
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = advance(t, time.Date(t.Year(), t.Month(), t.Day() + 1, 0, 0, 0, 0, loc))
+			continue
+		}
+		if s.hour & (1 << uint(t.Hour())) == 0 {
+			t = nextHour(t)
+			continue
+		}
+
+		for minute := t.Minute(); minute < 60; minute++ {
+			if s.minute & (1 << uint(minute)) != 0 {
+				return t.Add(time.Duration(minute - t.Minute()) * time.Minute)
+			}
+		}
+		t = nextHour(t)
+	}
+	return time.Time{}
+}
+
+//------------------------------------------------------------------------------
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	domMatch := s.dayOfMonth & (1 << uint(t.Day())) != 0
+	dowMatch := s.dayOfWeek & (1 << uint(t.Weekday())) != 0
+
+	//like in standard cron, if both day fields are restricted, any of them must match
+	if !s.anyDom && !s.anyDow {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// Returns the next time to check. Daylight saving changes can make the computed time not later than the current one
+// so, in that case, moves to the next minute.
+func advance(t time.Time, next time.Time) time.Time {
+	if !next.After(t) {
+		return t.Add(time.Minute)
+	}
+	return next
+}
+
+// Returns the start of the next hour. Minutes are added instead of building the date so hours repeated when daylight
+// saving ends are not skipped.
+func nextHour(t time.Time) time.Time {
+	return t.Add(time.Duration(60 - t.Minute()) * time.Minute)
+}
+
+func parseField(field string, b fieldBounds) (uint64, error) {
+	var result uint64
+
+	for _, part := range strings.Split(field, ",") {
+		var err error
+
+		step := 1
+		rangeStart := b.min
+		rangeEnd := b.max
+
+		if slash := strings.Index(part, "/"); slash >= 0 {
+			step, err = strconv.Atoi(part[slash + 1:])
+			if err != nil || step < 1 {
+				return 0, errors.New("invalid step in " + b.name + " field")
+			}
+			part = part[:slash]
+		}
+
+		if part != "*" {
+			dash := strings.Index(part, "-")
+			if dash >= 0 {
+				rangeStart, err = strconv.Atoi(part[:dash])
+				if err == nil {
+					rangeEnd, err = strconv.Atoi(part[dash + 1:])
+				}
+			} else {
+				rangeStart, err = strconv.Atoi(part)
+				rangeEnd = rangeStart
+				if err == nil && step > 1 {
+					rangeEnd = b.max //like 5/10
+				}
+			}
+			if err != nil {
+				return 0, errors.New("invalid value in " + b.name + " field")
+			}
+			if rangeStart < b.min || rangeEnd > b.max || rangeStart > rangeEnd {
+				return 0, errors.New("value out of range in " + b.name + " field")
+			}
+		}
+
+		for v := rangeStart; v <= rangeEnd; v += step {
+			result |= 1 << uint(v)
+		}
+	}
+
+	return result, nil
+}
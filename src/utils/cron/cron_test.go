@@ -0,0 +1,201 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr  string
+		valid bool
+	}{
+		{ "* * * * *", true },
+		{ "0 0 1 1 *", true },
+		{ "*/5 1-5 1,15 1-12/2 0-7", true },
+		{ "5/10 * * * *", true },
+		{ "  30 2 * * 1  ", true },
+		{ "@daily", true },
+		{ "@HOURLY", true },
+		{ "", false },
+		{ "* * * *", false },
+		{ "* * * * * *", false },
+		{ "60 * * * *", false },
+		{ "* 24 * * *", false },
+		{ "* * 0 * *", false },
+		{ "* * * 13 *", false },
+		{ "* * * * 8", false },
+		{ "5-1 * * * *", false },
+		{ "*/0 * * * *", false },
+		{ "a * * * *", false },
+		{ "1-a * * * *", false },
+		{ "@never", false },
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := Parse(tc.expr)
+			if tc.valid && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Fatal("invalid expression accepted")
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		time string
+		want bool
+	}{
+		{ "every minute", "* * * * *", "2024-01-01 10:07", true },
+		{ "minute and hour", "30 2 * * *", "2024-01-01 02:30", true },
+		{ "other minute", "30 2 * * *", "2024-01-01 02:31", false },
+		{ "step", "*/15 * * * *", "2024-01-01 10:45", true },
+		{ "step mismatch", "*/15 * * * *", "2024-01-01 10:40", false },
+		{ "offset step", "5/10 * * * *", "2024-01-01 10:25", true },
+		{ "list", "0 8,20 * * *", "2024-01-01 20:00", true },
+		{ "month", "0 0 * 2 *", "2024-01-01 00:00", false },
+		{ "sunday as 0", "0 0 * * 0", "2024-01-07 00:00", true },
+		{ "sunday as 7", "0 0 * * 7", "2024-01-07 00:00", true },
+		{ "weekday range", "0 0 * * 1-5", "2024-01-06 00:00", false },
+		{ "only day of month", "0 0 13 * *", "2024-01-13 00:00", true },
+		{ "only day of month mismatch", "0 0 13 * *", "2024-01-05 00:00", false },
+		{ "only day of week", "0 0 * * 5", "2024-01-05 00:00", true },
+		{ "only day of week mismatch", "0 0 * * 5", "2024-01-13 00:00", false },
+		{ "both days, day of month matches", "0 0 13 * 5", "2024-01-13 00:00", true },
+		{ "both days, day of week matches", "0 0 13 * 5", "2024-01-05 00:00", true },
+		{ "both days, none matches", "0 0 13 * 5", "2024-01-06 00:00", false },
+		{ "shortcut", "@monthly", "2024-03-01 00:00", true },
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := mustParse(t, tc.expr)
+			if got := s.Matches(mustTime(t, tc.time)); got != tc.want {
+				t.Fatalf("Matches(%v) = %v, want %v", tc.time, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsActive(t *testing.T) {
+	tests := []struct {
+		time string
+		want bool
+	}{
+		{ "2024-01-01 01:59", false },
+		{ "2024-01-01 02:00", true },
+		{ "2024-01-01 02:30", true },
+		{ "2024-01-01 02:59", true },
+		{ "2024-01-01 03:00", false },
+		{ "2024-01-02 02:15", true },
+	}
+
+	s := mustParse(t, "0 2 * * *")
+	for _, tc := range tests {
+		t.Run(tc.time, func(t *testing.T) {
+			if got := s.IsActive(mustTime(t, tc.time), time.Hour); got != tc.want {
+				t.Fatalf("IsActive(%v) = %v, want %v", tc.time, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		from string
+		want string
+	}{
+		{ "next step", "*/15 * * * *", "2024-01-01 10:07", "2024-01-01 10:15" },
+		{ "strictly after", "*/15 * * * *", "2024-01-01 10:15", "2024-01-01 10:30" },
+		{ "next day", "30 2 * * *", "2024-01-01 03:00", "2024-01-02 02:30" },
+		{ "next weekday", "0 9 * * 1-5", "2024-01-05 10:00", "2024-01-08 09:00" },
+		{ "leap day", "0 0 29 2 *", "2024-01-01 00:00", "2024-02-29 00:00" },
+		{ "none within a year", "0 0 29 2 *", "2024-03-01 00:00", "" },
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := mustParse(t, tc.expr)
+			got := s.Next(mustTime(t, tc.from))
+			if len(tc.want) == 0 {
+				if !got.IsZero() {
+					t.Fatalf("Next(%v) = %v, want the zero time", tc.from, got)
+				}
+				return
+			}
+			if want := mustTime(t, tc.want); !got.Equal(want) {
+				t.Fatalf("Next(%v) = %v, want %v", tc.from, got, want)
+			}
+		})
+	}
+}
+
+func TestNextDaylightSaving(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "skipped hour",
+			expr: "30 2 * * *",
+			from: time.Date(2024, 3, 10, 1, 0, 0, 0, loc),
+			want: time.Date(2024, 3, 11, 2, 30, 0, 0, loc),
+		},
+		{
+			name: "next hour after the skipped one",
+			expr: "0 3 * * *",
+			from: time.Date(2024, 3, 10, 1, 59, 0, 0, loc),
+			want: time.Date(2024, 3, 10, 3, 0, 0, 0, loc),
+		},
+		{
+			name: "repeated hour",
+			expr: "0 1 * * *",
+			from: time.Date(2024, 11, 3, 1, 30, 0, 0, loc), //first occurrence
+			want: time.Date(2024, 11, 3, 1, 30, 0, 0, loc).Add(30 * time.Minute),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := mustParse(t, tc.expr)
+			if got := s.Next(tc.from); !got.Equal(tc.want) {
+				t.Fatalf("Next(%v) = %v, want %v", tc.from, got, tc.want)
+			}
+		})
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func mustParse(t *testing.T, expr string) *Schedule {
+	s, err := Parse(expr)
+	if err != nil {
+		t.Fatalf("unable to parse \"%v\": %v", expr, err)
+	}
+	return s
+}
+
+func mustTime(t *testing.T, value string) time.Time {
+	tm, err := time.Parse("2006-01-02 15:04", value)
+	if err != nil {
+		t.Fatalf("unable to parse time \"%v\": %v", value, err)
+	}
+	return tm
+}
@@ -1,6 +1,8 @@
 package guid
 
 import (
+	"crypto/rand"
+	"encoding/binary"
 	"fmt"
 	"strconv"
 )
@@ -16,6 +18,25 @@ type Guid struct {
 
 //------------------------------------------------------------------------------
 
+// New creates a random (version 4) guid
+func New() (Guid, error) {
+	var guid Guid
+	var b [16]byte
+
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return Guid{}, err
+	}
+
+	guid.Value1 = binary.BigEndian.Uint32(b[0:4])
+	guid.Value2 = binary.BigEndian.Uint16(b[4:6])
+	guid.Value3 = (binary.BigEndian.Uint16(b[6:8]) & 0x0FFF) | 0x4000
+	copy(guid.Value4[:], b[8:16])
+	guid.Value4[0] = (guid.Value4[0] & 0x3F) | 0x80
+
+	return guid, nil
+}
+
 func FromString(s string) (Guid, bool) {
 	var guid Guid
 	var n uint64
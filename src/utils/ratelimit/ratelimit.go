@@ -0,0 +1,140 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	pruneInterval = time.Minute
+)
+
+//------------------------------------------------------------------------------
+
+// Limiter implements a token bucket for each client. Buckets are refilled at a constant rate up to the burst size and
+// each request takes one token, or one per item for requests containing many.
+type Limiter struct {
+	mtx       sync.Mutex
+	rate      float64 //tokens per second
+	burst     float64
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+// Result is the outcome of a request to a limiter
+type Result struct {
+	Allowed bool
+
+	// Granted is the number of tokens taken. It can be lower than the requested amount when not allowed.
+	Granted int
+
+	// RetryAfter is the time to wait until a token becomes available. Only set if the request was not allowed.
+	RetryAfter time.Duration
+
+	// ThrottlingStarted is true for the first rejected request after the client was allowed
+	ThrottlingStarted bool
+}
+
+type bucket struct {
+	tokens     float64
+	lastUpdate time.Time
+	throttled  bool
+}
+
+//------------------------------------------------------------------------------
+
+// New creates a limiter. The rate is expressed in requests per second.
+func New(rate float64, burst uint) *Limiter {
+	if burst == 0 {
+		burst = 1
+	}
+
+	return &Limiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token from the bucket of the client, if available
+func (l *Limiter) Allow(client string) Result {
+	return l.AllowN(client, 1)
+}
+
+// AllowN takes n tokens from the bucket of the client. If not enough tokens are available, takes the available ones
+// and returns the amount in Granted.
+func (l *Limiter) AllowN(client string, n int) Result {
+	var res Result
+
+	now := time.Now()
+
+	l.mtx.Lock()
+
+	l.prune(now)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{
+			tokens:     l.burst,
+			lastUpdate: now,
+		}
+		l.buckets[client] = b
+	} else {
+		b.tokens = math.Min(l.burst, b.tokens + now.Sub(b.lastUpdate).Seconds() * l.rate)
+		b.lastUpdate = now
+	}
+
+	if b.tokens >= float64(n) {
+		b.tokens -= float64(n)
+		b.throttled = false
+
+		res.Allowed = true
+		res.Granted = n
+	} else {
+		if b.tokens >= 1 {
+			res.Granted = int(b.tokens)
+			b.tokens -= float64(res.Granted)
+		}
+		res.RetryAfter = time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		res.ThrottlingStarted = !b.throttled
+		b.throttled = true
+	}
+
+	l.mtx.Unlock()
+
+	return res
+}
+
+// Refund returns tokens taken by AllowN, for e.g., when the request was rejected by another limit
+func (l *Limiter) Refund(client string, n int) {
+	if n <= 0 {
+		return
+	}
+
+	l.mtx.Lock()
+	if b, ok := l.buckets[client]; ok {
+		b.tokens = math.Min(l.burst, b.tokens + float64(n))
+	}
+	l.mtx.Unlock()
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// Full buckets are removed because a new bucket is equivalent
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < pruneInterval {
+		return
+	}
+	l.lastPrune = now
+
+	for client, b := range l.buckets {
+		if b.tokens + now.Sub(b.lastUpdate).Seconds() * l.rate >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+	return
+}
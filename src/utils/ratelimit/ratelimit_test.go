@@ -0,0 +1,171 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+func TestBurst(t *testing.T) {
+	tests := []struct {
+		name    string
+		burst   uint
+		n       []int
+		allowed []bool
+		granted []int
+	}{
+		{
+			name:    "zero burst acts as one",
+			burst:   0,
+			n:       []int{ 1, 1 },
+			allowed: []bool{ true, false },
+			granted: []int{ 1, 0 },
+		},
+		{
+			name:    "single tokens up to the burst",
+			burst:   3,
+			n:       []int{ 1, 1, 1, 1 },
+			allowed: []bool{ true, true, true, false },
+			granted: []int{ 1, 1, 1, 0 },
+		},
+		{
+			name:    "many tokens at once",
+			burst:   5,
+			n:       []int{ 5, 1 },
+			allowed: []bool{ true, false },
+			granted: []int{ 5, 0 },
+		},
+		{
+			name:    "partial grant takes the available tokens",
+			burst:   5,
+			n:       []int{ 3, 4, 1 },
+			allowed: []bool{ true, false, false },
+			granted: []int{ 3, 2, 0 },
+		},
+		{
+			name:    "more than the burst",
+			burst:   2,
+			n:       []int{ 10 },
+			allowed: []bool{ false },
+			granted: []int{ 2 },
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			//a very low rate so no tokens are refilled while the test runs
+			l := New(0.001, tc.burst)
+			for idx, n := range tc.n {
+				res := l.AllowN("client", n)
+				if res.Allowed != tc.allowed[idx] || res.Granted != tc.granted[idx] {
+					t.Fatalf("request #%d: got allowed=%v granted=%v, want allowed=%v granted=%v", idx,
+					         res.Allowed, res.Granted, tc.allowed[idx], tc.granted[idx])
+				}
+				if !res.Allowed && res.RetryAfter <= 0 {
+					t.Fatalf("request #%d: missing retry after", idx)
+				}
+			}
+		})
+	}
+}
+
+func TestClientsAreIndependent(t *testing.T) {
+	l := New(0.001, 1)
+
+	if !l.Allow("a").Allowed {
+		t.Fatal("first request of client a was rejected")
+	}
+	if !l.Allow("b").Allowed {
+		t.Fatal("first request of client b was rejected")
+	}
+	if l.Allow("a").Allowed {
+		t.Fatal("second request of client a was allowed")
+	}
+}
+
+func TestThrottlingStarted(t *testing.T) {
+	l := New(0.001, 1)
+
+	l.Allow("client")
+	if res := l.Allow("client"); !res.ThrottlingStarted {
+		t.Fatal("first rejected request did not start throttling")
+	}
+	if res := l.Allow("client"); res.ThrottlingStarted {
+		t.Fatal("second rejected request started throttling again")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	l := New(1, 1)
+
+	l.Allow("client")
+	res := l.Allow("client")
+	if res.Allowed {
+		t.Fatal("request without tokens was allowed")
+	}
+	if res.RetryAfter <= 900 * time.Millisecond || res.RetryAfter > time.Second {
+		t.Fatalf("got retry after %v, want about 1s", res.RetryAfter)
+	}
+}
+
+func TestRefill(t *testing.T) {
+	l := New(100, 2)
+
+	if !l.AllowN("client", 2).Allowed {
+		t.Fatal("burst request was rejected")
+	}
+	if l.Allow("client").Allowed {
+		t.Fatal("request with an empty bucket was allowed")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	//the bucket is refilled but never above the burst
+	res := l.AllowN("client", 3)
+	if res.Allowed || res.Granted != 2 {
+		t.Fatalf("got allowed=%v granted=%v, want allowed=false granted=2", res.Allowed, res.Granted)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if !l.Allow("client").Allowed {
+		t.Fatal("request after a refill was rejected")
+	}
+}
+
+func TestRefund(t *testing.T) {
+	tests := []struct {
+		name    string
+		take    int
+		refund  int
+		allowed int
+	}{
+		{ "partial", 3, 2, 2 },
+		{ "never above the burst", 1, 10, 3 },
+		{ "nothing", 3, 0, 0 },
+		{ "negative", 3, -1, 0 },
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			l := New(0.001, 3)
+
+			l.AllowN("client", tc.take)
+			l.Refund("client", tc.refund)
+
+			if res := l.AllowN("client", 3); res.Granted != tc.allowed {
+				t.Fatalf("got %v tokens after the refund, want %v", res.Granted, tc.allowed)
+			}
+		})
+	}
+}
+
+func TestRefundUnknownClient(t *testing.T) {
+	l := New(0.001, 1)
+
+	l.Refund("client", 5)
+	if res := l.AllowN("client", 2); res.Granted != 1 {
+		t.Fatalf("got %v tokens, want 1", res.Granted)
+	}
+}
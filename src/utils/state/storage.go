@@ -16,6 +16,23 @@ const (
 	maxStateFileSize = 100 * 1048576
 )
 
+// LegacyHashCode is the hash code versions prior to the status API stored for every web, TCP port group and device.
+// The settings were passed to Sum instead of Write so nothing was actually hashed and states saved by those versions
+// must be migrated.
+const LegacyHashCode uint64 = 0xcbf29ce484222325
+
+//------------------------------------------------------------------------------
+
+// IsLegacyHashCode returns true if all the hash codes were stored by a version prior to the status API
+func IsLegacyHashCode(hashCodes []uint64) bool {
+	for _, h := range hashCodes {
+		if h != LegacyHashCode {
+			return false
+		}
+	}
+	return len(hashCodes) > 0
+}
+
 //------------------------------------------------------------------------------
 
 var createDirOnce struct {
@@ -55,20 +72,37 @@ func LoadStateBlob(filename string) ([]byte, error) {
 	return b, err
 }
 
+// SaveStateBlob replaces the state file. The blob is written to a temporary file first so a failure does not leave a
+// truncated state behind.
 func SaveStateBlob(filename string, blob []byte) error {
+	var f *os.File
+
 	dir, err := getConfigDir()
-	if err == nil {
-		f, err := os.OpenFile(dir + filename, os.O_CREATE|os.O_WRONLY, 0644)
-		if err == nil {
-			defer func() {
-				_ = f.Close()
-			}()
+	if err != nil {
+		return err
+	}
 
-			_, err = f.Write(blob)
-		}
+	tempFilename := dir + filename + ".tmp"
+
+	f, err = os.OpenFile(tempFilename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(blob)
+	if err == nil {
+		err = f.Sync()
+	}
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tempFilename, dir + filename)
 	}
+
 	if err != nil {
-		_ = os.Remove(dir + filename)
+		_ = os.Remove(tempFilename)
 	}
 	return err
 }
@@ -81,6 +115,11 @@ func DeleteStateBlob(filename string) {
 	return
 }
 
+// GetFolder returns the folder where state files are stored, for modules that manage their own files
+func GetFolder() (string, error) {
+	return getConfigDir()
+}
+
 //------------------------------------------------------------------------------
 
 func getConfigDir() (string, error) {
@@ -0,0 +1,136 @@
+package statetracker
+
+import (
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// Event is the result of feeding a check result to a tracker
+type Event int
+
+const (
+	EventNone Event = iota
+	EventDown
+	EventUp
+	EventFlappingStarted
+	EventFlappingStopped
+)
+
+// Tracker turns raw check results into a confirmed up/down state. The state only changes after a number of
+// consecutive failures or successes. If the confirmed state changes too often within a time window, the target is
+// considered to be flapping and no more up/down events are reported until it becomes stable again.
+type Tracker struct {
+	mtx                  sync.Mutex
+	failureThreshold     uint
+	successThreshold     uint
+	flapThreshold        uint
+	flapWindow           time.Duration
+	up                   bool
+	consecutiveFailures  uint
+	consecutiveSuccesses uint
+	changes              []time.Time
+	flapping             bool
+}
+
+//------------------------------------------------------------------------------
+
+// New creates a tracker in the up state. A flap threshold of zero disables flap detection.
+func New(failureThreshold uint, successThreshold uint, flapThreshold uint, flapWindow time.Duration) *Tracker {
+	if failureThreshold == 0 {
+		failureThreshold = 1
+	}
+	if successThreshold == 0 {
+		successThreshold = 1
+	}
+
+	return &Tracker{
+		failureThreshold: failureThreshold,
+		successThreshold: successThreshold,
+		flapThreshold:    flapThreshold,
+		flapWindow:       flapWindow,
+		up:               true,
+	}
+}
+
+// Update feeds the result of a check and returns the event it produced
+func (t *Tracker) Update(ok bool) Event {
+	var ev Event
+
+	now := time.Now()
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if ok {
+		t.consecutiveSuccesses += 1
+		t.consecutiveFailures = 0
+	} else {
+		t.consecutiveFailures += 1
+		t.consecutiveSuccesses = 0
+	}
+
+	if t.up && t.consecutiveFailures >= t.failureThreshold {
+		t.up = false
+		ev = EventDown
+	} else if !t.up && t.consecutiveSuccesses >= t.successThreshold {
+		t.up = true
+		ev = EventUp
+	}
+
+	if t.flapThreshold == 0 {
+		return ev
+	}
+
+	if ev != EventNone {
+		t.changes = append(t.changes, now)
+	}
+
+	//discard state changes outside the window
+	idx := 0
+	for idx < len(t.changes) && now.Sub(t.changes[idx]) > t.flapWindow {
+		idx++
+	}
+	t.changes = t.changes[idx:]
+
+	if !t.flapping {
+		if uint(len(t.changes)) >= t.flapThreshold {
+			t.flapping = true
+			return EventFlappingStarted
+		}
+	} else {
+		//stop flapping when the state did not change for a whole window
+		if len(t.changes) == 0 {
+			t.flapping = false
+			return EventFlappingStopped
+		}
+		return EventNone
+	}
+
+	return ev
+}
+
+// IsUp returns the confirmed state
+func (t *Tracker) IsUp() bool {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	return t.up
+}
+
+// SetUp sets the confirmed state, for e.g., when it is restored from a previous run
+func (t *Tracker) SetUp(up bool) {
+	t.mtx.Lock()
+	t.up = up
+	t.mtx.Unlock()
+	return
+}
+
+// IsFlapping returns true if the confirmed state is changing too often
+func (t *Tracker) IsFlapping() bool {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	return t.flapping
+}
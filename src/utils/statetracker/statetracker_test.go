@@ -0,0 +1,137 @@
+package statetracker
+
+import (
+	"testing"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+func TestThresholds(t *testing.T) {
+	tests := []struct {
+		name             string
+		failureThreshold uint
+		successThreshold uint
+		results          []bool
+		events           []Event
+		up               bool
+	}{
+		{
+			name:    "zero thresholds act as one",
+			results: []bool{ false, true },
+			events:  []Event{ EventDown, EventUp },
+			up:      true,
+		},
+		{
+			name:             "down after consecutive failures",
+			failureThreshold: 3,
+			results:          []bool{ false, false, false, false },
+			events:           []Event{ EventNone, EventNone, EventDown, EventNone },
+			up:               false,
+		},
+		{
+			name:             "a success resets the failure count",
+			failureThreshold: 2,
+			results:          []bool{ false, true, false, true },
+			events:           []Event{ EventNone, EventNone, EventNone, EventNone },
+			up:               true,
+		},
+		{
+			name:             "up after consecutive successes",
+			failureThreshold: 1,
+			successThreshold: 2,
+			results:          []bool{ false, true, false, true, true },
+			events:           []Event{ EventDown, EventNone, EventNone, EventNone, EventUp },
+			up:               true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := New(tc.failureThreshold, tc.successThreshold, 0, 0)
+			for idx, ok := range tc.results {
+				if ev := tr.Update(ok); ev != tc.events[idx] {
+					t.Fatalf("update #%d: got event %v, want %v", idx, ev, tc.events[idx])
+				}
+			}
+			if tr.IsUp() != tc.up {
+				t.Fatalf("got up=%v, want %v", tr.IsUp(), tc.up)
+			}
+			if tr.IsFlapping() {
+				t.Fatal("flapping with flap detection disabled")
+			}
+		})
+	}
+}
+
+func TestFlapping(t *testing.T) {
+	tests := []struct {
+		name          string
+		flapThreshold uint
+		results       []bool
+		events        []Event
+		flapping      bool
+	}{
+		{
+			name:          "starts after enough changes within the window",
+			flapThreshold: 3,
+			results:       []bool{ false, true, false },
+			events:        []Event{ EventDown, EventUp, EventFlappingStarted },
+			flapping:      true,
+		},
+		{
+			name:          "no up/down events while flapping",
+			flapThreshold: 2,
+			results:       []bool{ false, true, false, true },
+			events:        []Event{ EventDown, EventFlappingStarted, EventNone, EventNone },
+			flapping:      true,
+		},
+		{
+			name:          "not reached",
+			flapThreshold: 4,
+			results:       []bool{ false, true, false },
+			events:        []Event{ EventDown, EventUp, EventDown },
+			flapping:      false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := New(1, 1, tc.flapThreshold, time.Hour)
+			for idx, ok := range tc.results {
+				if ev := tr.Update(ok); ev != tc.events[idx] {
+					t.Fatalf("update #%d: got event %v, want %v", idx, ev, tc.events[idx])
+				}
+			}
+			if tr.IsFlapping() != tc.flapping {
+				t.Fatalf("got flapping=%v, want %v", tr.IsFlapping(), tc.flapping)
+			}
+		})
+	}
+}
+
+func TestFlappingStops(t *testing.T) {
+	tr := New(1, 1, 2, 50 * time.Millisecond)
+
+	tr.Update(false)
+	if ev := tr.Update(true); ev != EventFlappingStarted {
+		t.Fatalf("got event %v, want %v", ev, EventFlappingStarted)
+	}
+
+	//still within the window
+	if ev := tr.Update(true); ev != EventNone {
+		t.Fatalf("got event %v, want %v", ev, EventNone)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if ev := tr.Update(true); ev != EventFlappingStopped {
+		t.Fatalf("got event %v, want %v", ev, EventFlappingStopped)
+	}
+	if tr.IsFlapping() {
+		t.Fatal("still flapping after a stable window")
+	}
+	if !tr.IsUp() {
+		t.Fatal("got down, want up")
+	}
+}
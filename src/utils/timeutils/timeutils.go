@@ -0,0 +1,16 @@
+package timeutils
+
+import (
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// FromUnixNano converts a timestamp stored as Unix nanoseconds to UTC. Returns nil if zero, which means not set.
+func FromUnixNano(t int64) *time.Time {
+	if t == 0 {
+		return nil
+	}
+	tm := time.Unix(0, t).UTC()
+	return &tm
+}
@@ -0,0 +1,114 @@
+package ttlcache
+
+import (
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	pruneInterval = time.Minute
+)
+
+//------------------------------------------------------------------------------
+
+// Cache keeps values for a fixed amount of time after they are stored
+type Cache struct {
+	mtx       sync.Mutex
+	ttl       time.Duration
+	items     map[string]*item
+	lastPrune time.Time
+}
+
+type item struct {
+	value   interface{}
+	expires time.Time
+}
+
+//------------------------------------------------------------------------------
+
+// New creates a cache whose values expire after the given time
+func New(ttl time.Duration) *Cache {
+	return &Cache{
+		ttl:   ttl,
+		items: make(map[string]*item),
+	}
+}
+
+// Get returns the value stored for the key, if not expired
+func (c *Cache) Get(key string) (interface{}, bool) {
+	now := time.Now()
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.prune(now)
+
+	it, ok := c.items[key]
+	if !ok || now.After(it.expires) {
+		return nil, false
+	}
+	return it.value, true
+}
+
+// Set stores the value for the key. The expiration time starts again if the key already exists.
+func (c *Cache) Set(key string, value interface{}) {
+	now := time.Now()
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.prune(now)
+
+	c.items[key] = &item{
+		value:   value,
+		expires: now.Add(c.ttl),
+	}
+	return
+}
+
+// SetIfAbsent stores the value only if the key does not exist or is expired. If it exists, the current value is
+// returned along with false.
+func (c *Cache) SetIfAbsent(key string, value interface{}) (interface{}, bool) {
+	now := time.Now()
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.prune(now)
+
+	if it, ok := c.items[key]; ok && !now.After(it.expires) {
+		return it.value, false
+	}
+
+	c.items[key] = &item{
+		value:   value,
+		expires: now.Add(c.ttl),
+	}
+	return value, true
+}
+
+// Delete removes the key
+func (c *Cache) Delete(key string) {
+	c.mtx.Lock()
+	delete(c.items, key)
+	c.mtx.Unlock()
+	return
+}
+
+//------------------------------------------------------------------------------
+
+func (c *Cache) prune(now time.Time) {
+	if now.Sub(c.lastPrune) < pruneInterval {
+		return
+	}
+	c.lastPrune = now
+
+	for key, it := range c.items {
+		if now.After(it.expires) {
+			delete(c.items, key)
+		}
+	}
+	return
+}